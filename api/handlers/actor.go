@@ -1,44 +1,29 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"github.com/ast3am/VKintern-movies/internal/models"
 	"io"
 	"net/http"
-	"path"
 )
 
 // CreateActor godoc
 // @Summary Создание актера
-// @Description Создание актера, предполагается что все поля не пустые
+// @Description Создание актера, в ответе созданный актер с UUID, его адрес передается в заголовке Location
 // @Tags actor
 // @Accept json
 // @Produce json
 // @Param data body models.Actor true "Входные параметры"
-// @Success 200 {object} string
-// @Failure 400,401,405,422 {object} error
-// @Router /actor/create [post]
+// @Success 201 {object} models.ActorDetails
+// @Header 201 {string} Location "Адрес созданного актера"
+// @Failure 401,403,409,422,500 {object} Problem
+// @Router /api/v1/actors [post]
 // @Security ApiKeyAuth
 func (h *Handler) CreateActor(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		h.log.HandlerErrorLog(r, http.StatusMethodNotAllowed, "", errors.New(MethodNotAllowed))
-		http.Error(w, MethodNotAllowed, http.StatusMethodNotAllowed)
-		return
-	}
-
-	token := r.Header.Get("Authorization")
-	err := h.services.CheckToken(token, Admin)
-	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnauthorized, InvalidToken, err)
-		http.Error(w, InvalidToken, http.StatusUnauthorized)
-		return
-	}
-
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnprocessableEntity, UnprocessableEntity, err)
-		http.Error(w, UnprocessableEntity, http.StatusUnprocessableEntity)
+		h.problem(w, r, UnprocessableEntity, unreadableBody(err))
 		return
 	}
 	defer r.Body.Close()
@@ -46,103 +31,128 @@ func (h *Handler) CreateActor(w http.ResponseWriter, r *http.Request) {
 	var actor models.Actor
 	err = actor.UnmarshalJSON(body)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnprocessableEntity, ParsingJSONError, err)
-		http.Error(w, ParsingJSONError, http.StatusUnprocessableEntity)
+		h.problem(w, r, ParsingJSONError, invalidJSON(err))
 		return
 	}
 
-	err = h.services.CreateActor(r.Context(), actor)
+	result, err := h.services.CreateActor(r.Context(), actor)
 	if err != nil {
-		if err.Error() == UnprocessableEntity {
-			h.log.HandlerErrorLog(r, http.StatusUnprocessableEntity, "Create actor", err)
-			http.Error(w, UnprocessableEntity, http.StatusUnprocessableEntity)
-			return
-		} else {
-			h.log.HandlerErrorLog(r, http.StatusBadRequest, "Create actor", err)
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
+		h.problem(w, r, "Create actor", err)
+		return
 	}
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Actor created"))
-	h.log.HandlerLog(r, http.StatusOK, "Actor created")
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		h.problem(w, r, "Can't marshal result", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", apiV1+"/actors/"+result.ID.String())
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusCreated, "Actor created")
 }
 
 // GetActorsList godoc
 // @Summary Получение списка актеров
-// @Description Для каждого актера так же выдается список фильмов
+// @Description Страница списка актеров, отсортированного по имени. Для каждого актера так же выдается список фильмов, актеры без фильмов выдаются с пустым списком.
+// @Description Страница задается параметрами limit и cursor или page и per_page, ссылки на соседние страницы передаются в заголовке Link
 // @Tags actor
 // @Accept json
 // @Produce json
-// @Param uuid query string true "UUID актера"
-// @Success 200 {object} map[string][]string
-// @Failure 400,401,405 {object} error
-// @Failure 500 {object} error
-// @Router /actor/get-list [get]
+// @Param limit query int false "Размер страницы при выводе по курсору"
+// @Param cursor query string false "Курсор следующей страницы из next_cursor"
+// @Param page query int false "Номер страницы, начиная с 1"
+// @Param per_page query int false "Размер страницы при выводе по номеру страницы"
+// @Success 200 {object} models.ActorList
+// @Header 200 {string} Link "Ссылки first, prev, next, last"
+// @Failure 400,401,403,422 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/v1/actors [get]
 // @Security ApiKeyAuth
 func (h *Handler) GetActorsList(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		h.log.HandlerErrorLog(r, http.StatusMethodNotAllowed, "", errors.New(MethodNotAllowed))
-		http.Error(w, MethodNotAllowed, http.StatusMethodNotAllowed)
+	result, err := h.services.GetActorList(r.Context(), pageParams(r.URL.Query()))
+	if err != nil {
+		h.problem(w, r, "Get actor list", err)
 		return
 	}
-
-	token := r.Header.Get("Authorization")
-	err := h.services.CheckToken(token, User)
+	jsonData, err := json.Marshal(result)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnauthorized, InvalidToken, err)
-		http.Error(w, InvalidToken, http.StatusUnauthorized)
+		h.problem(w, r, "Can't marshal result", err)
 		return
 	}
+	setPageLinks(w, r, result.PageInfo)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusOK, "Actor list created")
+}
+
+// GetActorByID godoc
+// @Summary Получение актера
+// @Description Получение актера по UUID вместе со списком фильмов
+// @Tags actor
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID актера"
+// @Success 200 {object} models.ActorDetails
+// @Failure 400,401,403,404,500 {object} Problem
+// @Router /api/v1/actors/{id} [get]
+// @Security ApiKeyAuth
+func (h *Handler) GetActorByID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
 
-	result, err := h.services.GetActorList(r.Context())
+	result, err := h.services.GetActorByID(r.Context(), id)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusBadRequest, "Get actor list", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.problem(w, r, "Get actor", err)
 		return
 	}
 	jsonData, err := json.Marshal(result)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusInternalServerError, "Can't marshal result", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.problem(w, r, "Can't marshal result", err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write(jsonData)
-	h.log.HandlerLog(r, http.StatusOK, "Actor list created")
+	h.log.HandlerLog(r, http.StatusOK, "Get actor")
 }
 
 // UpdateActor godoc
 // @Summary Изменение информации об актере
-// @Description Информация может быть изменена как частично, так и полностью
+// @Description Частичное изменение, незаполненные поля сохраняют текущие значения
 // @Tags actor
 // @Accept json
 // @Produce json
+// @Param id path string true "UUID актера"
 // @Param data body models.Actor true "Входные параметры"
 // @Success 200 {object} string
-// @Failure 400,401,405,422 {object} error
-// @Router /actor/update/ [patch]
+// @Failure 400,401,403,404,409,422,500 {object} Problem
+// @Router /api/v1/actors/{id} [patch]
 // @Security ApiKeyAuth
 func (h *Handler) UpdateActor(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPatch {
-		h.log.HandlerErrorLog(r, http.StatusMethodNotAllowed, "", errors.New(MethodNotAllowed))
-		http.Error(w, MethodNotAllowed, http.StatusMethodNotAllowed)
-		return
-	}
+	h.saveActor(w, r, h.services.UpdateActor)
+}
 
-	token := r.Header.Get("Authorization")
-	err := h.services.CheckToken(token, Admin)
-	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnauthorized, InvalidToken, err)
-		http.Error(w, InvalidToken, http.StatusUnauthorized)
-		return
-	}
+// ReplaceActor godoc
+// @Summary Замена информации об актере
+// @Description Полная замена, незаполненные поля очищаются, имя обязательно
+// @Tags actor
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID актера"
+// @Param data body models.Actor true "Входные параметры"
+// @Success 200 {object} string
+// @Failure 400,401,403,404,409,422,500 {object} Problem
+// @Router /api/v1/actors/{id} [put]
+// @Security ApiKeyAuth
+func (h *Handler) ReplaceActor(w http.ResponseWriter, r *http.Request) {
+	h.saveActor(w, r, h.services.ReplaceActor)
+}
 
+func (h *Handler) saveActor(w http.ResponseWriter, r *http.Request, save func(ctx context.Context, id string, actor models.Actor) error) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnprocessableEntity, UnprocessableEntity, err)
-		http.Error(w, UnprocessableEntity, http.StatusUnprocessableEntity)
+		h.problem(w, r, UnprocessableEntity, unreadableBody(err))
 		return
 	}
 	defer r.Body.Close()
@@ -150,17 +160,15 @@ func (h *Handler) UpdateActor(w http.ResponseWriter, r *http.Request) {
 	var actor models.Actor
 	err = actor.UnmarshalJSON(body)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnprocessableEntity, ParsingJSONError, err)
-		http.Error(w, ParsingJSONError, http.StatusUnprocessableEntity)
+		h.problem(w, r, ParsingJSONError, invalidJSON(err))
 		return
 	}
 
-	id := path.Base(r.URL.String())
+	id := r.PathValue("id")
 
-	err = h.services.UpdateActor(r.Context(), id, actor)
+	err = save(r.Context(), id, actor)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusBadRequest, "Update actor", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.problem(w, r, "Update actor", err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -174,32 +182,17 @@ func (h *Handler) UpdateActor(w http.ResponseWriter, r *http.Request) {
 // @Tags actor
 // @Accept json
 // @Produce json
-// @Param uuid query string true "UUID актера"
+// @Param id path string true "UUID актера"
 // @Success 200 {object} string
-// @Failure 400,401,405 {object} error
-// @Router /actor/delete [delete]
+// @Failure 400,401,403,404,500 {object} Problem
+// @Router /api/v1/actors/{id} [delete]
 // @Security ApiKeyAuth
 func (h *Handler) DeleteActor(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		h.log.HandlerErrorLog(r, http.StatusMethodNotAllowed, "", errors.New(MethodNotAllowed))
-		http.Error(w, MethodNotAllowed, http.StatusMethodNotAllowed)
-		return
-	}
-
-	token := r.Header.Get("Authorization")
-	err := h.services.CheckToken(token, Admin)
-	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnauthorized, InvalidToken, err)
-		http.Error(w, InvalidToken, http.StatusUnauthorized)
-		return
-	}
-
-	id := path.Base(r.URL.String())
+	id := r.PathValue("id")
 
-	err = h.services.DeleteActor(r.Context(), id)
+	err := h.services.DeleteActor(r.Context(), id)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusBadRequest, "Delete actor", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.problem(w, r, "Delete actor", err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
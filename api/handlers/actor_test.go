@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"errors"
 	"github.com/ast3am/VKintern-movies/api/handlers/mocks"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestHandler_CreateActor(t *testing.T) {
@@ -28,64 +31,75 @@ func TestHandler_CreateActor(t *testing.T) {
 		{
 			"wrong method",
 			[]byte(`{"name": "Tom Hanks", "gender": "male", "birth_date": "2000-01-01"}`),
-			http.MethodGet,
+			http.MethodDelete,
 			http.StatusMethodNotAllowed,
-			[]byte(`invalid request method` + "\n"),
+			[]byte(problemBody("/api/v1/actors", http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed)),
 		}, {
 			"wrong token",
 			[]byte(`{"name": "Tom Hanks", "gender": "male", "birth_date": "2000-01-01"}`),
 			http.MethodPost,
 			http.StatusUnauthorized,
-			[]byte(`invalid token` + "\n"),
+			[]byte(problemBody("/api/v1/actors", http.StatusUnauthorized, "invalid_token", InvalidToken)),
 		}, {
 			"wrong json",
 			[]byte(`"some data"`),
 			http.MethodPost,
 			http.StatusUnprocessableEntity,
-			[]byte(`JSON parsing error` + "\n"),
+			[]byte(problemBody("/api/v1/actors", http.StatusUnprocessableEntity, "invalid_json", ParsingJSONError)),
 		}, {
 			"no valid data",
 			[]byte(`{"name": "", "gender": "male", "birth_date": "2000-01-01"}`),
 			http.MethodPost,
 			http.StatusUnprocessableEntity,
-			[]byte(`no valid data` + "\n"),
+			[]byte(problemBody("/api/v1/actors", http.StatusUnprocessableEntity, models.CodeValidation, "validation failed", models.FieldError{Field: "name", Message: "must not be empty"})),
 		}, {
-			"another error service",
+			"actor exists",
 			[]byte(`{"name": "Tom Hanks", "gender": "male", "birth_date": "2000-01-01"}`),
 			http.MethodPost,
-			http.StatusBadRequest,
-			[]byte(`service error` + "\n"),
+			http.StatusConflict,
+			[]byte(problemBody("/api/v1/actors", http.StatusConflict, "actor_exists", models.ErrActorExists.Error())),
 		}, {
 			"positive",
 			[]byte(`{"name": "Tom Hanks", "gender": "male", "birth_date": "2000-01-01"}`),
 			http.MethodPost,
-			http.StatusOK,
-			[]byte(`Actor created`),
+			http.StatusCreated,
+			[]byte(`{"id":"40d882f7-b027-4a07-85da-76e0f7d9b6e3","name":"Tom Hanks","gender":"male","birth_date":"2000-01-01T00:00:00Z","filmography":[],` +
+				`"created_at":"2024-03-16T10:00:00Z","updated_at":"2024-03-16T10:00:00Z"}`),
 		},
 	}
+	created := &models.ActorDetails{
+		ID:          uuid.MustParse("40d882f7-b027-4a07-85da-76e0f7d9b6e3"),
+		Name:        "Tom Hanks",
+		Gender:      "male",
+		BirthDate:   GetDate("2000-01-01"),
+		Filmography: []models.ActorMovie{},
+		CreatedAt:   time.Date(2024, time.March, 16, 10, 0, 0, 0, time.UTC),
+		UpdatedAt:   time.Date(2024, time.March, 16, 10, 0, 0, 0, time.UTC),
+	}
 	for _, test := range testTable {
 		if test.name == "wrong method" {
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(MethodNotAllowed)).Return(0)
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "", errMethodNotAllowed).Return(0)
 		} else if test.name == "wrong token" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(errors.New(InvalidToken)).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(InvalidToken)).Return(0)
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(nil, models.ErrInvalidToken).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrInvalidToken).Return(0)
 		} else if test.name == "wrong json" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), mock.AnythingOfType("*json.UnmarshalTypeError")).Return(0)
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil)
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errInvalidJSON)).Return(0)
 		} else if test.name == "no valid data" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
-			serv.On("CreateActor", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("models.Actor")).Return(errors.New(UnprocessableEntity)).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(UnprocessableEntity)).Return(0)
-		} else if test.name == "another error service" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
-			serv.On("CreateActor", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("models.Actor")).Return(errors.New("service error")).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New("service error")).Return(0)
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil)
+			validationErr := &models.ValidationError{Fields: []models.FieldError{{Field: "name", Message: "must not be empty"}}}
+			serv.On("CreateActor", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("models.Actor")).Return(nil, validationErr).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), validationErr).Return(0)
+		} else if test.name == "actor exists" {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil)
+			serv.On("CreateActor", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("models.Actor")).Return(nil, models.ErrActorExists).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrActorExists).Return(0)
 		} else if test.name == "positive" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
-			serv.On("CreateActor", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("models.Actor")).Return(nil).Once()
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil)
+			serv.On("CreateActor", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("models.Actor")).Return(created, nil).Once()
 			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0)
 		}
-		req, err := http.NewRequest(test.httpMethod, "/actor/create", bytes.NewBuffer(test.requestBody))
+		req, err := http.NewRequest(test.httpMethod, "/api/v1/actors", bytes.NewBuffer(test.requestBody))
 		req.Header.Set("Authorization", "Test-token")
 		r := httptest.NewRecorder()
 		mux.ServeHTTP(r, req)
@@ -94,6 +108,9 @@ func TestHandler_CreateActor(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, test.expectedStatusCode, r.Code)
 		assert.Equal(t, test.expectedResponseBody, responseBody)
+		if test.name == "positive" {
+			assert.Equal(t, "/api/v1/actors/40d882f7-b027-4a07-85da-76e0f7d9b6e3", r.Header().Get("Location"))
+		}
 	}
 }
 
@@ -112,43 +129,53 @@ func TestHandler_GetActorsList(t *testing.T) {
 	}{
 		{
 			"wrong method",
-			http.MethodPost,
+			http.MethodDelete,
 			http.StatusMethodNotAllowed,
-			[]byte(`invalid request method` + "\n"),
+			[]byte(problemBody("/api/v1/actors", http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed)),
 		}, {
 			"wrong token",
 			http.MethodGet,
 			http.StatusUnauthorized,
-			[]byte(`invalid token` + "\n"),
+			[]byte(problemBody("/api/v1/actors", http.StatusUnauthorized, "invalid_token", InvalidToken)),
 		}, {
 			"service problem",
 			http.MethodGet,
-			http.StatusBadRequest,
-			[]byte(`service problem` + "\n"),
+			http.StatusInternalServerError,
+			[]byte(problemBody("/api/v1/actors", http.StatusInternalServerError, "internal_error", InternalError)),
 		}, {
 			"positive",
 			http.MethodGet,
 			http.StatusOK,
-			[]byte(`{"boris":["snatch"],"jhon":["badBoys2","badBoys"],"suize":["badBoys","hatiko"]}`),
+			[]byte(`{"actors":[{"id":"eb1b5f32-82c3-4dfb-aad2-9432908d12b7","name":"boris","gender":"male","birth_date":"0001-01-01T00:00:00Z",` +
+				`"filmography":[{"id":"a5e85bc9-5e75-4e5f-b890-0b156e15e40f","name":"snatch","release_date":"2000-08-23T00:00:00Z"}],` +
+				`"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}],"total":3,"page":2,"per_page":1}`),
 		},
 	}
 	for _, test := range testTable {
 		if test.name == "wrong method" {
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(MethodNotAllowed)).Return(0).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "", errMethodNotAllowed).Return(0)
 		} else if test.name == "wrong token" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(errors.New(InvalidToken)).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(InvalidToken)).Return(0).Once()
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(nil, models.ErrInvalidToken).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrInvalidToken).Return(0).Once()
 		} else if test.name == "service problem" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil).Once()
-			serv.On("GetActorList", mock.AnythingOfType("context.backgroundCtx")).Return(nil, errors.New("service problem")).Once()
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil).Once()
+			serv.On("GetActorList", mock.AnythingOfType("*context.valueCtx"), models.PageParams{Page: "2", PerPage: "1"}).Return(nil, errors.New("service problem")).Once()
 			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New("service problem")).Return(0).Once()
 		} else if test.name == "positive" {
-			result := map[string][]string{"boris": {"snatch"}, "jhon": {"badBoys2", "badBoys"}, "suize": {"badBoys", "hatiko"}}
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil).Once()
-			serv.On("GetActorList", mock.AnythingOfType("context.backgroundCtx")).Return(result, nil).Once()
+			result := &models.ActorList{
+				Actors: []*models.ActorDetails{{
+					ID:          uuid.MustParse("eb1b5f32-82c3-4dfb-aad2-9432908d12b7"),
+					Name:        "boris",
+					Gender:      "male",
+					Filmography: []models.ActorMovie{{ID: uuid.MustParse("a5e85bc9-5e75-4e5f-b890-0b156e15e40f"), Name: "snatch", ReleaseDate: GetDate("2000-08-23")}},
+				}},
+				PageInfo: models.PageInfo{Total: 3, Page: 2, PerPage: 1},
+			}
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil).Once()
+			serv.On("GetActorList", mock.AnythingOfType("*context.valueCtx"), models.PageParams{Page: "2", PerPage: "1"}).Return(result, nil).Once()
 			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
 		}
-		req, err := http.NewRequest(test.httpMethod, "/actor/get-list", nil)
+		req, err := http.NewRequest(test.httpMethod, "/api/v1/actors?page=2&per_page=1", nil)
 		req.Header.Set("Authorization", "Test-token")
 		r := httptest.NewRecorder()
 		mux.ServeHTTP(r, req)
@@ -156,6 +183,75 @@ func TestHandler_GetActorsList(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, test.expectedStatusCode, r.Code)
 		assert.Equal(t, test.expectedResponseBody, responseBody)
+		if test.name == "positive" {
+			assert.Equal(t, []string{
+				`</api/v1/actors?page=1&per_page=1>; rel="first"`,
+				`</api/v1/actors?page=1&per_page=1>; rel="prev"`,
+				`</api/v1/actors?page=3&per_page=1>; rel="next"`,
+				`</api/v1/actors?page=3&per_page=1>; rel="last"`,
+			}, r.Header().Values("Link"))
+		}
+	}
+}
+
+func TestHandler_GetActorByID(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+	id := "40d882f7-b027-4a07-85da-76e0f7d9b6e3"
+	movieID := uuid.MustParse("a5e85bc9-5e75-4e5f-b890-0b156e15e40f")
+	actor := &models.ActorDetails{
+		ID:          uuid.MustParse(id),
+		Name:        "Brad Pitt",
+		Gender:      "Male",
+		BirthDate:   GetDate("1963-12-18"),
+		Filmography: []models.ActorMovie{{ID: movieID, Name: "Training Day", ReleaseDate: GetDate("2001-10-05")}},
+	}
+	testTable := []struct {
+		name                 string
+		id                   string
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			"invalid id",
+			"40d882f7",
+			http.StatusBadRequest,
+			problemBody("/api/v1/actors/40d882f7", http.StatusBadRequest, "invalid_id", models.ErrInvalidID.Error()),
+		}, {
+			"not found",
+			id,
+			http.StatusNotFound,
+			problemBody("/api/v1/actors/"+id, http.StatusNotFound, "actor_not_found", models.ErrActorNotFound.Error()),
+		}, {
+			"positive",
+			id,
+			http.StatusOK,
+			`{"id":"40d882f7-b027-4a07-85da-76e0f7d9b6e3","name":"Brad Pitt","gender":"Male","birth_date":"1963-12-18T00:00:00Z",` +
+				`"filmography":[{"id":"a5e85bc9-5e75-4e5f-b890-0b156e15e40f","name":"Training Day","release_date":"2001-10-05T00:00:00Z"}],` +
+				`"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}`,
+		},
+	}
+	serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(testPrincipal, nil)
+	for _, test := range testTable {
+		if test.name == "invalid id" {
+			serv.On("GetActorByID", mock.AnythingOfType("*context.valueCtx"), test.id).Return(nil, models.ErrInvalidID).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "Get actor", models.ErrInvalidID).Return(0).Once()
+		} else if test.name == "not found" {
+			serv.On("GetActorByID", mock.AnythingOfType("*context.valueCtx"), test.id).Return(nil, models.ErrActorNotFound).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "Get actor", models.ErrActorNotFound).Return(0).Once()
+		} else if test.name == "positive" {
+			serv.On("GetActorByID", mock.AnythingOfType("*context.valueCtx"), test.id).Return(actor, nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "Get actor").Return(0).Once()
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/actors/"+test.id, nil)
+		req.Header.Set("Authorization", "Test-token")
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, r.Body.String(), test.name)
 	}
 }
 
@@ -177,19 +273,19 @@ func TestHandler_DeleteActor(t *testing.T) {
 			http.MethodPost,
 			"40d882f7-b027-4a07-85da-76e0f7d9b6e3",
 			http.StatusMethodNotAllowed,
-			[]byte(`invalid request method` + "\n"),
+			[]byte(problemBody("/api/v1/actors/40d882f7-b027-4a07-85da-76e0f7d9b6e3", http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed)),
 		}, {
 			"wrong token",
 			http.MethodDelete,
 			"40d882f7-b027-4a07-85da-76e0f7d9b6e3",
 			http.StatusUnauthorized,
-			[]byte(`invalid token` + "\n"),
+			[]byte(problemBody("/api/v1/actors/40d882f7-b027-4a07-85da-76e0f7d9b6e3", http.StatusUnauthorized, "invalid_token", InvalidToken)),
 		}, {
-			"service problem",
+			"not found",
 			http.MethodDelete,
 			"40d882f7-b027-4a07-85da-76e0f7d9b6e3",
-			http.StatusBadRequest,
-			[]byte(`service problem` + "\n"),
+			http.StatusNotFound,
+			[]byte(problemBody("/api/v1/actors/40d882f7-b027-4a07-85da-76e0f7d9b6e3", http.StatusNotFound, "actor_not_found", models.ErrActorNotFound.Error())),
 		}, {
 			"positive",
 			http.MethodDelete,
@@ -200,20 +296,20 @@ func TestHandler_DeleteActor(t *testing.T) {
 	}
 	for _, test := range testTable {
 		if test.name == "wrong method" {
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(MethodNotAllowed)).Return(0).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "", errMethodNotAllowed).Return(0)
 		} else if test.name == "wrong token" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(errors.New(InvalidToken)).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(InvalidToken)).Return(0).Once()
-		} else if test.name == "service problem" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil).Once()
-			serv.On("DeleteActor", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(errors.New("service problem")).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New("service problem")).Return(0).Once()
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(nil, models.ErrInvalidToken).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrInvalidToken).Return(0).Once()
+		} else if test.name == "not found" {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil).Once()
+			serv.On("DeleteActor", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("string")).Return(models.ErrActorNotFound).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrActorNotFound).Return(0).Once()
 		} else if test.name == "positive" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil).Once()
-			serv.On("DeleteActor", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(nil).Once()
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil).Once()
+			serv.On("DeleteActor", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("string")).Return(nil).Once()
 			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
 		}
-		req, err := http.NewRequest(test.httpMethod, "/actor/delete/"+test.id, nil)
+		req, err := http.NewRequest(test.httpMethod, "/api/v1/actors/"+test.id, nil)
 		req.Header.Set("Authorization", "Test-token")
 		r := httptest.NewRecorder()
 		mux.ServeHTTP(r, req)
@@ -242,31 +338,31 @@ func TestHandler_UpdateActor(t *testing.T) {
 		{
 			"wrong method",
 			[]byte(`{"name": "Tom Banks", "gender": "female", "birth_date": "2000-01-01"}`),
-			http.MethodGet,
+			http.MethodPost,
 			"40d882f7-b027-4a07-85da-76e0f7d9b6e3",
 			http.StatusMethodNotAllowed,
-			[]byte(`invalid request method` + "\n"),
+			[]byte(problemBody("/api/v1/actors/40d882f7-b027-4a07-85da-76e0f7d9b6e3", http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed)),
 		}, {
 			"wrong token",
 			[]byte(`{"name": "Tom Banks", "gender": "female", "birth_date": "2000-01-01"}`),
 			http.MethodPatch,
 			"40d882f7-b027-4a07-85da-76e0f7d9b6e3",
 			http.StatusUnauthorized,
-			[]byte(`invalid token` + "\n"),
+			[]byte(problemBody("/api/v1/actors/40d882f7-b027-4a07-85da-76e0f7d9b6e3", http.StatusUnauthorized, "invalid_token", InvalidToken)),
 		}, {
 			"wrong json",
 			[]byte(`"some data"`),
 			http.MethodPatch,
 			"40d882f7-b027-4a07-85da-76e0f7d9b6e3",
 			http.StatusUnprocessableEntity,
-			[]byte(`JSON parsing error` + "\n"),
+			[]byte(problemBody("/api/v1/actors/40d882f7-b027-4a07-85da-76e0f7d9b6e3", http.StatusUnprocessableEntity, "invalid_json", ParsingJSONError)),
 		}, {
 			"service error",
 			[]byte(`{"name": "Tom Banks", "gender": "female", "birth_date": "2000-01-01"}`),
 			http.MethodPatch,
 			"40d882f7-b027-4a07-85da-76e0f7d9b6e3",
-			http.StatusBadRequest,
-			[]byte(`service error` + "\n"),
+			http.StatusInternalServerError,
+			[]byte(problemBody("/api/v1/actors/40d882f7-b027-4a07-85da-76e0f7d9b6e3", http.StatusInternalServerError, "internal_error", InternalError)),
 		}, {
 			"positive",
 			[]byte(`{"name": "Tom Banks", "gender": "female", "birth_date": "2000-01-01"}`),
@@ -278,23 +374,23 @@ func TestHandler_UpdateActor(t *testing.T) {
 	}
 	for _, test := range testTable {
 		if test.name == "wrong method" {
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(MethodNotAllowed)).Return(0)
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "", errMethodNotAllowed).Return(0)
 		} else if test.name == "wrong token" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(errors.New(InvalidToken)).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(InvalidToken)).Return(0)
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(nil, models.ErrInvalidToken).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrInvalidToken).Return(0)
 		} else if test.name == "wrong json" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), mock.AnythingOfType("*json.UnmarshalTypeError")).Return(0)
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil)
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errInvalidJSON)).Return(0)
 		} else if test.name == "service error" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
-			serv.On("UpdateActor", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("models.Actor")).Return(errors.New("service error")).Once()
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil)
+			serv.On("UpdateActor", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("models.Actor")).Return(errors.New("service error")).Once()
 			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New("service error")).Return(0)
 		} else if test.name == "positive" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
-			serv.On("UpdateActor", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("models.Actor")).Return(nil).Once()
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil)
+			serv.On("UpdateActor", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("models.Actor")).Return(nil).Once()
 			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0)
 		}
-		req, err := http.NewRequest(test.httpMethod, "/actor/update/"+test.id, bytes.NewBuffer(test.requestBody))
+		req, err := http.NewRequest(test.httpMethod, "/api/v1/actors/"+test.id, bytes.NewBuffer(test.requestBody))
 		req.Header.Set("Authorization", "Test-token")
 		r := httptest.NewRecorder()
 		mux.ServeHTTP(r, req)
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+type RolesDTO struct {
+	Roles []string `json:"roles"`
+}
+
+type PasswordDTO struct {
+	Password string `json:"password"`
+}
+
+// GetUserList godoc
+// @Summary Получение списка пользователей
+// @Description Список пользователей с ролями, отсортированный по email, limit от 1 до 100 (по умолчанию 20)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param limit query int false "Количество пользователей"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} models.UserList
+// @Failure 400,401,403,422 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/v1/admin/users [get]
+// @Security ApiKeyAuth
+func (h *Handler) GetUserList(w http.ResponseWriter, r *http.Request) {
+	limit := r.URL.Query().Get("limit")
+	offset := r.URL.Query().Get("offset")
+	result, err := h.services.GetUserList(r.Context(), limit, offset)
+	if err != nil {
+		h.problem(w, r, "Get user list", err)
+		return
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		h.problem(w, r, "Can't marshal result", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusOK, "Get user list")
+}
+
+// GetUser godoc
+// @Summary Получение пользователя
+// @Description Получение пользователя с ролями по UUID
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID пользователя"
+// @Success 200 {object} models.User
+// @Failure 400,401,403,404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/v1/admin/users/{id} [get]
+// @Security ApiKeyAuth
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	result, err := h.services.GetUser(r.Context(), id)
+	if err != nil {
+		h.problem(w, r, "Get user", err)
+		return
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		h.problem(w, r, "Can't marshal result", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusOK, "Get user")
+}
+
+// SetUserRoles godoc
+// @Summary Изменение ролей пользователя
+// @Description Замена списка ролей пользователя, все токены пользователя отзываются
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID пользователя"
+// @Param data body RolesDTO true "Входные параметры"
+// @Success 200 {object} string
+// @Failure 400,401,403,404,422,500 {object} Problem
+// @Router /api/v1/admin/users/{id}/roles [patch]
+// @Security ApiKeyAuth
+func (h *Handler) SetUserRoles(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.problem(w, r, UnprocessableEntity, unreadableBody(err))
+		return
+	}
+	defer r.Body.Close()
+
+	var roles RolesDTO
+	err = json.Unmarshal(body, &roles)
+	if err != nil {
+		h.problem(w, r, ParsingJSONError, invalidJSON(err))
+		return
+	}
+
+	id := r.PathValue("id")
+
+	err = h.services.SetUserRoles(r.Context(), id, roles.Roles)
+	if err != nil {
+		h.problem(w, r, "Set user roles", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("User roles updated"))
+	h.log.HandlerLog(r, http.StatusOK, "User roles updated")
+}
+
+// DisableUser godoc
+// @Summary Блокировка пользователя
+// @Description Заблокированный пользователь не может войти в систему, все его токены отзываются
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID пользователя"
+// @Success 200 {object} string
+// @Failure 400,401,403,404,500 {object} Problem
+// @Router /api/v1/admin/users/{id}/disable [post]
+// @Security ApiKeyAuth
+func (h *Handler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	err := h.services.DisableUser(r.Context(), id)
+	if err != nil {
+		h.problem(w, r, "Disable user", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("User disabled"))
+	h.log.HandlerLog(r, http.StatusOK, "User disabled")
+}
+
+// EnableUser godoc
+// @Summary Разблокировка пользователя
+// @Description Снятие блокировки пользователя
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID пользователя"
+// @Success 200 {object} string
+// @Failure 400,401,403,404,500 {object} Problem
+// @Router /api/v1/admin/users/{id}/enable [post]
+// @Security ApiKeyAuth
+func (h *Handler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	err := h.services.EnableUser(r.Context(), id)
+	if err != nil {
+		h.problem(w, r, "Enable user", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("User enabled"))
+	h.log.HandlerLog(r, http.StatusOK, "User enabled")
+}
+
+// ResetUserPassword godoc
+// @Summary Сброс пароля пользователя
+// @Description Установка нового пароля пользователю, все его токены отзываются
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID пользователя"
+// @Param data body PasswordDTO true "Входные параметры"
+// @Success 200 {object} string
+// @Failure 400,401,403,404,422,500 {object} Problem
+// @Router /api/v1/admin/users/{id}/reset-password [post]
+// @Security ApiKeyAuth
+func (h *Handler) ResetUserPassword(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.problem(w, r, UnprocessableEntity, unreadableBody(err))
+		return
+	}
+	defer r.Body.Close()
+
+	var password PasswordDTO
+	err = json.Unmarshal(body, &password)
+	if err != nil {
+		h.problem(w, r, ParsingJSONError, invalidJSON(err))
+		return
+	}
+
+	id := r.PathValue("id")
+
+	err = h.services.ResetUserPassword(r.Context(), id, password.Password)
+	if err != nil {
+		h.problem(w, r, "Reset user password", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("User password reset"))
+	h.log.HandlerLog(r, http.StatusOK, "User password reset")
+}
+
+// DeleteUser godoc
+// @Summary Удаление пользователя
+// @Description Полное удаление пользователя по UUID вместе с его токенами
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID пользователя"
+// @Success 200 {object} string
+// @Failure 400,401,403,404,500 {object} Problem
+// @Router /api/v1/admin/users/{id} [delete]
+// @Security ApiKeyAuth
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	err := h.services.DeleteUser(r.Context(), id)
+	if err != nil {
+		h.problem(w, r, "Delete user", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("User deleted"))
+	h.log.HandlerLog(r, http.StatusOK, "User deleted")
+}
+
+// RevokeUserTokens godoc
+// @Summary Отзыв токенов пользователя
+// @Description Отзыв всех access и refresh токенов, выпущенных пользователю, по UUID
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID пользователя"
+// @Success 200 {object} string
+// @Failure 400,401,403,404,500 {object} Problem
+// @Router /api/v1/admin/users/{id}/revoke-tokens [post]
+// @Security ApiKeyAuth
+func (h *Handler) RevokeUserTokens(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	err := h.services.RevokeUserTokens(r.Context(), id)
+	if err != nil {
+		h.problem(w, r, "Revoke user tokens", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("User tokens revoked"))
+	h.log.HandlerLog(r, http.StatusOK, "User tokens revoked")
+}
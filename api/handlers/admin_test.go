@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"github.com/ast3am/VKintern-movies/api/handlers/mocks"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_RevokeUserTokens(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+	id := "2300a1f6-b2aa-4f5b-b6ca-8f495582e255"
+
+	testTable := []struct {
+		name                 string
+		httpMethod           string
+		expectedStatusCode   int
+		expectedResponseBody []byte
+	}{
+		{
+			"wrong method",
+			http.MethodGet,
+			http.StatusMethodNotAllowed,
+			[]byte(problemBody("/api/v1/admin/users/"+id+"/revoke-tokens", http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed)),
+		}, {
+			"wrong token",
+			http.MethodPost,
+			http.StatusUnauthorized,
+			[]byte(problemBody("/api/v1/admin/users/"+id+"/revoke-tokens", http.StatusUnauthorized, "invalid_token", InvalidToken)),
+		}, {
+			"user not found",
+			http.MethodPost,
+			http.StatusNotFound,
+			[]byte(problemBody("/api/v1/admin/users/"+id+"/revoke-tokens", http.StatusNotFound, "user_not_found", models.ErrUserNotFound.Error())),
+		}, {
+			"another error service",
+			http.MethodPost,
+			http.StatusInternalServerError,
+			[]byte(problemBody("/api/v1/admin/users/"+id+"/revoke-tokens", http.StatusInternalServerError, "internal_error", InternalError)),
+		}, {
+			"positive",
+			http.MethodPost,
+			http.StatusOK,
+			[]byte(`User tokens revoked`),
+		},
+	}
+	for _, test := range testTable {
+		if test.name == "wrong method" {
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "", errMethodNotAllowed).Return(0)
+		} else if test.name == "wrong token" {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(nil, models.ErrInvalidToken).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrInvalidToken).Return(0).Once()
+		} else if test.name == "user not found" {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(testPrincipal, nil).Once()
+			serv.On("RevokeUserTokens", mock.AnythingOfType("*context.valueCtx"), id).Return(models.ErrUserNotFound).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrUserNotFound).Return(0).Once()
+		} else if test.name == "another error service" {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(testPrincipal, nil).Once()
+			serv.On("RevokeUserTokens", mock.AnythingOfType("*context.valueCtx"), id).Return(errors.New("service error")).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New("service error")).Return(0).Once()
+		} else if test.name == "positive" {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(testPrincipal, nil).Once()
+			serv.On("RevokeUserTokens", mock.AnythingOfType("*context.valueCtx"), id).Return(nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
+		}
+		req, err := http.NewRequest(test.httpMethod, "/api/v1/admin/users/"+id+"/revoke-tokens", bytes.NewBuffer(nil))
+		req.Header.Set("Authorization", "Test-token")
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+		responseBody := r.Body.Bytes()
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, responseBody, test.name)
+	}
+}
+
+func TestHandler_GetUserList(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+	id := uuid.MustParse("2300a1f6-b2aa-4f5b-b6ca-8f495582e255")
+
+	testTable := []struct {
+		name                 string
+		url                  string
+		httpMethod           string
+		expectedStatusCode   int
+		expectedResponseBody []byte
+	}{
+		{
+			"wrong method",
+			"/api/v1/admin/users",
+			http.MethodPut,
+			http.StatusMethodNotAllowed,
+			[]byte(problemBody("/api/v1/admin/users", http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed)),
+		}, {
+			"wrong pagination",
+			"/api/v1/admin/users?limit=1000",
+			http.MethodGet,
+			http.StatusUnprocessableEntity,
+			[]byte(problemBody("/api/v1/admin/users", http.StatusUnprocessableEntity, "invalid_pagination", models.ErrInvalidPagination.Error())),
+		}, {
+			"positive",
+			"/api/v1/admin/users?limit=1&offset=2",
+			http.MethodGet,
+			http.StatusOK,
+			[]byte(`{"users":[{"id":"2300a1f6-b2aa-4f5b-b6ca-8f495582e255","email":"testuser@mail.com","roles":["user"],"disabled":false}],"total":3,"limit":1,"offset":2}`),
+		},
+	}
+	for _, test := range testTable {
+		if test.name == "wrong method" {
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "", errMethodNotAllowed).Return(0)
+		} else if test.name == "wrong pagination" {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(testPrincipal, nil).Once()
+			serv.On("GetUserList", mock.AnythingOfType("*context.valueCtx"), "1000", "").Return(nil, models.ErrInvalidPagination).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrInvalidPagination).Return(0).Once()
+		} else if test.name == "positive" {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(testPrincipal, nil).Once()
+			serv.On("GetUserList", mock.AnythingOfType("*context.valueCtx"), "1", "2").Return(&models.UserList{
+				Users:  []*models.User{{ID: id, Email: "testuser@mail.com", Password: "hash", Roles: []string{"user"}}},
+				Total:  3,
+				Limit:  1,
+				Offset: 2,
+			}, nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
+		}
+		req, err := http.NewRequest(test.httpMethod, test.url, nil)
+		req.Header.Set("Authorization", "Test-token")
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+		responseBody := r.Body.Bytes()
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, responseBody, test.name)
+	}
+}
+
+func TestHandler_GetUser(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+	id := "2300a1f6-b2aa-4f5b-b6ca-8f495582e255"
+
+	serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(testPrincipal, nil)
+	//not found
+	serv.On("GetUser", mock.AnythingOfType("*context.valueCtx"), id).Return(nil, models.ErrUserNotFound).Once()
+	log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), http.StatusNotFound, mock.AnythingOfType("string"), models.ErrUserNotFound).Return(0).Once()
+	req, err := http.NewRequest(http.MethodGet, "/api/v1/admin/users/"+id+"?fields=all", nil)
+	assert.Nil(t, err)
+	req.Header.Set("Authorization", "Test-token")
+	r := httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusNotFound, r.Code)
+
+	//positive, пароль не попадает в ответ
+	serv.On("GetUser", mock.AnythingOfType("*context.valueCtx"), id).Return(&models.User{ID: uuid.MustParse(id), Email: "testuser@mail.com", Password: "hash", Roles: []string{"user"}, Disabled: true}, nil).Once()
+	log.On("HandlerLog", mock.AnythingOfType("*http.Request"), http.StatusOK, mock.AnythingOfType("string")).Return(0).Once()
+	r = httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, `{"id":"2300a1f6-b2aa-4f5b-b6ca-8f495582e255","email":"testuser@mail.com","roles":["user"],"disabled":true}`, r.Body.String())
+}
+
+func TestHandler_SetUserRoles(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+	id := "2300a1f6-b2aa-4f5b-b6ca-8f495582e255"
+
+	testTable := []struct {
+		name                 string
+		requestBody          []byte
+		serviceErr           error
+		expectedStatusCode   int
+		expectedResponseBody []byte
+	}{
+		{
+			"wrong json",
+			[]byte(`"some data"`),
+			nil,
+			http.StatusUnprocessableEntity,
+			[]byte(problemBody("/api/v1/admin/users/"+id+"/roles", http.StatusUnprocessableEntity, "invalid_json", ParsingJSONError)),
+		}, {
+			"unknown role",
+			[]byte(`{"roles": ["unknown"]}`),
+			models.ErrUnknownRole,
+			http.StatusUnprocessableEntity,
+			[]byte(problemBody("/api/v1/admin/users/"+id+"/roles", http.StatusUnprocessableEntity, "unknown_role", models.ErrUnknownRole.Error())),
+		}, {
+			"user not found",
+			[]byte(`{"roles": ["editor"]}`),
+			models.ErrUserNotFound,
+			http.StatusNotFound,
+			[]byte(problemBody("/api/v1/admin/users/"+id+"/roles", http.StatusNotFound, "user_not_found", models.ErrUserNotFound.Error())),
+		}, {
+			"positive",
+			[]byte(`{"roles": ["editor"]}`),
+			nil,
+			http.StatusOK,
+			[]byte(`User roles updated`),
+		},
+	}
+	serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(testPrincipal, nil)
+	for _, test := range testTable {
+		if test.name == "wrong json" {
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errInvalidJSON)).Return(0).Once()
+		} else if test.name == "unknown role" {
+			serv.On("SetUserRoles", mock.AnythingOfType("*context.valueCtx"), id, []string{"unknown"}).Return(test.serviceErr).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), test.serviceErr).Return(0).Once()
+		} else if test.name == "user not found" {
+			serv.On("SetUserRoles", mock.AnythingOfType("*context.valueCtx"), id, []string{"editor"}).Return(test.serviceErr).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), test.serviceErr).Return(0).Once()
+		} else if test.name == "positive" {
+			serv.On("SetUserRoles", mock.AnythingOfType("*context.valueCtx"), id, []string{"editor"}).Return(nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
+		}
+		req, err := http.NewRequest(http.MethodPatch, "/api/v1/admin/users/"+id+"/roles", bytes.NewBuffer(test.requestBody))
+		req.Header.Set("Authorization", "Test-token")
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+		responseBody := r.Body.Bytes()
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, responseBody, test.name)
+	}
+}
+
+func TestHandler_UserActions(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+	id := "2300a1f6-b2aa-4f5b-b6ca-8f495582e255"
+
+	testTable := []struct {
+		name               string
+		httpMethod         string
+		suffix             string
+		requestBody        []byte
+		serviceMethod      string
+		serviceArgs        []interface{}
+		serviceErr         error
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{"disable", http.MethodPost, "/disable", nil, "DisableUser", nil, nil, http.StatusOK, "User disabled"},
+		{"disable not found", http.MethodPost, "/disable", nil, "DisableUser", nil, models.ErrUserNotFound, http.StatusNotFound,
+			problemBody("/api/v1/admin/users/"+id+"/disable", http.StatusNotFound, "user_not_found", models.ErrUserNotFound.Error())},
+		{"enable", http.MethodPost, "/enable", nil, "EnableUser", nil, nil, http.StatusOK, "User enabled"},
+		{"enable wrong method", http.MethodGet, "/enable", nil, "", nil, nil, http.StatusMethodNotAllowed,
+			problemBody("/api/v1/admin/users/"+id+"/enable", http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed)},
+		{"reset password", http.MethodPost, "/reset-password", []byte(`{"password": "newPassword1"}`), "ResetUserPassword", []interface{}{"newPassword1"}, nil, http.StatusOK, "User password reset"},
+		{"reset weak password", http.MethodPost, "/reset-password", []byte(`{"password": "weak"}`), "ResetUserPassword", []interface{}{"weak"}, models.ErrWeakPassword, http.StatusUnprocessableEntity,
+			problemBody("/api/v1/admin/users/"+id+"/reset-password", http.StatusUnprocessableEntity, "weak_password", models.ErrWeakPassword.Error())},
+		{"delete", http.MethodDelete, "", nil, "DeleteUser", nil, nil, http.StatusOK, "User deleted"},
+		{"delete with query", http.MethodDelete, "?force=true", nil, "DeleteUser", nil, nil, http.StatusOK, "User deleted"},
+		{"delete error", http.MethodDelete, "", nil, "DeleteUser", nil, errors.New("service error"), http.StatusInternalServerError,
+			problemBody("/api/v1/admin/users/"+id, http.StatusInternalServerError, "internal_error", InternalError)},
+	}
+	for _, test := range testTable {
+		if test.serviceMethod != "" {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(testPrincipal, nil).Once()
+			args := append([]interface{}{mock.AnythingOfType("*context.valueCtx"), id}, test.serviceArgs...)
+			serv.On(test.serviceMethod, args...).Return(test.serviceErr).Once()
+			if test.serviceErr != nil {
+				log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), test.serviceErr).Return(0).Once()
+			} else {
+				log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, test.expectedResponse).Return(0).Once()
+			}
+		} else {
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "", errMethodNotAllowed).Return(0).Once()
+		}
+		req, err := http.NewRequest(test.httpMethod, "/api/v1/admin/users/"+id+test.suffix, bytes.NewBuffer(test.requestBody))
+		req.Header.Set("Authorization", "Test-token")
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponse, r.Body.String(), test.name)
+	}
+}
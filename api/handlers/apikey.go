@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"io"
+	"net/http"
+	"time"
+)
+
+type APIKeyDTO struct {
+	Name        string              `json:"name"`
+	Permissions []models.Permission `json:"permissions"`
+	ExpiresAt   *time.Time          `json:"expires_at"`
+}
+
+// CreateAPIKey godoc
+// @Summary Создание API ключа
+// @Description Выпуск долгоживущего ключа для сервисных клиентов с частью прав создателя, ключ передается в заголовке Authorization в виде "ApiKey <key>" и показывается только один раз
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param data body APIKeyDTO true "Входные параметры"
+// @Success 201 {object} models.CreatedAPIKey
+// @Failure 400,401,403,422 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/v1/admin/api-keys [post]
+// @Security ApiKeyAuth
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.problem(w, r, UnprocessableEntity, unreadableBody(err))
+		return
+	}
+	defer r.Body.Close()
+
+	var apiKey APIKeyDTO
+	err = json.Unmarshal(body, &apiKey)
+	if err != nil {
+		h.problem(w, r, ParsingJSONError, invalidJSON(err))
+		return
+	}
+
+	result, err := h.services.CreateAPIKey(r.Context(), apiKey.Name, apiKey.Permissions, apiKey.ExpiresAt)
+	if err != nil {
+		h.problem(w, r, "Create api key", err)
+		return
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		h.problem(w, r, "Can't marshal result", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusCreated, "Api key created")
+}
+
+// GetAPIKeyList godoc
+// @Summary Получение списка API ключей
+// @Description Список всех API ключей, включая отозванные и просроченные, сами ключи не возвращаются
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.APIKey
+// @Failure 400,401,403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/v1/admin/api-keys [get]
+// @Security ApiKeyAuth
+func (h *Handler) GetAPIKeyList(w http.ResponseWriter, r *http.Request) {
+	result, err := h.services.GetAPIKeyList(r.Context())
+	if err != nil {
+		h.problem(w, r, "Get api key list", err)
+		return
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		h.problem(w, r, "Can't marshal result", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusOK, "Get api key list")
+}
+
+// RevokeAPIKey godoc
+// @Summary Отзыв API ключа
+// @Description Ключ перестает приниматься сразу после отзыва
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID ключа"
+// @Success 200 {object} string
+// @Failure 400,401,403,404,500 {object} Problem
+// @Router /api/v1/admin/api-keys/{id}/revoke [post]
+// @Security ApiKeyAuth
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	err := h.services.RevokeAPIKey(r.Context(), id)
+	if err != nil {
+		h.problem(w, r, "Revoke api key", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Api key revoked"))
+	h.log.HandlerLog(r, http.StatusOK, "Api key revoked")
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bytes"
+	"github.com/ast3am/VKintern-movies/api/handlers/mocks"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_CreateAPIKey(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+	id := uuid.MustParse("7c9e6679-7425-40de-944b-e07fc1f90ae7")
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	permissions := []models.Permission{models.PermMovieRead, models.PermMovieWrite}
+
+	testTable := []struct {
+		name                 string
+		requestBody          []byte
+		serviceErr           error
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			"wrong json",
+			[]byte(`{wrong json}`),
+			nil,
+			http.StatusUnprocessableEntity,
+			problemBody("/api/v1/admin/api-keys", http.StatusUnprocessableEntity, "invalid_json", ParsingJSONError),
+		}, {
+			"unknown permission",
+			[]byte(`{"name": "importer", "permissions": ["movie:read", "movie:write"], "expires_at": "2025-03-01T12:00:00Z"}`),
+			models.ErrUnknownPermission,
+			http.StatusUnprocessableEntity,
+			problemBody("/api/v1/admin/api-keys", http.StatusUnprocessableEntity, "unknown_permission", models.ErrUnknownPermission.Error()),
+		}, {
+			"permission not owned",
+			[]byte(`{"name": "importer", "permissions": ["movie:read", "movie:write"], "expires_at": "2025-03-01T12:00:00Z"}`),
+			models.ErrPermissionNotOwned,
+			http.StatusForbidden,
+			problemBody("/api/v1/admin/api-keys", http.StatusForbidden, "permission_not_owned", models.ErrPermissionNotOwned.Error()),
+		}, {
+			"positive",
+			[]byte(`{"name": "importer", "permissions": ["movie:read", "movie:write"], "expires_at": "2025-03-01T12:00:00Z"}`),
+			nil,
+			http.StatusCreated,
+			`{"id":"7c9e6679-7425-40de-944b-e07fc1f90ae7","name":"importer","prefix":"vkm_0a1b2c3d","permissions":["movie:read","movie:write"],` +
+				`"created_by":null,"creator_disabled":false,"created_at":"2024-03-01T12:00:00Z","expires_at":"2025-03-01T12:00:00Z","last_used_at":null,"revoked_at":null,"key":"vkm_0a1b2c3d_secret"}`,
+		},
+	}
+	serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(testPrincipal, nil)
+	for _, test := range testTable {
+		if test.name == "wrong json" {
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errInvalidJSON)).Return(0).Once()
+		} else if test.serviceErr != nil {
+			serv.On("CreateAPIKey", mock.AnythingOfType("*context.valueCtx"), "importer", permissions, &expiresAt).Return(nil, test.serviceErr).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), test.serviceErr).Return(0).Once()
+		} else {
+			serv.On("CreateAPIKey", mock.AnythingOfType("*context.valueCtx"), "importer", permissions, &expiresAt).Return(&models.CreatedAPIKey{
+				APIKey: models.APIKey{ID: id, Name: "importer", Prefix: "vkm_0a1b2c3d", Hash: "hash", Permissions: permissions, CreatedAt: createdAt, ExpiresAt: &expiresAt},
+				Key:    "vkm_0a1b2c3d_secret",
+			}, nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
+		}
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/admin/api-keys", bytes.NewBuffer(test.requestBody))
+		req.Header.Set("Authorization", "Test-token")
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, r.Body.String(), test.name)
+	}
+}
+
+func TestHandler_RevokeAPIKey(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+	id := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+	serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(testPrincipal, nil)
+	//not found
+	serv.On("RevokeAPIKey", mock.AnythingOfType("*context.valueCtx"), id).Return(models.ErrAPIKeyNotFound).Once()
+	log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), http.StatusNotFound, mock.AnythingOfType("string"), models.ErrAPIKeyNotFound).Return(0).Once()
+	req, err := http.NewRequest(http.MethodPost, "/api/v1/admin/api-keys/"+id+"/revoke?reason=leak", nil)
+	assert.Nil(t, err)
+	req.Header.Set("Authorization", "Test-token")
+	r := httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusNotFound, r.Code)
+
+	//positive
+	serv.On("RevokeAPIKey", mock.AnythingOfType("*context.valueCtx"), id).Return(nil).Once()
+	log.On("HandlerLog", mock.AnythingOfType("*http.Request"), http.StatusOK, mock.AnythingOfType("string")).Return(0).Once()
+	r = httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "Api key revoked", r.Body.String())
+
+	//list
+	serv.On("GetAPIKeyList", mock.AnythingOfType("*context.valueCtx")).Return([]*models.APIKey{}, nil).Once()
+	log.On("HandlerLog", mock.AnythingOfType("*http.Request"), http.StatusOK, mock.AnythingOfType("string")).Return(0).Once()
+	req, err = http.NewRequest(http.MethodGet, "/api/v1/admin/api-keys", nil)
+	assert.Nil(t, err)
+	req.Header.Set("Authorization", "Test-token")
+	r = httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "[]", r.Body.String())
+}
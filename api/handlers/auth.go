@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
 	"io"
 	"net/http"
 )
@@ -12,27 +11,117 @@ type UserDTO struct {
 	Password string `json:"password"`
 }
 
+type RefreshDTO struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 // Auth godoc
 // @Summary Вход в систему
-// @Description Вход в систему по логину и паролю
+// @Description Вход в систему по логину и паролю. Если у пользователя подключен TOTP или его роль требует TOTP, вместо токенов возвращается mfa_token для /auth/2fa (или /auth/2fa/setup при setup_required)
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param data body UserDTO true "Входные параметры"
-// @Success 200 {object} string
-// @Failure 400,405,422 {object} error
-// @Router /auth [post]
+// @Success 200 {object} models.TokenPair
+// @Success 200 {object} models.MFAChallenge "если нужен второй фактор"
+// @Failure 400,403,422,429,500 {object} Problem
+// @Router /api/v1/auth [post]
 func (h *Handler) Auth(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		h.log.HandlerErrorLog(r, http.StatusMethodNotAllowed, "", errors.New(MethodNotAllowed))
-		http.Error(w, MethodNotAllowed, http.StatusMethodNotAllowed)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.problem(w, r, UnprocessableEntity, unreadableBody(err))
+		return
+	}
+	defer r.Body.Close()
+
+	var user UserDTO
+	err = json.Unmarshal(body, &user)
+	if err != nil {
+		h.problem(w, r, ParsingJSONError, invalidJSON(err))
+		return
+	}
+
+	tokens, challenge, err := h.services.Auth(r.Context(), user.Email, user.Password, h.clientIP(r))
+	if err != nil {
+		h.problem(w, r, "", err)
+		return
+	}
+
+	if challenge != nil {
+		jsonData, err := json.Marshal(challenge)
+		if err != nil {
+			h.problem(w, r, "Can't marshal result", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(jsonData)
+		h.log.HandlerLog(r, http.StatusOK, "authorization, two-factor required")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("authorization success"))
+	json.NewEncoder(w).Encode(tokens)
+	h.log.HandlerLog(r, http.StatusOK, "authorization")
+}
+
+// Refresh godoc
+// @Summary Обновление токенов
+// @Description Обмен refresh токена на новую пару access и refresh токенов, старый refresh токен становится недействительным
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param data body RefreshDTO true "Входные параметры"
+// @Success 200 {object} models.TokenPair
+// @Failure 401,422,500 {object} Problem
+// @Router /api/v1/auth/refresh [post]
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.problem(w, r, UnprocessableEntity, unreadableBody(err))
+		return
+	}
+	defer r.Body.Close()
+
+	var refresh RefreshDTO
+	err = json.Unmarshal(body, &refresh)
+	if err != nil || refresh.RefreshToken == "" {
+		h.problem(w, r, ParsingJSONError, invalidJSON(err))
+		return
+	}
+
+	tokens, err := h.services.Refresh(r.Context(), refresh.RefreshToken)
+	if err != nil {
+		h.problem(w, r, "Refresh", err)
+		return
+	}
+
+	jsonData, err := json.Marshal(tokens)
+	if err != nil {
+		h.problem(w, r, "Can't marshal result", err)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusOK, "tokens refreshed")
+}
 
+// Register godoc
+// @Summary Регистрация пользователя
+// @Description Создание пользователя с ролью user, пароль должен содержать от 8 до 72 символов, буквы и цифры
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param data body UserDTO true "Входные параметры"
+// @Success 201 {object} string
+// @Failure 400,409,422,500 {object} Problem
+// @Router /api/v1/auth/register [post]
+func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnprocessableEntity, UnprocessableEntity, err)
-		http.Error(w, UnprocessableEntity, http.StatusUnprocessableEntity)
+		h.problem(w, r, UnprocessableEntity, unreadableBody(err))
 		return
 	}
 	defer r.Body.Close()
@@ -40,24 +129,76 @@ func (h *Handler) Auth(w http.ResponseWriter, r *http.Request) {
 	var user UserDTO
 	err = json.Unmarshal(body, &user)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnprocessableEntity, "", errors.New(ParsingJSONError))
-		http.Error(w, ParsingJSONError, http.StatusUnprocessableEntity)
+		h.problem(w, r, ParsingJSONError, invalidJSON(err))
 		return
 	}
 
-	token, err := h.services.Auth(r.Context(), user.Email, user.Password)
+	err = h.services.Register(r.Context(), user.Email, user.Password)
+	if err != nil {
+		h.problem(w, r, "Register", err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte("User registered"))
+	h.log.HandlerLog(r, http.StatusCreated, "User registered")
+}
 
+// Logout godoc
+// @Summary Выход из системы
+// @Description Отзыв access токена из заголовка Authorization, если в теле передан refresh токен, отзывается и он
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param data body RefreshDTO false "Входные параметры"
+// @Success 200 {object} string
+// @Failure 401,422,500 {object} Problem
+// @Router /api/v1/auth/logout [post]
+// @Security ApiKeyAuth
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusBadRequest, "", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.problem(w, r, UnprocessableEntity, unreadableBody(err))
 		return
 	}
+	defer r.Body.Close()
 
+	var refresh RefreshDTO
+	if len(body) > 0 {
+		err = json.Unmarshal(body, &refresh)
+		if err != nil {
+			h.problem(w, r, ParsingJSONError, invalidJSON(err))
+			return
+		}
+	}
+
+	token := r.Header.Get("Authorization")
+	err = h.services.Logout(r.Context(), token, refresh.RefreshToken)
+	if err != nil {
+		h.problem(w, r, InvalidToken, err)
+		return
+	}
 	w.WriteHeader(http.StatusOK)
-	response := map[string]string{
-		"token": token,
+	w.Write([]byte("Logged out"))
+	h.log.HandlerLog(r, http.StatusOK, "Logged out")
+}
+
+// JWKS godoc
+// @Summary Публичные ключи подписи токенов
+// @Description Набор ключей в формате JWK для проверки токенов другими сервисами, ключ выбирается по kid из заголовка токена
+// @Tags auth
+// @Produce json
+// @Success 200 {object} models.JWKSet
+// @Failure 500 {object} Problem
+// @Router /.well-known/jwks.json [get]
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	jsonData, err := json.Marshal(h.services.GetJWKS())
+	if err != nil {
+		h.problem(w, r, "Can't marshal result", err)
+		return
 	}
-	w.Write([]byte("authorization success"))
-	json.NewEncoder(w).Encode(response)
-	h.log.HandlerLog(r, http.StatusOK, "authorization")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusOK, "jwks")
 }
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"errors"
 	"github.com/ast3am/VKintern-movies/api/handlers/mocks"
+	"github.com/ast3am/VKintern-movies/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestHandler_Auth(t *testing.T) {
@@ -30,40 +32,77 @@ func TestHandler_Auth(t *testing.T) {
 			[]byte(`{"email": "test@example.com", "password": "password123"}`),
 			http.MethodPost,
 			http.StatusOK,
-			[]byte(`authorization success{"token":"some token"}` + "\n"),
+			[]byte(`authorization success{"token":"some token","refresh_token":"some refresh token"}` + "\n"),
 		}, {
 			"wrong method",
 			[]byte(`{"email": "test@example.com", "password": "password123"}`),
 			http.MethodGet,
 			http.StatusMethodNotAllowed,
-			[]byte(`invalid request method` + "\n"),
+			[]byte(problemBody("/api/v1/auth", http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed)),
 		}, {
 			"wrong json",
 			[]byte(`{wrong json}`),
 			http.MethodPost,
 			http.StatusUnprocessableEntity,
-			[]byte(`JSON parsing error` + "\n"),
+			[]byte(problemBody("/api/v1/auth", http.StatusUnprocessableEntity, "invalid_json", ParsingJSONError)),
 		}, {
 			"wrong user",
 			[]byte(`{"email": "test@example.com", "password": "password123"}`),
 			http.MethodPost,
 			http.StatusBadRequest,
-			[]byte(`wrong email or password` + "\n"),
+			[]byte(problemBody("/api/v1/auth", http.StatusBadRequest, "wrong_credentials", models.ErrWrongCredentials.Error())),
+		}, {
+			"disabled user",
+			[]byte(`{"email": "test@example.com", "password": "password123"}`),
+			http.MethodPost,
+			http.StatusForbidden,
+			[]byte(problemBody("/api/v1/auth", http.StatusForbidden, "user_disabled", models.ErrUserDisabled.Error())),
+		}, {
+			"two-factor required",
+			[]byte(`{"email": "admin@example.com", "password": "password123"}`),
+			http.MethodPost,
+			http.StatusOK,
+			[]byte(`{"mfa_token":"some mfa token","setup_required":false}`),
+		}, {
+			"login locked",
+			[]byte(`{"email": "test@example.com", "password": "password123"}`),
+			http.MethodPost,
+			http.StatusTooManyRequests,
+			[]byte(problemBody("/api/v1/auth", http.StatusTooManyRequests, "too_many_login_attempts", models.ErrTooManyLoginAttempts.Error())),
+		}, {
+			"db error",
+			[]byte(`{"email": "test@example.com", "password": "password123"}`),
+			http.MethodPost,
+			http.StatusInternalServerError,
+			[]byte(problemBody("/api/v1/auth", http.StatusInternalServerError, "internal_error", InternalError)),
 		},
 	}
+	locked := &models.LoginLockedError{Until: time.Now().Add(90 * time.Second)}
 	for _, test := range testTable {
-		if test.name == "positive" {
-			serv.On("Auth", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return("some token", nil).Once()
+		if test.name == "two-factor required" {
+			serv.On("Auth", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, &models.MFAChallenge{MFAToken: "some mfa token"}, nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "authorization, two-factor required").Return(0)
+		} else if test.name == "login locked" {
+			serv.On("Auth", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, nil, locked).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), locked).Return(0)
+		} else if test.name == "db error" {
+			serv.On("Auth", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, nil, errors.New("db error")).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New("db error")).Return(0)
+		} else if test.name == "disabled user" {
+			serv.On("Auth", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, nil, models.ErrUserDisabled).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrUserDisabled).Return(0)
+		} else if test.name == "positive" {
+			serv.On("Auth", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(&models.TokenPair{AccessToken: "some token", RefreshToken: "some refresh token"}, nil, nil).Once()
 			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "authorization").Return(0)
 		} else if test.name == "wrong method" {
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(MethodNotAllowed)).Return(0)
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errMethodNotAllowed).Return(0)
 		} else if test.name == "wrong json" {
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(ParsingJSONError)).Return(0)
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errInvalidJSON)).Return(0)
 		} else if test.name == "wrong user" {
-			serv.On("Auth", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return("", errors.New("wrong email or password")).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New("wrong email or password")).Return(0)
+			serv.On("Auth", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, nil, models.ErrWrongCredentials).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrWrongCredentials).Return(0)
 		}
-		req, err := http.NewRequest(test.httpMethod, "/auth", bytes.NewBuffer(test.requestBody))
+		req, err := http.NewRequest(test.httpMethod, "/api/v1/auth", bytes.NewBuffer(test.requestBody))
 		r := httptest.NewRecorder()
 		mux.ServeHTTP(r, req)
 		responseBody := r.Body.Bytes()
@@ -71,5 +110,263 @@ func TestHandler_Auth(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, test.expectedStatusCode, r.Code)
 		assert.Equal(t, test.expectedResponseBody, responseBody)
+		if test.name == "login locked" {
+			assert.Equal(t, "90", r.Header().Get("Retry-After"))
+		}
+		if test.name == "two-factor required" {
+			assert.Equal(t, "application/json", r.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestHandler_Register(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+
+	testTable := []struct {
+		name                 string
+		requestBody          []byte
+		httpMethod           string
+		serviceErr           error
+		expectedStatusCode   int
+		expectedResponseBody []byte
+	}{
+		{
+			"positive",
+			[]byte(`{"email": "new@example.com", "password": "password123"}`),
+			http.MethodPost,
+			nil,
+			http.StatusCreated,
+			[]byte(`User registered`),
+		}, {
+			"wrong method",
+			[]byte(`{"email": "new@example.com", "password": "password123"}`),
+			http.MethodGet,
+			nil,
+			http.StatusMethodNotAllowed,
+			[]byte(problemBody("/api/v1/auth/register", http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed)),
+		}, {
+			"wrong json",
+			[]byte(`{wrong json}`),
+			http.MethodPost,
+			nil,
+			http.StatusUnprocessableEntity,
+			[]byte(problemBody("/api/v1/auth/register", http.StatusUnprocessableEntity, "invalid_json", ParsingJSONError)),
+		}, {
+			"duplicate email",
+			[]byte(`{"email": "new@example.com", "password": "password123"}`),
+			http.MethodPost,
+			models.ErrUserExists,
+			http.StatusConflict,
+			[]byte(problemBody("/api/v1/auth/register", http.StatusConflict, "user_exists", models.ErrUserExists.Error())),
+		}, {
+			"weak password",
+			[]byte(`{"email": "new@example.com", "password": "123"}`),
+			http.MethodPost,
+			models.ErrWeakPassword,
+			http.StatusUnprocessableEntity,
+			[]byte(problemBody("/api/v1/auth/register", http.StatusUnprocessableEntity, "weak_password", models.ErrWeakPassword.Error())),
+		}, {
+			"invalid email",
+			[]byte(`{"email": "new", "password": "password123"}`),
+			http.MethodPost,
+			models.ErrInvalidEmail,
+			http.StatusUnprocessableEntity,
+			[]byte(problemBody("/api/v1/auth/register", http.StatusUnprocessableEntity, "invalid_email", models.ErrInvalidEmail.Error())),
+		}, {
+			"another error service",
+			[]byte(`{"email": "new@example.com", "password": "password123"}`),
+			http.MethodPost,
+			errors.New("service error"),
+			http.StatusInternalServerError,
+			[]byte(problemBody("/api/v1/auth/register", http.StatusInternalServerError, "internal_error", InternalError)),
+		},
+	}
+	for _, test := range testTable {
+		if test.name == "positive" {
+			serv.On("Register", mock.AnythingOfType("context.backgroundCtx"), "new@example.com", "password123").Return(nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "User registered").Return(0).Once()
+		} else if test.name == "wrong method" {
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errMethodNotAllowed).Return(0).Once()
+		} else if test.name == "wrong json" {
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errInvalidJSON)).Return(0).Once()
+		} else {
+			serv.On("Register", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(test.serviceErr).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "Register", test.serviceErr).Return(0).Once()
+		}
+		req, err := http.NewRequest(test.httpMethod, "/api/v1/auth/register", bytes.NewBuffer(test.requestBody))
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+		responseBody := r.Body.Bytes()
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, responseBody, test.name)
 	}
 }
+
+func TestHandler_Refresh(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+
+	testTable := []struct {
+		name                 string
+		requestBody          []byte
+		httpMethod           string
+		expectedStatusCode   int
+		expectedResponseBody []byte
+	}{
+		{
+			"positive",
+			[]byte(`{"refresh_token": "old refresh token"}`),
+			http.MethodPost,
+			http.StatusOK,
+			[]byte(`{"token":"new token","refresh_token":"new refresh token"}`),
+		}, {
+			"wrong method",
+			[]byte(`{"refresh_token": "old refresh token"}`),
+			http.MethodGet,
+			http.StatusMethodNotAllowed,
+			[]byte(problemBody("/api/v1/auth/refresh", http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed)),
+		}, {
+			"empty token",
+			[]byte(`{}`),
+			http.MethodPost,
+			http.StatusUnprocessableEntity,
+			[]byte(problemBody("/api/v1/auth/refresh", http.StatusUnprocessableEntity, "invalid_json", ParsingJSONError)),
+		}, {
+			"reused token",
+			[]byte(`{"refresh_token": "old refresh token"}`),
+			http.MethodPost,
+			http.StatusUnauthorized,
+			[]byte(problemBody("/api/v1/auth/refresh", http.StatusUnauthorized, "refresh_token_reused", models.ErrRefreshTokenReused.Error())),
+		},
+	}
+	for _, test := range testTable {
+		if test.name == "positive" {
+			serv.On("Refresh", mock.AnythingOfType("context.backgroundCtx"), "old refresh token").Return(&models.TokenPair{AccessToken: "new token", RefreshToken: "new refresh token"}, nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "tokens refreshed").Return(0).Once()
+		} else if test.name == "wrong method" {
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errMethodNotAllowed).Return(0).Once()
+		} else if test.name == "empty token" {
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errInvalidJSON)).Return(0).Once()
+		} else if test.name == "reused token" {
+			serv.On("Refresh", mock.AnythingOfType("context.backgroundCtx"), "old refresh token").Return(nil, models.ErrRefreshTokenReused).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "Refresh", models.ErrRefreshTokenReused).Return(0).Once()
+		}
+		req, err := http.NewRequest(test.httpMethod, "/api/v1/auth/refresh", bytes.NewBuffer(test.requestBody))
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+		responseBody := r.Body.Bytes()
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, responseBody, test.name)
+	}
+}
+
+func TestHandler_Logout(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+
+	testTable := []struct {
+		name                 string
+		requestBody          []byte
+		httpMethod           string
+		expectedStatusCode   int
+		expectedResponseBody []byte
+	}{
+		{
+			"positive",
+			[]byte(`{"refresh_token": "some refresh token"}`),
+			http.MethodPost,
+			http.StatusOK,
+			[]byte(`Logged out`),
+		}, {
+			"positive empty body",
+			nil,
+			http.MethodPost,
+			http.StatusOK,
+			[]byte(`Logged out`),
+		}, {
+			"wrong method",
+			nil,
+			http.MethodGet,
+			http.StatusMethodNotAllowed,
+			[]byte(problemBody("/api/v1/auth/logout", http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed)),
+		}, {
+			"wrong json",
+			[]byte(`"some data"`),
+			http.MethodPost,
+			http.StatusUnprocessableEntity,
+			[]byte(problemBody("/api/v1/auth/logout", http.StatusUnprocessableEntity, "invalid_json", ParsingJSONError)),
+		}, {
+			"revoked token",
+			nil,
+			http.MethodPost,
+			http.StatusUnauthorized,
+			[]byte(problemBody("/api/v1/auth/logout", http.StatusUnauthorized, "token_revoked", models.ErrTokenRevoked.Error())),
+		},
+	}
+	for _, test := range testTable {
+		if test.name == "positive" {
+			serv.On("Logout", mock.AnythingOfType("context.backgroundCtx"), "Test-token", "some refresh token").Return(nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "Logged out").Return(0).Once()
+		} else if test.name == "positive empty body" {
+			serv.On("Logout", mock.AnythingOfType("context.backgroundCtx"), "Test-token", "").Return(nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "Logged out").Return(0).Once()
+		} else if test.name == "wrong method" {
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errMethodNotAllowed).Return(0).Once()
+		} else if test.name == "wrong json" {
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errInvalidJSON)).Return(0).Once()
+		} else if test.name == "revoked token" {
+			serv.On("Logout", mock.AnythingOfType("context.backgroundCtx"), "Test-token", "").Return(models.ErrTokenRevoked).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, InvalidToken, models.ErrTokenRevoked).Return(0).Once()
+		}
+		req, err := http.NewRequest(test.httpMethod, "/api/v1/auth/logout", bytes.NewBuffer(test.requestBody))
+		req.Header.Set("Authorization", "Test-token")
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+		responseBody := r.Body.Bytes()
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, responseBody, test.name)
+	}
+}
+
+func TestHandler_JWKS(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+
+	//wrong method
+	log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), http.StatusMethodNotAllowed, mock.AnythingOfType("string"), errMethodNotAllowed).Return(0).Once()
+	req, err := http.NewRequest(http.MethodPost, "/.well-known/jwks.json", nil)
+	assert.Nil(t, err)
+	r := httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, r.Code)
+
+	//positive
+	serv.On("GetJWKS").Return(models.JWKSet{Keys: []models.JWK{{Kty: "OKP", Use: "sig", Alg: "EdDSA", Kid: "ed-1", Crv: "Ed25519", X: "key"}}}).Once()
+	log.On("HandlerLog", mock.AnythingOfType("*http.Request"), http.StatusOK, "jwks").Return(0).Once()
+	req, err = http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	assert.Nil(t, err)
+	r = httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/json", r.Header().Get("Content-Type"))
+	assert.Equal(t, `{"keys":[{"kty":"OKP","use":"sig","alg":"EdDSA","kid":"ed-1","crv":"Ed25519","x":"key"}]}`, r.Body.String())
+}
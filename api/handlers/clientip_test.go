@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_SetTrustedProxies(t *testing.T) {
+	h := NewHandler(nil, nil)
+	assert.NoError(t, h.SetTrustedProxies([]string{"10.0.0.1", "192.168.0.0/16", "::1", "fd00::/8"}))
+	assert.Len(t, h.trustedProxies, 4)
+	assert.Error(t, h.SetTrustedProxies([]string{"proxy.local"}))
+	assert.Error(t, h.SetTrustedProxies([]string{"10.0.0.0/33"}))
+}
+
+func TestHandler_ClientIP(t *testing.T) {
+	h := NewHandler(nil, nil)
+	require.NoError(t, h.SetTrustedProxies([]string{"10.0.0.1", "192.168.0.0/16"}))
+
+	testTable := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{"direct", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"untrusted proxy headers ignored", "203.0.113.7:5000", map[string]string{"X-Forwarded-For": "1.2.3.4", "X-Real-IP": "1.2.3.4"}, "203.0.113.7"},
+		{"trusted proxy without headers", "10.0.0.1:5000", nil, "10.0.0.1"},
+		{"trusted proxy forwarded", "10.0.0.1:5000", map[string]string{"X-Forwarded-For": "198.51.100.2"}, "198.51.100.2"},
+		{"spoofed hop left of client", "10.0.0.1:5000", map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.2, 192.168.1.5"}, "198.51.100.2"},
+		{"all hops trusted", "10.0.0.1:5000", map[string]string{"X-Forwarded-For": "192.168.1.6, 192.168.1.5"}, "192.168.1.6"},
+		{"invalid hop", "10.0.0.1:5000", map[string]string{"X-Forwarded-For": "unknown, 192.168.1.5"}, "192.168.1.5"},
+		{"trusted proxy real ip", "192.168.3.3:5000", map[string]string{"X-Real-IP": "198.51.100.3"}, "198.51.100.3"},
+		{"invalid real ip", "192.168.3.3:5000", map[string]string{"X-Real-IP": "unknown"}, "192.168.3.3"},
+	}
+	for _, test := range testTable {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth", nil)
+		req.RemoteAddr = test.remoteAddr
+		for key, value := range test.headers {
+			req.Header.Set(key, value)
+		}
+		assert.Equal(t, test.expected, h.clientIP(req), test.name)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"github.com/ast3am/VKintern-movies/internal/models"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 
+	"net"
 	"net/http"
+	"time"
 )
 
 const (
@@ -14,11 +16,7 @@ const (
 	UnprocessableEntity = "no valid data"
 	ParsingJSONError    = "JSON parsing error"
 	InvalidToken        = "invalid token"
-)
-
-const (
-	Admin = "admin"
-	User  = "user"
+	InternalError       = "internal server error"
 )
 
 //go:generate mockery --name logger
@@ -29,22 +27,52 @@ type logger interface {
 
 //go:generate mockery --name service
 type service interface {
-	Auth(ctx context.Context, email, password string) (token string, err error)
-	CheckToken(token, permissionLevel string) error
-	CreateActor(ctx context.Context, actor models.Actor) error
-	GetActorList(ctx context.Context) (map[string][]string, error)
+	Auth(ctx context.Context, email, password, ip string) (*models.TokenPair, *models.MFAChallenge, error)
+	AuthMFA(ctx context.Context, mfaToken, code, ip string) (*models.TokenPair, error)
+	SetupTOTP(ctx context.Context, token string) (*models.TOTPSetup, error)
+	VerifyTOTP(ctx context.Context, token, code, ip string) (*models.MFAEnrollment, error)
+	OIDCLogin(ctx context.Context) (string, string, error)
+	OIDCCallback(ctx context.Context, state, code string) (*models.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error)
+	Register(ctx context.Context, email, password string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	Logout(ctx context.Context, token, refreshToken string) error
+	RevokeUserTokens(ctx context.Context, id string) error
+	GetUserList(ctx context.Context, limit, offset string) (*models.UserList, error)
+	GetUser(ctx context.Context, id string) (*models.User, error)
+	SetUserRoles(ctx context.Context, id string, roles []string) error
+	DisableUser(ctx context.Context, id string) error
+	EnableUser(ctx context.Context, id string) error
+	ResetUserPassword(ctx context.Context, id, password string) error
+	DeleteUser(ctx context.Context, id string) error
+	CreateAPIKey(ctx context.Context, name string, permissions []models.Permission, expiresAt *time.Time) (*models.CreatedAPIKey, error)
+	GetAPIKeyList(ctx context.Context) ([]*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) error
+	Authenticate(ctx context.Context, authorization string) (*models.Principal, error)
+	GetJWKS() models.JWKSet
+	CreateActor(ctx context.Context, actor models.Actor) (*models.ActorDetails, error)
+	GetActorList(ctx context.Context, page models.PageParams) (*models.ActorList, error)
+	GetActorByID(ctx context.Context, id string) (*models.ActorDetails, error)
 	DeleteActor(ctx context.Context, id string) error
 	UpdateActor(ctx context.Context, id string, actor models.Actor) error
-	CreateMovie(ctx context.Context, movie models.Movie) error
+	ReplaceActor(ctx context.Context, id string, actor models.Actor) error
+	CreateMovie(ctx context.Context, movie models.Movie) (*models.MovieDetails, error)
+	GetMovieByID(ctx context.Context, id string) (*models.MovieDetails, error)
 	UpdateMovie(ctx context.Context, id string, movie models.Movie) error
+	ReplaceMovie(ctx context.Context, id string, movie models.Movie) error
 	DeleteMovie(ctx context.Context, id string) error
-	GetMovieList(ctx context.Context, sortby, line string) ([]*models.Movie, error)
+	GetMovieList(ctx context.Context, filter models.MovieFilterParams, sort models.SortSpec, page models.PageParams) (*models.MovieList, error)
+	GetAllMovies(ctx context.Context, filter models.MovieFilterParams, sort models.SortSpec) ([]*models.Movie, error)
 	GetMovie(ctx context.Context, actor, movie string) ([]*models.Movie, error)
+	Search(ctx context.Context, params models.SearchParams) (*models.SearchResults, error)
+	Suggest(ctx context.Context, params models.SuggestParams) (*models.Suggestions, error)
 }
 
 type Handler struct {
-	services service
-	log      logger
+	services       service
+	log            logger
+	trustedProxies []*net.IPNet
 }
 
 func NewHandler(serv service, log logger) *Handler {
@@ -54,18 +82,71 @@ func NewHandler(serv service, log logger) *Handler {
 	}
 }
 
+// RegisterHandlers регистрирует все маршруты в отдельном ServeMux, который подключается к mux целиком:
+// так ответы 404 и 405 самого маршрутизатора тоже отдаются в формате problem+json
 func (h *Handler) RegisterHandlers(mux *http.ServeMux) {
-	mux.HandleFunc("/auth", h.Auth)
-	mux.HandleFunc("/actor/create", h.CreateActor)
-	mux.HandleFunc("/actor/get-list", h.GetActorsList)
-	mux.HandleFunc("/actor/update/", h.UpdateActor)
-	mux.HandleFunc("/actor/delete/", h.DeleteActor)
-	mux.HandleFunc("/movie/create", h.CreateMovie)
-	mux.HandleFunc("/movie/update/", h.UpdateMovie)
-	mux.HandleFunc("/movie/delete/", h.DeleteMovie)
-	mux.HandleFunc("/movie/get-list", h.GetMoviesList)
-	mux.HandleFunc("/movie/get-movie", h.GetMovie)
-	mux.Handle("/swagger/", httpSwagger.Handler(
+	routes := http.NewServeMux()
+	h.routesV1(router{mux: routes, prefix: apiV1})
+	// адреса без версии работают как псевдонимы v1 до legacySunsetAt
+	legacy := router{mux: routes, prefix: apiV1, legacy: true}
+	h.routesV1(legacy)
+	h.legacyAliasesV1(legacy)
+
+	routes.HandleFunc("GET /.well-known/jwks.json", h.JWKS)
+	routes.Handle("/swagger/", httpSwagger.Handler(
 		httpSwagger.URL("http://localhost:8080/swagger/doc.json"),
 	))
+	mux.Handle("/", h.routeProblems(routes))
+}
+
+func (h *Handler) routesV1(rt router) {
+	rt.handle("POST /auth", h.Auth)
+	rt.handle("POST /auth/register", h.Register)
+	rt.handle("POST /auth/refresh", h.Refresh)
+	rt.handle("POST /auth/logout", h.Logout)
+	rt.handle("POST /auth/2fa", h.AuthMFA)
+	rt.handle("POST /auth/2fa/setup", h.SetupTOTP)
+	rt.handle("POST /auth/2fa/verify", h.VerifyTOTP)
+	rt.handle("POST /auth/password/forgot", h.ForgotPassword)
+	rt.handle("POST /auth/password/reset", h.ResetPassword)
+	rt.handle("GET /auth/oidc/login", h.OIDCLogin)
+	rt.handle("GET /auth/oidc/callback", h.OIDCCallback)
+	rt.handle("GET /admin/users", h.authorize(models.PermUserManage, h.GetUserList))
+	rt.handle("GET /admin/users/{id}", h.authorize(models.PermUserManage, h.GetUser))
+	rt.handle("DELETE /admin/users/{id}", h.authorize(models.PermUserManage, h.DeleteUser))
+	rt.handle("PATCH /admin/users/{id}/roles", h.authorize(models.PermUserManage, h.SetUserRoles))
+	rt.handle("POST /admin/users/{id}/disable", h.authorize(models.PermUserManage, h.DisableUser))
+	rt.handle("POST /admin/users/{id}/enable", h.authorize(models.PermUserManage, h.EnableUser))
+	rt.handle("POST /admin/users/{id}/reset-password", h.authorize(models.PermUserManage, h.ResetUserPassword))
+	rt.handle("POST /admin/users/{id}/revoke-tokens", h.authorize(models.PermUserManage, h.RevokeUserTokens))
+	rt.handle("GET /admin/api-keys", h.authorize(models.PermUserManage, h.GetAPIKeyList))
+	rt.handle("POST /admin/api-keys", h.authorize(models.PermUserManage, h.CreateAPIKey))
+	rt.handle("POST /admin/api-keys/{id}/revoke", h.authorize(models.PermUserManage, h.RevokeAPIKey))
+	rt.handle("GET /actors", h.authorize(models.PermActorRead, h.GetActorsList))
+	rt.handle("POST /actors", h.authorize(models.PermActorWrite, h.CreateActor))
+	rt.handle("GET /actors/{id}", h.authorize(models.PermActorRead, h.GetActorByID))
+	rt.handle("PATCH /actors/{id}", h.authorize(models.PermActorWrite, h.UpdateActor))
+	rt.handle("PUT /actors/{id}", h.authorize(models.PermActorWrite, h.ReplaceActor))
+	rt.handle("DELETE /actors/{id}", h.authorize(models.PermActorDelete, h.DeleteActor))
+	rt.handle("GET /movies", h.authorize(models.PermMovieRead, h.GetMoviesList))
+	rt.handle("POST /movies", h.authorize(models.PermMovieWrite, h.CreateMovie))
+	rt.handle("GET /movies/{id}", h.authorize(models.PermMovieRead, h.GetMovieByID))
+	rt.handle("PATCH /movies/{id}", h.authorize(models.PermMovieWrite, h.UpdateMovie))
+	rt.handle("PUT /movies/{id}", h.authorize(models.PermMovieWrite, h.ReplaceMovie))
+	rt.handle("DELETE /movies/{id}", h.authorize(models.PermMovieDelete, h.DeleteMovie))
+	rt.handle("GET /search", h.authorizeAny([]models.Permission{models.PermMovieRead, models.PermActorRead}, h.Search))
+	rt.handle("GET /suggest", h.authorizeAny([]models.Permission{models.PermMovieRead, models.PermActorRead}, h.Suggest))
+}
+
+// legacyAliasesV1 - адреса с действием в пути, которые были до /movies и /actors
+func (h *Handler) legacyAliasesV1(rt router) {
+	rt.alias("POST /actor/create", "/actors", h.authorize(models.PermActorWrite, h.CreateActor))
+	rt.alias("GET /actor/get-list", "/actors", h.authorize(models.PermActorRead, h.GetActorsList))
+	rt.alias("PATCH /actor/update/{id}", "/actors/{id}", h.authorize(models.PermActorWrite, h.UpdateActor))
+	rt.alias("DELETE /actor/delete/{id}", "/actors/{id}", h.authorize(models.PermActorDelete, h.DeleteActor))
+	rt.alias("POST /movie/create", "/movies", h.authorize(models.PermMovieWrite, h.CreateMovie))
+	rt.alias("PATCH /movie/update/{id}", "/movies/{id}", h.authorize(models.PermMovieWrite, h.UpdateMovie))
+	rt.alias("DELETE /movie/delete/{id}", "/movies/{id}", h.authorize(models.PermMovieDelete, h.DeleteMovie))
+	rt.alias("GET /movie/get-list", "/movies", h.authorize(models.PermMovieRead, h.GetAllMovies))
+	rt.alias("GET /movie/get-movie", "/movies", h.authorize(models.PermMovieRead, h.GetMovie))
 }
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ast3am/VKintern-movies/api/handlers/mocks"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testPrincipal - Principal со всеми правами, его возвращает мок Authenticate для успешных запросов
+var testPrincipal = &models.Principal{
+	UserID:      uuid.MustParse("2300a1f6-b2aa-4f5b-b6ca-8f495582e255"),
+	Email:       "admin@vk.ru",
+	Roles:       []string{"admin"},
+	Permissions: models.Permissions,
+}
+
+// problemBody - ожидаемое тело ответа с ошибкой
+func problemBody(instance string, status int, code, detail string, fields ...models.FieldError) string {
+	body, _ := json.Marshal(Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Code:     code,
+		Detail:   detail,
+		Instance: instance,
+		Errors:   fields,
+	})
+	return string(body) + "\n"
+}
+
+// errorIs - аргумент мока для ошибки, обернутой обработчиком, например ошибки разбора JSON
+func errorIs(target error) interface{} {
+	return mock.MatchedBy(func(err error) bool {
+		return errors.Is(err, target)
+	})
+}
+
+// Каждый обработчик проверяет токен на свое право, например редактор может изменять фильмы, но не удалять их
+func TestHandler_RequiredPermissions(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+
+	testTable := []struct {
+		httpMethod string
+		url        string
+		permission models.Permission
+	}{
+		{http.MethodPost, "/api/v1/actors", models.PermActorWrite},
+		{http.MethodGet, "/api/v1/actors", models.PermActorRead},
+		{http.MethodPatch, "/api/v1/actors/b0482c7a-1a4c-4a3c-9463-35f0036a0d60", models.PermActorWrite},
+		{http.MethodPut, "/api/v1/actors/b0482c7a-1a4c-4a3c-9463-35f0036a0d60", models.PermActorWrite},
+		{http.MethodDelete, "/api/v1/actors/b0482c7a-1a4c-4a3c-9463-35f0036a0d60", models.PermActorDelete},
+		{http.MethodPost, "/api/v1/movies", models.PermMovieWrite},
+		{http.MethodGet, "/api/v1/movies", models.PermMovieRead},
+		{http.MethodGet, "/api/v1/movies?movie=Star", models.PermMovieRead},
+		{http.MethodPatch, "/api/v1/movies/08ed098c-e3a0-11ee-8751-2e8752a1069e", models.PermMovieWrite},
+		{http.MethodPut, "/api/v1/movies/08ed098c-e3a0-11ee-8751-2e8752a1069e", models.PermMovieWrite},
+		{http.MethodDelete, "/api/v1/movies/08ed098c-e3a0-11ee-8751-2e8752a1069e", models.PermMovieDelete},
+		{http.MethodGet, "/movies", models.PermMovieRead},
+		{http.MethodPost, "/admin/api-keys", models.PermUserManage},
+		{http.MethodPost, "/actor/create", models.PermActorWrite},
+		{http.MethodGet, "/actor/get-list", models.PermActorRead},
+		{http.MethodPatch, "/actor/update/b0482c7a-1a4c-4a3c-9463-35f0036a0d60", models.PermActorWrite},
+		{http.MethodDelete, "/actor/delete/b0482c7a-1a4c-4a3c-9463-35f0036a0d60", models.PermActorDelete},
+		{http.MethodPost, "/movie/create", models.PermMovieWrite},
+		{http.MethodPatch, "/movie/update/08ed098c-e3a0-11ee-8751-2e8752a1069e", models.PermMovieWrite},
+		{http.MethodDelete, "/movie/delete/08ed098c-e3a0-11ee-8751-2e8752a1069e", models.PermMovieDelete},
+		{http.MethodGet, "/movie/get-list", models.PermMovieRead},
+		{http.MethodGet, "/movie/get-movie?movie=Star", models.PermMovieRead},
+		{http.MethodGet, "/api/v1/admin/users", models.PermUserManage},
+		{http.MethodGet, "/api/v1/admin/users/2300a1f6-b2aa-4f5b-b6ca-8f495582e255", models.PermUserManage},
+		{http.MethodDelete, "/api/v1/admin/users/2300a1f6-b2aa-4f5b-b6ca-8f495582e255", models.PermUserManage},
+		{http.MethodPatch, "/api/v1/admin/users/2300a1f6-b2aa-4f5b-b6ca-8f495582e255/roles", models.PermUserManage},
+		{http.MethodPost, "/api/v1/admin/users/2300a1f6-b2aa-4f5b-b6ca-8f495582e255/disable", models.PermUserManage},
+		{http.MethodPost, "/api/v1/admin/users/2300a1f6-b2aa-4f5b-b6ca-8f495582e255/enable", models.PermUserManage},
+		{http.MethodPost, "/api/v1/admin/users/2300a1f6-b2aa-4f5b-b6ca-8f495582e255/reset-password", models.PermUserManage},
+		{http.MethodPost, "/api/v1/admin/users/2300a1f6-b2aa-4f5b-b6ca-8f495582e255/revoke-tokens", models.PermUserManage},
+		{http.MethodGet, "/admin/users/2300a1f6-b2aa-4f5b-b6ca-8f495582e255", models.PermUserManage},
+		{http.MethodPost, "/api/v1/admin/api-keys", models.PermUserManage},
+		{http.MethodGet, "/api/v1/admin/api-keys", models.PermUserManage},
+		{http.MethodPost, "/api/v1/admin/api-keys/7c9e6679-7425-40de-944b-e07fc1f90ae7/revoke", models.PermUserManage},
+	}
+	for _, test := range testTable {
+		principal := &models.Principal{UserID: testPrincipal.UserID}
+		for _, permission := range models.Permissions {
+			if permission != test.permission {
+				principal.Permissions = append(principal.Permissions, permission)
+			}
+		}
+		serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(principal, nil).Once()
+		log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), http.StatusForbidden, string(test.permission), models.ErrPermissionDenied).Return(0).Once()
+		req, err := http.NewRequest(test.httpMethod, test.url, nil)
+		req.Header.Set("Authorization", "Test-token")
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusForbidden, r.Code, test.url)
+		assert.Equal(t, problemBody(req.URL.Path, http.StatusForbidden, "permission_denied", "permission denied"), r.Body.String(), test.url)
+		assert.Equal(t, problemContentType, r.Header().Get("Content-Type"), test.url)
+	}
+}
+
+func TestHandler_Authorize(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+
+	testTable := []struct {
+		name               string
+		authorization      string
+		authErr            error
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{"no token", "", models.ErrInvalidToken, http.StatusUnauthorized,
+			problemBody("/api/v1/actors", http.StatusUnauthorized, "invalid_token", InvalidToken)},
+		{"revoked", "Bearer revoked", models.ErrTokenRevoked, http.StatusUnauthorized,
+			problemBody("/api/v1/actors", http.StatusUnauthorized, "token_revoked", "token revoked")},
+		{"invalid api key", "ApiKey unknown", models.ErrInvalidAPIKey, http.StatusUnauthorized,
+			problemBody("/api/v1/actors", http.StatusUnauthorized, "invalid_api_key", "invalid api key")},
+		// текст ошибки БД остается в логе
+		{"db error", "Bearer token", errors.New("db error"), http.StatusInternalServerError,
+			problemBody("/api/v1/actors", http.StatusInternalServerError, "internal_error", InternalError)},
+		{"positive", "Bearer token", nil, http.StatusOK, `{"actors":[{"id":"b0482c7a-1a4c-4a3c-9463-35f0036a0d60","name":"Tom Hanks","gender":"Male",` +
+			`"birth_date":"1956-07-09T00:00:00Z","filmography":[],"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}],"total":1,"limit":20}`},
+	}
+	for _, test := range testTable {
+		if test.authErr != nil {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), test.authorization).Return(nil, test.authErr).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), test.authErr).Return(0).Once()
+		} else {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), test.authorization).Return(testPrincipal, nil).Once()
+			// обработчик и сервис получают Principal из контекста запроса
+			serv.On("GetActorList", mock.MatchedBy(func(ctx context.Context) bool {
+				principal, ok := models.PrincipalFromContext(ctx)
+				return ok && principal == testPrincipal
+			}), models.PageParams{}).Return(&models.ActorList{Actors: []*models.ActorDetails{{ID: uuid.MustParse("b0482c7a-1a4c-4a3c-9463-35f0036a0d60"), Name: "Tom Hanks", Gender: "Male",
+				BirthDate: GetDate("1956-07-09"), Filmography: []models.ActorMovie{}}}, PageInfo: models.PageInfo{Total: 1, Limit: 20}}, nil).Once()
+			log.On("HandlerLog", mock.MatchedBy(func(r *http.Request) bool {
+				principal, ok := models.PrincipalFromContext(r.Context())
+				return ok && principal == testPrincipal
+			}), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
+		}
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/actors", nil)
+		if test.authorization != "" {
+			req.Header.Set("Authorization", test.authorization)
+		}
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponse, r.Body.String(), test.name)
+		if test.expectedStatusCode == http.StatusUnauthorized {
+			assert.Equal(t, "Bearer", r.Header().Get("WWW-Authenticate"), test.name)
+		}
+	}
+}
+
+func TestHandler_Routes(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+	id := "08ed098c-e3a0-11ee-8751-2e8752a1069e"
+	body := `{"name": "Forrest Gump", "rating": 8.5}`
+	movie := models.Movie{Name: "Forrest Gump", Rating: 8.5}
+
+	//Неподдерживаемый метод отклоняется до проверки токена, в Allow перечислены допустимые
+	log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), http.StatusMethodNotAllowed, "", errMethodNotAllowed).Return(0).Once()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/movies/"+id, nil)
+	r := httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, r.Code)
+	assert.Equal(t, "DELETE, GET, HEAD, PATCH, PUT", r.Header().Get("Allow"))
+	assert.Equal(t, problemContentType, r.Header().Get("Content-Type"))
+	assert.Equal(t, problemBody("/api/v1/movies/"+id, http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed), r.Body.String())
+
+	//Неизвестный адрес тоже отвечает problem+json
+	log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), http.StatusNotFound, "", errRouteNotFound).Return(0).Once()
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/movie/get-list", nil)
+	r = httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusNotFound, r.Code)
+	assert.Equal(t, problemContentType, r.Header().Get("Content-Type"))
+	assert.Equal(t, problemBody("/api/v1/movie/get-list", http.StatusNotFound, "route_not_found", "route not found"), r.Body.String())
+
+	//Старые адреса администратора с глаголом в пути больше не обслуживаются
+	log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), http.StatusNotFound, "", errRouteNotFound).Return(0).Once()
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/admin/api-keys/get-list", nil)
+	r = httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusNotFound, r.Code)
+
+	//PUT заменяет фильм целиком, id берется из пути без строки запроса
+	serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(testPrincipal, nil)
+	serv.On("ReplaceMovie", mock.AnythingOfType("*context.valueCtx"), id, movie).Return(nil).Once()
+	log.On("HandlerLog", mock.AnythingOfType("*http.Request"), http.StatusOK, "Movie updated").Return(0).Once()
+	req = httptest.NewRequest(http.MethodPut, "/api/v1/movies/"+id+"?lang=ru", strings.NewReader(body))
+	req.Header.Set("Authorization", "Test-token")
+	r = httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Empty(t, r.Header().Get("Deprecation"))
+
+	//Старый адрес работает, но помечен устаревшим
+	serv.On("UpdateMovie", mock.AnythingOfType("*context.valueCtx"), id, movie).Return(nil).Once()
+	log.On("HandlerLog", mock.AnythingOfType("*http.Request"), http.StatusOK, "Movie updated").Return(0).Once()
+	req = httptest.NewRequest(http.MethodPatch, "/movie/update/"+id, strings.NewReader(body))
+	req.Header.Set("Authorization", "Test-token")
+	r = httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "@1792281600", r.Header().Get("Deprecation"))
+	assert.Equal(t, "Sun, 18 Apr 2027 00:00:00 GMT", r.Header().Get("Sunset"))
+	assert.Equal(t, `</api/v1/movies/`+id+`>; rel="successor-version"`, r.Header().Get("Link"))
+
+	//Адрес без версии - псевдоним /api/v1
+	serv.On("ReplaceMovie", mock.AnythingOfType("*context.valueCtx"), id, movie).Return(nil).Once()
+	log.On("HandlerLog", mock.AnythingOfType("*http.Request"), http.StatusOK, "Movie updated").Return(0).Once()
+	req = httptest.NewRequest(http.MethodPut, "/movies/"+id, strings.NewReader(body))
+	req.Header.Set("Authorization", "Test-token")
+	r = httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "Sun, 18 Apr 2027 00:00:00 GMT", r.Header().Get("Sunset"))
+	assert.Equal(t, `</api/v1/movies/`+id+`>; rel="successor-version"`, r.Header().Get("Link"))
+
+	//Устаревшие actor и movie - условия отбора страницы списка
+	serv.On("GetMovieList", mock.AnythingOfType("*context.valueCtx"), models.MovieFilterParams{ActorNameContains: "Hanks", NameContains: "Star"}, models.SortSpec(nil), models.PageParams{}).Return(&models.MovieList{Movies: []*models.Movie{}}, nil).Once()
+	log.On("HandlerLog", mock.AnythingOfType("*http.Request"), http.StatusOK, "Movie list created").Return(0).Once()
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/movies?actor=Hanks&movie=Star", nil)
+	req.Header.Set("Authorization", "Test-token")
+	r = httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, `{"movies":[],"total":0}`, r.Body.String())
+
+	serv.On("GetMovieList", mock.AnythingOfType("*context.valueCtx"), models.MovieFilterParams{ActorNameContains: "Tom", NameContains: "Wars"}, models.SortSpec(nil), models.PageParams{}).Return(&models.MovieList{Movies: []*models.Movie{}}, nil).Once()
+	log.On("HandlerLog", mock.AnythingOfType("*http.Request"), http.StatusOK, "Movie list created").Return(0).Once()
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/movies?actor=Hanks&actor_name_contains=Tom&movie=Star&name_contains=Wars", nil)
+	req.Header.Set("Authorization", "Test-token")
+	r = httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusOK, r.Code)
+
+	serv.On("GetMovieList", mock.AnythingOfType("*context.valueCtx"), models.MovieFilterParams{}, models.SortSpec{{Field: "name", Desc: true}}, models.PageParams{}).Return(&models.MovieList{Movies: []*models.Movie{}}, nil).Once()
+	log.On("HandlerLog", mock.AnythingOfType("*http.Request"), http.StatusOK, "Movie list created").Return(0).Once()
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/movies?sortby=name", nil)
+	req.Header.Set("Authorization", "Test-token")
+	r = httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusOK, r.Code)
+}
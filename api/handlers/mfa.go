@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+type MFADTO struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type CodeDTO struct {
+	Code string `json:"code"`
+}
+
+// AuthMFA godoc
+// @Summary Вход со вторым фактором
+// @Description Обмен mfa_token, полученного на /auth, и кода TOTP или кода восстановления на пару токенов
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param data body MFADTO true "Входные параметры"
+// @Success 200 {object} models.TokenPair
+// @Failure 400,401,403,409,422,429,500 {object} Problem
+// @Router /api/v1/auth/2fa [post]
+func (h *Handler) AuthMFA(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.problem(w, r, UnprocessableEntity, unreadableBody(err))
+		return
+	}
+	defer r.Body.Close()
+
+	var mfa MFADTO
+	err = json.Unmarshal(body, &mfa)
+	if err != nil || mfa.MFAToken == "" || mfa.Code == "" {
+		h.problem(w, r, ParsingJSONError, invalidJSON(err))
+		return
+	}
+
+	tokens, err := h.services.AuthMFA(r.Context(), mfa.MFAToken, mfa.Code, h.clientIP(r))
+	if err != nil {
+		h.problem(w, r, "Auth 2fa", err)
+		return
+	}
+
+	jsonData, err := json.Marshal(tokens)
+	if err != nil {
+		h.problem(w, r, "Can't marshal result", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusOK, "authorization, two-factor")
+}
+
+// SetupTOTP godoc
+// @Summary Подключение TOTP
+// @Description Создание секрета TOTP для приложения-аутентификатора, секрет начинает действовать после подтверждения на /auth/2fa/verify. В заголовке Authorization передается access токен или mfa_token, если роль требует TOTP
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.TOTPSetup
+// @Failure 401,403,409,500 {object} Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/auth/2fa/setup [post]
+func (h *Handler) SetupTOTP(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	result, err := h.services.SetupTOTP(r.Context(), token)
+	if err != nil {
+		h.problem(w, r, "Setup 2fa", err)
+		return
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		h.problem(w, r, "Can't marshal result", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusOK, "2fa setup started")
+}
+
+// VerifyTOTP godoc
+// @Summary Подтверждение TOTP
+// @Description Подтверждение секрета первым кодом из приложения, в ответе одноразовые коды восстановления. Если в заголовке Authorization передан mfa_token, в ответе также выдаются токены
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param data body CodeDTO true "Входные параметры"
+// @Success 200 {object} models.MFAEnrollment
+// @Failure 400,401,403,409,422,429,500 {object} Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/auth/2fa/verify [post]
+func (h *Handler) VerifyTOTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.problem(w, r, UnprocessableEntity, unreadableBody(err))
+		return
+	}
+	defer r.Body.Close()
+
+	var code CodeDTO
+	err = json.Unmarshal(body, &code)
+	if err != nil || code.Code == "" {
+		h.problem(w, r, ParsingJSONError, invalidJSON(err))
+		return
+	}
+
+	token := r.Header.Get("Authorization")
+	result, err := h.services.VerifyTOTP(r.Context(), token, code.Code, h.clientIP(r))
+	if err != nil {
+		h.problem(w, r, "Verify 2fa", err)
+		return
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		h.problem(w, r, "Can't marshal result", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusOK, "2fa enabled")
+}
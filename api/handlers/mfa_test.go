@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"github.com/ast3am/VKintern-movies/api/handlers/mocks"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_AuthMFA(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+
+	testTable := []struct {
+		name                 string
+		requestBody          []byte
+		serviceErr           error
+		expectedStatusCode   int
+		expectedResponseBody []byte
+	}{
+		{
+			"positive",
+			[]byte(`{"mfa_token": "mfa token", "code": "123456"}`),
+			nil,
+			http.StatusOK,
+			[]byte(`{"token":"some token","refresh_token":"some refresh token"}`),
+		}, {
+			"no code",
+			[]byte(`{"mfa_token": "mfa token"}`),
+			nil,
+			http.StatusUnprocessableEntity,
+			[]byte(problemBody("/api/v1/auth/2fa", http.StatusUnprocessableEntity, "invalid_json", ParsingJSONError)),
+		}, {
+			"wrong code",
+			[]byte(`{"mfa_token": "mfa token", "code": "000000"}`),
+			models.ErrInvalidMFACode,
+			http.StatusBadRequest,
+			[]byte(problemBody("/api/v1/auth/2fa", http.StatusBadRequest, "invalid_mfa_code", models.ErrInvalidMFACode.Error())),
+		}, {
+			"invalid mfa token",
+			[]byte(`{"mfa_token": "mfa token", "code": "123456"}`),
+			models.ErrInvalidToken,
+			http.StatusUnauthorized,
+			[]byte(problemBody("/api/v1/auth/2fa", http.StatusUnauthorized, "invalid_token", InvalidToken)),
+		}, {
+			"db error",
+			[]byte(`{"mfa_token": "mfa token", "code": "123456"}`),
+			errors.New("db error"),
+			http.StatusInternalServerError,
+			[]byte(problemBody("/api/v1/auth/2fa", http.StatusInternalServerError, "internal_error", InternalError)),
+		},
+	}
+	for _, test := range testTable {
+		if test.name == "no code" {
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errInvalidJSON)).Return(0).Once()
+		} else if test.name == "positive" {
+			serv.On("AuthMFA", mock.AnythingOfType("context.backgroundCtx"), "mfa token", "123456", mock.AnythingOfType("string")).Return(&models.TokenPair{AccessToken: "some token", RefreshToken: "some refresh token"}, nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
+		} else {
+			serv.On("AuthMFA", mock.AnythingOfType("context.backgroundCtx"), "mfa token", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, test.serviceErr).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), test.serviceErr).Return(0).Once()
+		}
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/auth/2fa", bytes.NewBuffer(test.requestBody))
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, r.Body.Bytes(), test.name)
+	}
+}
+
+func TestHandler_SetupTOTP(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+
+	//Уже подключен
+	serv.On("SetupTOTP", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(nil, models.ErrMFAAlreadyEnabled).Once()
+	log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), http.StatusConflict, mock.AnythingOfType("string"), models.ErrMFAAlreadyEnabled).Return(0).Once()
+	req, err := http.NewRequest(http.MethodPost, "/api/v1/auth/2fa/setup", nil)
+	assert.Nil(t, err)
+	req.Header.Set("Authorization", "Test-token")
+	r := httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusConflict, r.Code)
+
+	//positive
+	serv.On("SetupTOTP", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(&models.TOTPSetup{Secret: "SECRET", URI: "otpauth://totp/test"}, nil).Once()
+	log.On("HandlerLog", mock.AnythingOfType("*http.Request"), http.StatusOK, mock.AnythingOfType("string")).Return(0).Once()
+	r = httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, `{"secret":"SECRET","uri":"otpauth://totp/test"}`, r.Body.String())
+}
+
+func TestHandler_VerifyTOTP(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+
+	testTable := []struct {
+		name                 string
+		requestBody          []byte
+		result               *models.MFAEnrollment
+		serviceErr           error
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			"wrong json",
+			[]byte(`{wrong json}`),
+			nil,
+			nil,
+			http.StatusUnprocessableEntity,
+			problemBody("/api/v1/auth/2fa/verify", http.StatusUnprocessableEntity, "invalid_json", ParsingJSONError),
+		}, {
+			"setup not started",
+			[]byte(`{"code": "123456"}`),
+			nil,
+			models.ErrMFASetupNotStarted,
+			http.StatusConflict,
+			problemBody("/api/v1/auth/2fa/verify", http.StatusConflict, "mfa_setup_not_started", models.ErrMFASetupNotStarted.Error()),
+		}, {
+			"access token",
+			[]byte(`{"code": "123456"}`),
+			&models.MFAEnrollment{RecoveryCodes: []string{"AAAAA-BBBBB"}},
+			nil,
+			http.StatusOK,
+			`{"recovery_codes":["AAAAA-BBBBB"]}`,
+		}, {
+			"mfa token",
+			[]byte(`{"code": "123456"}`),
+			&models.MFAEnrollment{RecoveryCodes: []string{"AAAAA-BBBBB"}, TokenPair: &models.TokenPair{AccessToken: "some token", RefreshToken: "some refresh token"}},
+			nil,
+			http.StatusOK,
+			`{"recovery_codes":["AAAAA-BBBBB"],"token":"some token","refresh_token":"some refresh token"}`,
+		},
+	}
+	for _, test := range testTable {
+		if test.name == "wrong json" {
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errInvalidJSON)).Return(0).Once()
+		} else if test.serviceErr != nil {
+			serv.On("VerifyTOTP", mock.AnythingOfType("context.backgroundCtx"), "Test-token", "123456", mock.AnythingOfType("string")).Return(nil, test.serviceErr).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), test.serviceErr).Return(0).Once()
+		} else {
+			serv.On("VerifyTOTP", mock.AnythingOfType("context.backgroundCtx"), "Test-token", "123456", mock.AnythingOfType("string")).Return(test.result, nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
+		}
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/auth/2fa/verify", bytes.NewBuffer(test.requestBody))
+		req.Header.Set("Authorization", "Test-token")
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, r.Body.String(), test.name)
+	}
+}
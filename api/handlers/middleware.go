@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"net/http"
+	"strings"
+)
+
+// authorize проверяет заголовок Authorization один раз для запроса и кладет Principal в контекст,
+// обработчик вызывается, только если у Principal есть нужное право
+func (h *Handler) authorize(permission models.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return h.authorizeAny([]models.Permission{permission}, next)
+}
+
+// authorizeAny работает как authorize, но достаточно любого из прав permissions. Обработчик сам
+// проверяет, какие из них есть у Principal
+func (h *Handler) authorizeAny(permissions []models.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, err := h.services.Authenticate(r.Context(), r.Header.Get("Authorization"))
+		if err != nil {
+			h.problem(w, r, "Authenticate", err)
+			return
+		}
+
+		r = r.WithContext(models.ContextWithPrincipal(r.Context(), principal))
+		for _, permission := range permissions {
+			if principal.HasPermission(permission) {
+				next(w, r)
+				return
+			}
+		}
+		names := make([]string, 0, len(permissions))
+		for _, permission := range permissions {
+			names = append(names, string(permission))
+		}
+		h.problem(w, r, strings.Join(names, " or "), models.ErrPermissionDenied)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	models "github.com/ast3am/VKintern-movies/internal/models"
+
+	time "time"
 )
 
 // service is an autogenerated mock type for the service type
@@ -15,23 +17,60 @@ type service struct {
 	mock.Mock
 }
 
-// Auth provides a mock function with given fields: ctx, email, password
-func (_m *service) Auth(ctx context.Context, email string, password string) (string, error) {
-	ret := _m.Called(ctx, email, password)
+// Auth provides a mock function with given fields: ctx, email, password, ip
+func (_m *service) Auth(ctx context.Context, email string, password string, ip string) (*models.TokenPair, *models.MFAChallenge, error) {
+	ret := _m.Called(ctx, email, password, ip)
 
-	var r0 string
+	var r0 *models.TokenPair
+	var r1 *models.MFAChallenge
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*models.TokenPair, *models.MFAChallenge, error)); ok {
+		return rf(ctx, email, password, ip)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *models.TokenPair); ok {
+		r0 = rf(ctx, email, password, ip)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.TokenPair)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) *models.MFAChallenge); ok {
+		r1 = rf(ctx, email, password, ip)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*models.MFAChallenge)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, string) error); ok {
+		r2 = rf(ctx, email, password, ip)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// AuthMFA provides a mock function with given fields: ctx, mfaToken, code, ip
+func (_m *service) AuthMFA(ctx context.Context, mfaToken string, code string, ip string) (*models.TokenPair, error) {
+	ret := _m.Called(ctx, mfaToken, code, ip)
+
+	var r0 *models.TokenPair
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
-		return rf(ctx, email, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*models.TokenPair, error)); ok {
+		return rf(ctx, mfaToken, code, ip)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
-		r0 = rf(ctx, email, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *models.TokenPair); ok {
+		r0 = rf(ctx, mfaToken, code, ip)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.TokenPair)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, email, password)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, mfaToken, code, ip)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -39,46 +78,108 @@ func (_m *service) Auth(ctx context.Context, email string, password string) (str
 	return r0, r1
 }
 
-// CheckToken provides a mock function with given fields: token, permissionLevel
-func (_m *service) CheckToken(token string, permissionLevel string) error {
-	ret := _m.Called(token, permissionLevel)
+// Authenticate provides a mock function with given fields: ctx, authorization
+func (_m *service) Authenticate(ctx context.Context, authorization string) (*models.Principal, error) {
+	ret := _m.Called(ctx, authorization)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(token, permissionLevel)
+	var r0 *models.Principal
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.Principal, error)); ok {
+		return rf(ctx, authorization)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Principal); ok {
+		r0 = rf(ctx, authorization)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Principal)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, authorization)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAPIKey provides a mock function with given fields: ctx, name, permissions, expiresAt
+func (_m *service) CreateAPIKey(ctx context.Context, name string, permissions []models.Permission, expiresAt *time.Time) (*models.CreatedAPIKey, error) {
+	ret := _m.Called(ctx, name, permissions, expiresAt)
+
+	var r0 *models.CreatedAPIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []models.Permission, *time.Time) (*models.CreatedAPIKey, error)); ok {
+		return rf(ctx, name, permissions, expiresAt)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []models.Permission, *time.Time) *models.CreatedAPIKey); ok {
+		r0 = rf(ctx, name, permissions, expiresAt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.CreatedAPIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []models.Permission, *time.Time) error); ok {
+		r1 = rf(ctx, name, permissions, expiresAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // CreateActor provides a mock function with given fields: ctx, actor
-func (_m *service) CreateActor(ctx context.Context, actor models.Actor) error {
+func (_m *service) CreateActor(ctx context.Context, actor models.Actor) (*models.ActorDetails, error) {
 	ret := _m.Called(ctx, actor)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, models.Actor) error); ok {
+	var r0 *models.ActorDetails
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Actor) (*models.ActorDetails, error)); ok {
+		return rf(ctx, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.Actor) *models.ActorDetails); ok {
 		r0 = rf(ctx, actor)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ActorDetails)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, models.Actor) error); ok {
+		r1 = rf(ctx, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // CreateMovie provides a mock function with given fields: ctx, movie
-func (_m *service) CreateMovie(ctx context.Context, movie models.Movie) error {
+func (_m *service) CreateMovie(ctx context.Context, movie models.Movie) (*models.MovieDetails, error) {
 	ret := _m.Called(ctx, movie)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, models.Movie) error); ok {
+	var r0 *models.MovieDetails
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Movie) (*models.MovieDetails, error)); ok {
+		return rf(ctx, movie)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.Movie) *models.MovieDetails); ok {
 		r0 = rf(ctx, movie)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.MovieDetails)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, models.Movie) error); ok {
+		r1 = rf(ctx, movie)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // DeleteActor provides a mock function with given fields: ctx, id
@@ -109,20 +210,76 @@ func (_m *service) DeleteMovie(ctx context.Context, id string) error {
 	return r0
 }
 
-// GetActorList provides a mock function with given fields: ctx
-func (_m *service) GetActorList(ctx context.Context) (map[string][]string, error) {
+// DeleteUser provides a mock function with given fields: ctx, id
+func (_m *service) DeleteUser(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DisableUser provides a mock function with given fields: ctx, id
+func (_m *service) DisableUser(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnableUser provides a mock function with given fields: ctx, id
+func (_m *service) EnableUser(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ForgotPassword provides a mock function with given fields: ctx, email
+func (_m *service) ForgotPassword(ctx context.Context, email string) error {
+	ret := _m.Called(ctx, email)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAPIKeyList provides a mock function with given fields: ctx
+func (_m *service) GetAPIKeyList(ctx context.Context) ([]*models.APIKey, error) {
 	ret := _m.Called(ctx)
 
-	var r0 map[string][]string
+	var r0 []*models.APIKey
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) (map[string][]string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*models.APIKey, error)); ok {
 		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) map[string][]string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) []*models.APIKey); ok {
 		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(map[string][]string)
+			r0 = ret.Get(0).([]*models.APIKey)
 		}
 	}
 
@@ -135,6 +292,98 @@ func (_m *service) GetActorList(ctx context.Context) (map[string][]string, error
 	return r0, r1
 }
 
+// GetActorByID provides a mock function with given fields: ctx, id
+func (_m *service) GetActorByID(ctx context.Context, id string) (*models.ActorDetails, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *models.ActorDetails
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.ActorDetails, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.ActorDetails); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ActorDetails)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetActorList provides a mock function with given fields: ctx, page
+func (_m *service) GetActorList(ctx context.Context, page models.PageParams) (*models.ActorList, error) {
+	ret := _m.Called(ctx, page)
+
+	var r0 *models.ActorList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.PageParams) (*models.ActorList, error)); ok {
+		return rf(ctx, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.PageParams) *models.ActorList); ok {
+		r0 = rf(ctx, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ActorList)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.PageParams) error); ok {
+		r1 = rf(ctx, page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAllMovies provides a mock function with given fields: ctx, filter, sort
+func (_m *service) GetAllMovies(ctx context.Context, filter models.MovieFilterParams, sort models.SortSpec) ([]*models.Movie, error) {
+	ret := _m.Called(ctx, filter, sort)
+
+	var r0 []*models.Movie
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.MovieFilterParams, models.SortSpec) ([]*models.Movie, error)); ok {
+		return rf(ctx, filter, sort)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.MovieFilterParams, models.SortSpec) []*models.Movie); ok {
+		r0 = rf(ctx, filter, sort)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Movie)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.MovieFilterParams, models.SortSpec) error); ok {
+		r1 = rf(ctx, filter, sort)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJWKS provides a mock function with given fields:
+func (_m *service) GetJWKS() models.JWKSet {
+	ret := _m.Called()
+
+	var r0 models.JWKSet
+	if rf, ok := ret.Get(0).(func() models.JWKSet); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(models.JWKSet)
+	}
+
+	return r0
+}
+
 // GetMovie provides a mock function with given fields: ctx, actor, movie
 func (_m *service) GetMovie(ctx context.Context, actor string, movie string) ([]*models.Movie, error) {
 	ret := _m.Called(ctx, actor, movie)
@@ -161,25 +410,390 @@ func (_m *service) GetMovie(ctx context.Context, actor string, movie string) ([]
 	return r0, r1
 }
 
-// GetMovieList provides a mock function with given fields: ctx, sortby, line
-func (_m *service) GetMovieList(ctx context.Context, sortby string, line string) ([]*models.Movie, error) {
-	ret := _m.Called(ctx, sortby, line)
+// GetMovieByID provides a mock function with given fields: ctx, id
+func (_m *service) GetMovieByID(ctx context.Context, id string) (*models.MovieDetails, error) {
+	ret := _m.Called(ctx, id)
 
-	var r0 []*models.Movie
+	var r0 *models.MovieDetails
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]*models.Movie, error)); ok {
-		return rf(ctx, sortby, line)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.MovieDetails, error)); ok {
+		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) []*models.Movie); ok {
-		r0 = rf(ctx, sortby, line)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.MovieDetails); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.Movie)
+			r0 = ret.Get(0).(*models.MovieDetails)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMovieList provides a mock function with given fields: ctx, filter, sort, page
+func (_m *service) GetMovieList(ctx context.Context, filter models.MovieFilterParams, sort models.SortSpec, page models.PageParams) (*models.MovieList, error) {
+	ret := _m.Called(ctx, filter, sort, page)
+
+	var r0 *models.MovieList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.MovieFilterParams, models.SortSpec, models.PageParams) (*models.MovieList, error)); ok {
+		return rf(ctx, filter, sort, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.MovieFilterParams, models.SortSpec, models.PageParams) *models.MovieList); ok {
+		r0 = rf(ctx, filter, sort, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.MovieList)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.MovieFilterParams, models.SortSpec, models.PageParams) error); ok {
+		r1 = rf(ctx, filter, sort, page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUser provides a mock function with given fields: ctx, id
+func (_m *service) GetUser(ctx context.Context, id string) (*models.User, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.User, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserList provides a mock function with given fields: ctx, limit, offset
+func (_m *service) GetUserList(ctx context.Context, limit string, offset string) (*models.UserList, error) {
+	ret := _m.Called(ctx, limit, offset)
+
+	var r0 *models.UserList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*models.UserList, error)); ok {
+		return rf(ctx, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.UserList); ok {
+		r0 = rf(ctx, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.UserList)
 		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, sortby, line)
+		r1 = rf(ctx, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Logout provides a mock function with given fields: ctx, token, refreshToken
+func (_m *service) Logout(ctx context.Context, token string, refreshToken string) error {
+	ret := _m.Called(ctx, token, refreshToken)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, token, refreshToken)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// OIDCCallback provides a mock function with given fields: ctx, state, code
+func (_m *service) OIDCCallback(ctx context.Context, state string, code string) (*models.TokenPair, error) {
+	ret := _m.Called(ctx, state, code)
+
+	var r0 *models.TokenPair
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*models.TokenPair, error)); ok {
+		return rf(ctx, state, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.TokenPair); ok {
+		r0 = rf(ctx, state, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.TokenPair)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, state, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OIDCLogin provides a mock function with given fields: ctx
+func (_m *service) OIDCLogin(ctx context.Context) (string, string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 string
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context) (string, string, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) string); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) string); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context) error); ok {
+		r2 = rf(ctx)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Refresh provides a mock function with given fields: ctx, refreshToken
+func (_m *service) Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	ret := _m.Called(ctx, refreshToken)
+
+	var r0 *models.TokenPair
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.TokenPair, error)); ok {
+		return rf(ctx, refreshToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.TokenPair); ok {
+		r0 = rf(ctx, refreshToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.TokenPair)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, refreshToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Register provides a mock function with given fields: ctx, email, password
+func (_m *service) Register(ctx context.Context, email string, password string) error {
+	ret := _m.Called(ctx, email, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, email, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplaceActor provides a mock function with given fields: ctx, id, actor
+func (_m *service) ReplaceActor(ctx context.Context, id string, actor models.Actor) error {
+	ret := _m.Called(ctx, id, actor)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Actor) error); ok {
+		r0 = rf(ctx, id, actor)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplaceMovie provides a mock function with given fields: ctx, id, movie
+func (_m *service) ReplaceMovie(ctx context.Context, id string, movie models.Movie) error {
+	ret := _m.Called(ctx, id, movie)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Movie) error); ok {
+		r0 = rf(ctx, id, movie)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetPassword provides a mock function with given fields: ctx, token, password
+func (_m *service) ResetPassword(ctx context.Context, token string, password string) error {
+	ret := _m.Called(ctx, token, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, token, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetUserPassword provides a mock function with given fields: ctx, id, password
+func (_m *service) ResetUserPassword(ctx context.Context, id string, password string) error {
+	ret := _m.Called(ctx, id, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, id, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeAPIKey provides a mock function with given fields: ctx, id
+func (_m *service) RevokeAPIKey(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeUserTokens provides a mock function with given fields: ctx, id
+func (_m *service) RevokeUserTokens(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Search provides a mock function with given fields: ctx, params
+func (_m *service) Search(ctx context.Context, params models.SearchParams) (*models.SearchResults, error) {
+	ret := _m.Called(ctx, params)
+
+	var r0 *models.SearchResults
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.SearchParams) (*models.SearchResults, error)); ok {
+		return rf(ctx, params)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.SearchParams) *models.SearchResults); ok {
+		r0 = rf(ctx, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.SearchResults)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.SearchParams) error); ok {
+		r1 = rf(ctx, params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetUserRoles provides a mock function with given fields: ctx, id, roles
+func (_m *service) SetUserRoles(ctx context.Context, id string, roles []string) error {
+	ret := _m.Called(ctx, id, roles)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) error); ok {
+		r0 = rf(ctx, id, roles)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetupTOTP provides a mock function with given fields: ctx, token
+func (_m *service) SetupTOTP(ctx context.Context, token string) (*models.TOTPSetup, error) {
+	ret := _m.Called(ctx, token)
+
+	var r0 *models.TOTPSetup
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.TOTPSetup, error)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.TOTPSetup); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.TOTPSetup)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Suggest provides a mock function with given fields: ctx, params
+func (_m *service) Suggest(ctx context.Context, params models.SuggestParams) (*models.Suggestions, error) {
+	ret := _m.Called(ctx, params)
+
+	var r0 *models.Suggestions
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.SuggestParams) (*models.Suggestions, error)); ok {
+		return rf(ctx, params)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.SuggestParams) *models.Suggestions); ok {
+		r0 = rf(ctx, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Suggestions)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.SuggestParams) error); ok {
+		r1 = rf(ctx, params)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -215,6 +829,32 @@ func (_m *service) UpdateMovie(ctx context.Context, id string, movie models.Movi
 	return r0
 }
 
+// VerifyTOTP provides a mock function with given fields: ctx, token, code, ip
+func (_m *service) VerifyTOTP(ctx context.Context, token string, code string, ip string) (*models.MFAEnrollment, error) {
+	ret := _m.Called(ctx, token, code, ip)
+
+	var r0 *models.MFAEnrollment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*models.MFAEnrollment, error)); ok {
+		return rf(ctx, token, code, ip)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *models.MFAEnrollment); ok {
+		r0 = rf(ctx, token, code, ip)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.MFAEnrollment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, token, code, ip)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTnewService interface {
 	mock.TestingT
 	Cleanup(func())
@@ -1,104 +1,126 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"github.com/ast3am/VKintern-movies/internal/models"
 	"io"
 	"net/http"
 	"net/url"
-	"path"
+	"strings"
 )
 
 // CreateMovie godoc
 // @Summary Создание фильма
-// @Description Создание фильма, предполагается что все поля не пустые
+// @Description Создание фильма, в ответе созданный фильм с UUID и составом актеров, его адрес передается в заголовке Location
 // @Tags movie
 // @Accept json
 // @Produce json
 // @Param data body models.Movie true "Входные параметры"
-// @Success 200 {object} string
-// @Failure 400,401,405,422 {object} error
-// @Router /movie/create [post]
+// @Success 201 {object} models.MovieDetails
+// @Header 201 {string} Location "Адрес созданного фильма"
+// @Failure 401,403,409,422,500 {object} Problem
+// @Router /api/v1/movies [post]
 // @Security ApiKeyAuth
 func (h *Handler) CreateMovie(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		h.log.HandlerErrorLog(r, http.StatusMethodNotAllowed, "", errors.New(MethodNotAllowed))
-		http.Error(w, MethodNotAllowed, http.StatusMethodNotAllowed)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.problem(w, r, UnprocessableEntity, unreadableBody(err))
 		return
 	}
+	defer r.Body.Close()
 
-	token := r.Header.Get("Authorization")
-	err := h.services.CheckToken(token, Admin)
+	var movie models.Movie
+	err = movie.UnmarshalJSON(body)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnauthorized, InvalidToken, err)
-		http.Error(w, InvalidToken, http.StatusUnauthorized)
+		h.problem(w, r, ParsingJSONError, invalidJSON(err))
 		return
 	}
 
-	body, err := io.ReadAll(r.Body)
+	result, err := h.services.CreateMovie(r.Context(), movie)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnprocessableEntity, UnprocessableEntity, err)
-		http.Error(w, UnprocessableEntity, http.StatusUnprocessableEntity)
+		h.problem(w, r, "Create movie", err)
 		return
 	}
-	defer r.Body.Close()
-
-	var movie models.Movie
-	err = movie.UnmarshalJSON(body)
+	jsonData, err := json.Marshal(result)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnprocessableEntity, ParsingJSONError, err)
-		http.Error(w, ParsingJSONError, http.StatusUnprocessableEntity)
+		h.problem(w, r, "Can't marshal result", err)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", apiV1+"/movies/"+result.ID.String())
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusCreated, "Movie created")
+}
+
+// GetMovieByID godoc
+// @Summary Получение фильма
+// @Description Получение фильма по UUID вместе с составом актеров
+// @Tags movie
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID фильма"
+// @Success 200 {object} models.MovieDetails
+// @Failure 400,401,403,404,500 {object} Problem
+// @Router /api/v1/movies/{id} [get]
+// @Security ApiKeyAuth
+func (h *Handler) GetMovieByID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
 
-	err = h.services.CreateMovie(r.Context(), movie)
+	result, err := h.services.GetMovieByID(r.Context(), id)
 	if err != nil {
-		if err.Error() == UnprocessableEntity {
-			h.log.HandlerErrorLog(r, http.StatusUnprocessableEntity, "Create movie", err)
-			http.Error(w, UnprocessableEntity, http.StatusUnprocessableEntity)
-			return
-		} else {
-			h.log.HandlerErrorLog(r, http.StatusBadRequest, "Create movie", err)
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
+		h.problem(w, r, "Get movie", err)
+		return
+	}
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		h.problem(w, r, "Can't marshal result", err)
+		return
 	}
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Movie created"))
-	h.log.HandlerLog(r, http.StatusOK, "Movie created")
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusOK, "Get movie")
 }
 
 // UpdateMovie godoc
 // @Summary Изменение информации о фильме
-// @Description Информация может быть изменена как частично, так и полностью
+// @Description Частичное изменение, незаполненные поля сохраняют текущие значения
 // @Tags movie
 // @Accept json
 // @Produce json
+// @Param id path string true "UUID фильма"
 // @Param data body models.Movie true "Входные параметры"
 // @Success 200 {object} string
-// @Failure 400,401,405,422 {object} error
-// @Router /movie/update/ [patch]
+// @Failure 400,401,403,404,409,422,500 {object} Problem
+// @Router /api/v1/movies/{id} [patch]
 // @Security ApiKeyAuth
 func (h *Handler) UpdateMovie(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPatch {
-		h.log.HandlerErrorLog(r, http.StatusMethodNotAllowed, "", errors.New(MethodNotAllowed))
-		http.Error(w, MethodNotAllowed, http.StatusMethodNotAllowed)
-		return
-	}
+	h.saveMovie(w, r, h.services.UpdateMovie)
+}
 
-	token := r.Header.Get("Authorization")
-	err := h.services.CheckToken(token, Admin)
-	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnauthorized, InvalidToken, err)
-		http.Error(w, InvalidToken, http.StatusUnauthorized)
-		return
-	}
+// ReplaceMovie godoc
+// @Summary Замена информации о фильме
+// @Description Полная замена, незаполненные поля очищаются, пустой actor_list очищает список актеров
+// @Tags movie
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID фильма"
+// @Param data body models.Movie true "Входные параметры"
+// @Success 200 {object} string
+// @Failure 400,401,403,404,409,422,500 {object} Problem
+// @Router /api/v1/movies/{id} [put]
+// @Security ApiKeyAuth
+func (h *Handler) ReplaceMovie(w http.ResponseWriter, r *http.Request) {
+	h.saveMovie(w, r, h.services.ReplaceMovie)
+}
 
+func (h *Handler) saveMovie(w http.ResponseWriter, r *http.Request, save func(ctx context.Context, id string, movie models.Movie) error) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnprocessableEntity, UnprocessableEntity, err)
-		http.Error(w, UnprocessableEntity, http.StatusUnprocessableEntity)
+		h.problem(w, r, UnprocessableEntity, unreadableBody(err))
 		return
 	}
 	defer r.Body.Close()
@@ -106,24 +128,16 @@ func (h *Handler) UpdateMovie(w http.ResponseWriter, r *http.Request) {
 	var movie models.Movie
 	err = movie.UnmarshalJSON(body)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnprocessableEntity, ParsingJSONError, err)
-		http.Error(w, ParsingJSONError, http.StatusUnprocessableEntity)
+		h.problem(w, r, ParsingJSONError, invalidJSON(err))
 		return
 	}
 
-	id := path.Base(r.URL.String())
+	id := r.PathValue("id")
 
-	err = h.services.UpdateMovie(r.Context(), id, movie)
+	err = save(r.Context(), id, movie)
 	if err != nil {
-		if err.Error() == UnprocessableEntity {
-			h.log.HandlerErrorLog(r, http.StatusUnprocessableEntity, "Update movie", err)
-			http.Error(w, UnprocessableEntity, http.StatusUnprocessableEntity)
-			return
-		} else {
-			h.log.HandlerErrorLog(r, http.StatusBadRequest, "Update movie", err)
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
+		h.problem(w, r, "Update movie", err)
+		return
 	}
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Movie updated"))
@@ -136,32 +150,17 @@ func (h *Handler) UpdateMovie(w http.ResponseWriter, r *http.Request) {
 // @Tags movie
 // @Accept json
 // @Produce json
-// @Param uuid query string true "UUID фильма"
+// @Param id path string true "UUID фильма"
 // @Success 200 {object} string
-// @Failure 400,401,405 {object} error
-// @Router /movie/delete [delete]
+// @Failure 400,401,403,404,500 {object} Problem
+// @Router /api/v1/movies/{id} [delete]
 // @Security ApiKeyAuth
 func (h *Handler) DeleteMovie(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		h.log.HandlerErrorLog(r, http.StatusMethodNotAllowed, "", errors.New(MethodNotAllowed))
-		http.Error(w, MethodNotAllowed, http.StatusMethodNotAllowed)
-		return
-	}
-
-	token := r.Header.Get("Authorization")
-	err := h.services.CheckToken(token, Admin)
-	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnauthorized, InvalidToken, err)
-		http.Error(w, InvalidToken, http.StatusUnauthorized)
-		return
-	}
-
-	id := path.Base(r.URL.String())
+	id := r.PathValue("id")
 
-	err = h.services.DeleteMovie(r.Context(), id)
+	err := h.services.DeleteMovie(r.Context(), id)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusBadRequest, "Delete actor", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.problem(w, r, "Delete movie", err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -171,56 +170,114 @@ func (h *Handler) DeleteMovie(w http.ResponseWriter, r *http.Request) {
 
 // GetMoviesList godoc
 // @Summary Получение списка фильмов
-// @Description Получение списка с возможностью сортировки, параметры задаются в URL
+// @Description Страница списка фильмов, отобранного по условиям, с сортировкой sort.
+// @Description Страница задается параметрами limit и cursor или page и per_page, ссылки на соседние страницы передаются в заголовке Link
 // @Tags movie
 // @Accept json
 // @Produce json
-// @Param sortby query string false "Указания поля для сортировки, по умолчанию rating)"
-// @Param line query string false "Указание типа сортировки, по умолчанию desc"
-// @Success 200 {object} models.Movie
-// @Failure 400,401,405 {object} error
-// @Failure 500 {object} error
-// @Router /movie/get-list [get]
+// @Param sort query string false "Поля сортировки через запятую, минус перед полем - по убыванию: name, rating, release_date, rank (только с q), по умолчанию -rating, с q -rank" example(-rating,release_date,name)
+// @Param sortby query string false "Устарел, используйте sort. Поле сортировки, учитывается, если не задан sort"
+// @Param line query string false "Устарел, используйте sort. Направление сортировки sortby: asc, desc, по умолчанию desc"
+// @Param limit query int false "Размер страницы при выводе по курсору"
+// @Param cursor query string false "Курсор следующей страницы из next_cursor"
+// @Param page query int false "Номер страницы, начиная с 1"
+// @Param per_page query int false "Размер страницы при выводе по номеру страницы"
+// @Param released_from query string false "Фильмы, вышедшие не раньше даты, в формате YYYY-MM-DD"
+// @Param released_to query string false "Фильмы, вышедшие не позже даты, в формате YYYY-MM-DD"
+// @Param rating_min query number false "Минимальный рейтинг"
+// @Param rating_max query number false "Максимальный рейтинг"
+// @Param actor_id query []string false "UUID актеров, можно повторять или перечислять через запятую" collectionFormat(multi)
+// @Param actor_match query string false "any - снимался любой из актеров actor_id (по умолчанию), all - все" Enums(any, all)
+// @Param name_contains query string false "Фрагмент названия без учета регистра"
+// @Param actor_name_contains query string false "Фрагмент имени любого из актеров фильма без учета регистра"
+// @Param q query string false "Полнотекстовый поиск по названию и описанию с учетом словоформ, синтаксис websearch: \"точная фраза\", or, -исключить"
+// @Param actor query string false "Устарел, используйте actor_name_contains. Учитывается, если не задан actor_name_contains"
+// @Param movie query string false "Устарел, используйте name_contains. Учитывается, если не задан name_contains"
+// @Success 200 {object} models.MovieList
+// @Header 200 {string} Link "Ссылки first, prev, next, last"
+// @Failure 400,401,403,422 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/v1/movies [get]
 // @Security ApiKeyAuth
 func (h *Handler) GetMoviesList(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		h.log.HandlerErrorLog(r, http.StatusMethodNotAllowed, "", errors.New(MethodNotAllowed))
-		http.Error(w, MethodNotAllowed, http.StatusMethodNotAllowed)
+	parseURL, err := url.Parse(r.URL.String())
+	if err != nil {
+		h.problem(w, r, "Get movie list URL parse error", err)
 		return
 	}
 
-	token := r.Header.Get("Authorization")
-	err := h.services.CheckToken(token, User)
+	queryParams, err := url.ParseQuery(parseURL.RawQuery)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnauthorized, InvalidToken, err)
-		http.Error(w, InvalidToken, http.StatusUnauthorized)
+		h.problem(w, r, "Get movie list URL parse error", fmt.Errorf("%w: %w", errInvalidQuery, err))
 		return
 	}
 
-	parseURL, err := url.Parse(r.URL.String())
+	sort, err := movieSort(queryParams)
+	if err != nil {
+		h.problem(w, r, "Get movie list sort parse error", err)
+		return
+	}
+	movieList, err := h.services.GetMovieList(r.Context(), movieFilterParams(queryParams), sort, pageParams(queryParams))
+	if err != nil {
+		h.problem(w, r, "Get movie list", err)
+		return
+	}
+	jsonData, err := json.Marshal(movieList)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusBadRequest, "Get movie list URL parse error", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.problem(w, r, "Can't marshal result", err)
 		return
 	}
+	setPageLinks(w, r, movieList.PageInfo)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusOK, "Movie list created")
+}
 
-	queryParams, err := url.ParseQuery(parseURL.RawQuery)
+// GetAllMovies godoc
+// @Summary Получение списка фильмов
+// @Description Получение всего списка фильмов одним массивом с возможностью отбора и сортировки, параметры задаются в URL. Постраничный вывод есть только в GET /api/v1/movies
+// @Tags movie
+// @Accept json
+// @Produce json
+// @Param sort query string false "Поля сортировки через запятую, минус перед полем - по убыванию: name, rating, release_date, rank (только с q), по умолчанию -rating, с q -rank" example(-rating,release_date,name)
+// @Param sortby query string false "Устарел, используйте sort. Поле сортировки, учитывается, если не задан sort"
+// @Param line query string false "Устарел, используйте sort. Направление сортировки sortby: asc, desc, по умолчанию desc"
+// @Param released_from query string false "Фильмы, вышедшие не раньше даты, в формате YYYY-MM-DD"
+// @Param released_to query string false "Фильмы, вышедшие не позже даты, в формате YYYY-MM-DD"
+// @Param rating_min query number false "Минимальный рейтинг"
+// @Param rating_max query number false "Максимальный рейтинг"
+// @Param actor_id query []string false "UUID актеров, можно повторять или перечислять через запятую" collectionFormat(multi)
+// @Param actor_match query string false "any - снимался любой из актеров actor_id (по умолчанию), all - все" Enums(any, all)
+// @Param name_contains query string false "Фрагмент названия без учета регистра"
+// @Param q query string false "Полнотекстовый поиск по названию и описанию с учетом словоформ, синтаксис websearch: \"точная фраза\", or, -исключить"
+// @Success 200 {array} models.Movie
+// @Failure 400,401,403,422 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /movie/get-list [get]
+// @Deprecated
+// @Security ApiKeyAuth
+func (h *Handler) GetAllMovies(w http.ResponseWriter, r *http.Request) {
+	queryParams, err := url.ParseQuery(r.URL.RawQuery)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusBadRequest, "Get movie list URL parse error", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.problem(w, r, "Get movie list URL parse error", fmt.Errorf("%w: %w", errInvalidQuery, err))
 		return
 	}
 
-	movieArr, err := h.services.GetMovieList(r.Context(), queryParams.Get("sortby"), queryParams.Get("line"))
+	sort, err := movieSort(queryParams)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusBadRequest, "Get movie list", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.problem(w, r, "Get movie list sort parse error", err)
 		return
 	}
-	jsonData, err := json.Marshal(movieArr)
+	movies, err := h.services.GetAllMovies(r.Context(), movieFilterParams(queryParams), sort)
+	if err != nil {
+		h.problem(w, r, "Get movie list", err)
+		return
+	}
+	jsonData, err := json.Marshal(movies)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusInternalServerError, "Can't marshal result", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.problem(w, r, "Can't marshal result", err)
+		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -228,6 +285,53 @@ func (h *Handler) GetMoviesList(w http.ResponseWriter, r *http.Request) {
 	h.log.HandlerLog(r, http.StatusOK, "Movie list created")
 }
 
+// movieFilterParams собирает условия отбора фильмов. Устаревшие actor и movie из поиска /movie/get-movie
+// учитываются, если не заданы actor_name_contains и name_contains
+func movieFilterParams(query url.Values) models.MovieFilterParams {
+	result := models.MovieFilterParams{
+		ReleasedFrom:      query.Get("released_from"),
+		ReleasedTo:        query.Get("released_to"),
+		RatingMin:         query.Get("rating_min"),
+		RatingMax:         query.Get("rating_max"),
+		ActorIDs:          query["actor_id"],
+		ActorMatch:        query.Get("actor_match"),
+		ActorNameContains: query.Get("actor_name_contains"),
+		NameContains:      query.Get("name_contains"),
+		Query:             query.Get("q"),
+	}
+	if result.ActorNameContains == "" {
+		result.ActorNameContains = query.Get("actor")
+	}
+	if result.NameContains == "" {
+		result.NameContains = query.Get("movie")
+	}
+	return result
+}
+
+// movieSort разбирает сортировку списка фильмов из параметра sort. Устаревшие sortby и line учитываются,
+// если sort не задан, и задают сортировку по одному полю
+func movieSort(query url.Values) (models.SortSpec, error) {
+	sort := query.Get("sort")
+	if sort == "" && (query.Has("sortby") || query.Has("line")) {
+		sortby := query.Get("sortby")
+		if sortby == "" {
+			sortby = "rating"
+		}
+		if strings.ContainsAny(sortby, "-,") {
+			return nil, models.ErrInvalidSort
+		}
+		switch strings.ToLower(query.Get("line")) {
+		case "", "desc":
+			sort = "-" + sortby
+		case "asc":
+			sort = sortby
+		default:
+			return nil, models.ErrInvalidSort
+		}
+	}
+	return models.ParseMovieSort(sort)
+}
+
 // GetMovie godoc
 // @Summary Получение списка фильмов
 // @Description Получение списка фильмов с поиском по фрагменту названия и фрагменту имени актера
@@ -237,49 +341,33 @@ func (h *Handler) GetMoviesList(w http.ResponseWriter, r *http.Request) {
 // @Param actor query string false "Указание актера"
 // @Param movie query string false "Указание названия фильма"
 // @Success 200 {object} models.Movie
-// @Failure 400,401,405 {object} error
-// @Failure 500 {object} error
+// @Failure 400,401,403 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /movie/get-movie [get]
+// @Deprecated
 // @Security ApiKeyAuth
 func (h *Handler) GetMovie(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		h.log.HandlerErrorLog(r, http.StatusMethodNotAllowed, "", errors.New(MethodNotAllowed))
-		http.Error(w, MethodNotAllowed, http.StatusMethodNotAllowed)
-		return
-	}
-
-	token := r.Header.Get("Authorization")
-	err := h.services.CheckToken(token, User)
-	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusUnauthorized, InvalidToken, err)
-		http.Error(w, InvalidToken, http.StatusUnauthorized)
-		return
-	}
-
 	parseURL, err := url.Parse(r.URL.String())
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusBadRequest, "Get movie URL parse error", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.problem(w, r, "Get movie URL parse error", err)
 		return
 	}
 
 	queryParams, err := url.ParseQuery(parseURL.RawQuery)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusBadRequest, "Get movie URL parse error", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.problem(w, r, "Get movie URL parse error", fmt.Errorf("%w: %w", errInvalidQuery, err))
 		return
 	}
 
 	movieArr, err := h.services.GetMovie(r.Context(), queryParams.Get("actor"), queryParams.Get("movie"))
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusBadRequest, "Get movie list", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.problem(w, r, "Get movie list", err)
 		return
 	}
 	jsonData, err := json.Marshal(movieArr)
 	if err != nil {
-		h.log.HandlerErrorLog(r, http.StatusInternalServerError, "Can't marshal result", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.problem(w, r, "Can't marshal result", err)
+		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -5,10 +5,12 @@ import (
 	"errors"
 	"github.com/ast3am/VKintern-movies/api/handlers/mocks"
 	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 )
@@ -36,64 +38,78 @@ func TestHandler_CreateMovie(t *testing.T) {
 		{
 			"wrong method",
 			[]byte(`{"name": "Forrest Gump", "description": "Description 1", "release_date": "1994-07-06", "rating": 8.5, "actor_list": ["Tom Hanks", "Brad Pitt"]}`),
-			http.MethodGet,
+			http.MethodDelete,
 			http.StatusMethodNotAllowed,
-			[]byte(`invalid request method` + "\n"),
+			[]byte(problemBody("/api/v1/movies", http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed)),
 		}, {
 			"wrong token",
 			[]byte(`{"name": "Forrest Gump", "description": "Description 1", "release_date": "1994-07-06", "rating": 8.5, "actor_list": ["Tom Hanks", "Brad Pitt"]}`),
 			http.MethodPost,
 			http.StatusUnauthorized,
-			[]byte(`invalid token` + "\n"),
+			[]byte(problemBody("/api/v1/movies", http.StatusUnauthorized, "invalid_token", InvalidToken)),
 		}, {
 			"wrong json",
 			[]byte(`"some data"`),
 			http.MethodPost,
 			http.StatusUnprocessableEntity,
-			[]byte(`JSON parsing error` + "\n"),
+			[]byte(problemBody("/api/v1/movies", http.StatusUnprocessableEntity, "invalid_json", ParsingJSONError)),
 		}, {
 			"no valid data",
 			[]byte(`{"name": "", "description": "Description 1", "release_date": "1994-07-06", "rating": 8.5, "actor_list": ["Tom Hanks", "Brad Pitt"]}`),
 			http.MethodPost,
 			http.StatusUnprocessableEntity,
-			[]byte(`no valid data` + "\n"),
+			[]byte(problemBody("/api/v1/movies", http.StatusUnprocessableEntity, models.CodeValidation, "validation failed", models.FieldError{Field: "name", Message: "must be 1-150 characters long"})),
 		}, {
-			"another error service",
+			"movie exists",
 			[]byte(`{"name": "Forrest Gump", "description": "Description 1", "release_date": "1994-07-06", "rating": 8.5, "actor_list": ["Tom Hanks", "Brad Pitt"]}`),
 			http.MethodPost,
-			http.StatusBadRequest,
-			[]byte(`service error` + "\n"),
+			http.StatusConflict,
+			[]byte(problemBody("/api/v1/movies", http.StatusConflict, "movie_exists", models.ErrMovieExists.Error())),
 		}, {
 			"positive",
 			[]byte(`{"name": "Forrest Gump", "description": "Description 1", "release_date": "1994-07-06", "rating": 8.5, "actor_list": ["Tom Hanks", "Brad Pitt"]}`),
 			http.MethodPost,
-			http.StatusOK,
-			[]byte(`Movie created`),
+			http.StatusCreated,
+			[]byte(`{"id":"20648636-b14e-4f88-a02a-8c4e3c2f534d","name":"Forrest Gump","description":"Description 1","release_date":"1994-07-06T00:00:00Z","rating":8.5,` +
+				`"cast":[{"id":null,"name":"Brad Pitt"},{"id":"b0482c7a-1a4c-4a3c-9463-35f0036a0d60","name":"Tom Hanks"}],` +
+				`"created_at":"2024-03-16T10:00:00Z","updated_at":"2024-03-16T10:00:00Z"}`),
 		},
 	}
+	actorID := uuid.MustParse("b0482c7a-1a4c-4a3c-9463-35f0036a0d60")
+	created := &models.MovieDetails{
+		ID:          uuid.MustParse("20648636-b14e-4f88-a02a-8c4e3c2f534d"),
+		Name:        "Forrest Gump",
+		Description: "Description 1",
+		ReleaseDate: GetDate("1994-07-06"),
+		Rating:      8.5,
+		Cast:        []models.CastMember{{Name: "Brad Pitt"}, {ID: &actorID, Name: "Tom Hanks"}},
+		CreatedAt:   time.Date(2024, time.March, 16, 10, 0, 0, 0, time.UTC),
+		UpdatedAt:   time.Date(2024, time.March, 16, 10, 0, 0, 0, time.UTC),
+	}
 	for _, test := range testTable {
 		if test.name == "wrong method" {
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New("invalid request method")).Return(0)
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "", errMethodNotAllowed).Return(0)
 		} else if test.name == "wrong token" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(errors.New(InvalidToken)).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(InvalidToken)).Return(0)
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(nil, models.ErrInvalidToken).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrInvalidToken).Return(0)
 		} else if test.name == "wrong json" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), mock.AnythingOfType("*json.UnmarshalTypeError")).Return(0)
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil)
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errInvalidJSON)).Return(0)
 		} else if test.name == "no valid data" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
-			serv.On("CreateMovie", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("models.Movie")).Return(errors.New(UnprocessableEntity)).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(UnprocessableEntity)).Return(0)
-		} else if test.name == "another error service" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
-			serv.On("CreateMovie", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("models.Movie")).Return(errors.New("service error")).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New("service error")).Return(0)
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil)
+			validationErr := &models.ValidationError{Fields: []models.FieldError{{Field: "name", Message: "must be 1-150 characters long"}}}
+			serv.On("CreateMovie", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("models.Movie")).Return(nil, validationErr).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), validationErr).Return(0)
+		} else if test.name == "movie exists" {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil)
+			serv.On("CreateMovie", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("models.Movie")).Return(nil, models.ErrMovieExists).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrMovieExists).Return(0)
 		} else if test.name == "positive" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
-			serv.On("CreateMovie", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("models.Movie")).Return(nil).Once()
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil)
+			serv.On("CreateMovie", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("models.Movie")).Return(created, nil).Once()
 			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0)
 		}
-		req, err := http.NewRequest(test.httpMethod, "/movie/create", bytes.NewBuffer(test.requestBody))
+		req, err := http.NewRequest(test.httpMethod, "/api/v1/movies", bytes.NewBuffer(test.requestBody))
 		req.Header.Set("Authorization", "Test-token")
 		r := httptest.NewRecorder()
 		mux.ServeHTTP(r, req)
@@ -102,6 +118,9 @@ func TestHandler_CreateMovie(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, test.expectedStatusCode, r.Code)
 		assert.Equal(t, test.expectedResponseBody, responseBody)
+		if test.name == "positive" {
+			assert.Equal(t, "/api/v1/movies/20648636-b14e-4f88-a02a-8c4e3c2f534d", r.Header().Get("Location"))
+		}
 	}
 }
 
@@ -121,47 +140,101 @@ func TestHandler_GetMoviesList(t *testing.T) {
 	}{
 		{
 			"wrong method",
-			http.MethodPost,
+			http.MethodDelete,
 			"sortby=name&line=asc",
 			http.StatusMethodNotAllowed,
-			[]byte(`invalid request method` + "\n"),
+			[]byte(problemBody("/api/v1/movies", http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed)),
 		}, {
 			"wrong token",
 			http.MethodGet,
 			"sortby=name&line=asc",
 			http.StatusUnauthorized,
-			[]byte(`invalid token` + "\n"),
+			[]byte(problemBody("/api/v1/movies", http.StatusUnauthorized, "invalid_token", InvalidToken)),
 		}, {
 			"wrong service",
 			http.MethodGet,
 			"sortby=name&line=asc",
+			http.StatusInternalServerError,
+			[]byte(problemBody("/api/v1/movies", http.StatusInternalServerError, "internal_error", InternalError)),
+		}, {
+			"invalid query",
+			http.MethodGet,
+			"sortby=name;line=asc",
+			http.StatusBadRequest,
+			[]byte(problemBody("/api/v1/movies", http.StatusBadRequest, "invalid_query", "invalid query string")),
+		}, {
+			"invalid pagination",
+			http.MethodGet,
+			"sortby=name&line=asc&limit=1000",
+			http.StatusUnprocessableEntity,
+			[]byte(problemBody("/api/v1/movies", http.StatusUnprocessableEntity, "invalid_pagination", models.ErrInvalidPagination.Error())),
+		}, {
+			"unknown sort field",
+			http.MethodGet,
+			"sort=-rating,description",
 			http.StatusBadRequest,
-			[]byte(`service error` + "\n"),
+			[]byte(problemBody("/api/v1/movies", http.StatusBadRequest, "invalid_sort", `unknown sort field "description", allowed fields: name, rating, release_date, rank`)),
 		}, {
 			"positive",
 			http.MethodGet,
-			"sortby=name&line=asc",
+			"sortby=name&line=asc&limit=1&rating_min=7&actor_id=b0482c7a-1a4c-4a3c-9463-35f0036a0d60",
 			http.StatusOK,
-			[]byte(`[{"name":"hatiko","description":"the movie about sad dog","release_date":"1992-04-01T00:00:00Z","rating":9.1,"actor_list":["suize"]}]`),
+			[]byte(`{"movies":[{"id":"b19069b7-7296-4e67-a5f2-80c6e01a32d0","name":"hatiko","description":"the movie about sad dog","release_date":"1992-04-01T00:00:00Z","rating":9.1,"actor_list":["suize"],` +
+				`"created_at":"2024-03-16T10:00:00Z","updated_at":"2024-03-17T10:00:00Z"}],"total":2,"limit":1,"next_cursor":"next"}`),
+		}, {
+			"full text query",
+			http.MethodGet,
+			"q=sad+dog",
+			http.StatusOK,
+			[]byte(`{"movies":[{"id":"b19069b7-7296-4e67-a5f2-80c6e01a32d0","name":"hatiko","description":"the movie about sad dog","release_date":"1992-04-01T00:00:00Z","rating":9.1,"actor_list":[],` +
+				`"rank":0.6,"headline":"the movie about \u003cmark\u003esad\u003c/mark\u003e \u003cmark\u003edog\u003c/mark\u003e",` +
+				`"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}],"total":1,"limit":20}`),
 		},
 	}
 	for _, test := range testTable {
 		if test.name == "wrong method" {
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New("invalid request method")).Return(0).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "", errMethodNotAllowed).Return(0)
 		} else if test.name == "wrong token" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(errors.New(InvalidToken)).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(InvalidToken)).Return(0).Once()
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(nil, models.ErrInvalidToken).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrInvalidToken).Return(0).Once()
 		} else if test.name == "wrong service" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil).Once()
-			serv.On("GetMovieList", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, errors.New("service error")).Once()
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil).Once()
+			serv.On("GetMovieList", mock.AnythingOfType("*context.valueCtx"), models.MovieFilterParams{}, models.SortSpec{{Field: "name"}}, models.PageParams{}).Return(nil, errors.New("service error")).Once()
 			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New("service error")).Return(0).Once()
+		} else if test.name == "invalid query" {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errInvalidQuery)).Return(0).Once()
+		} else if test.name == "invalid pagination" {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil).Once()
+			serv.On("GetMovieList", mock.AnythingOfType("*context.valueCtx"), models.MovieFilterParams{}, models.SortSpec{{Field: "name"}}, models.PageParams{Limit: "1000"}).Return(nil, models.ErrInvalidPagination).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrInvalidPagination).Return(0).Once()
+		} else if test.name == "unknown sort field" {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(models.ErrBadRequest)).Return(0).Once()
 		} else if test.name == "positive" {
-			result := []*models.Movie{{Name: "hatiko", Description: "the movie about sad dog", ReleaseDate: GetDate("1992-04-01"), Rating: 9.1, ActorList: []string{"suize"}}}
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil).Once()
-			serv.On("GetMovieList", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(result, nil).Once()
+			result := &models.MovieList{
+				Movies: []*models.Movie{{ID: uuid.MustParse("b19069b7-7296-4e67-a5f2-80c6e01a32d0"), Name: "hatiko", Description: "the movie about sad dog",
+					ReleaseDate: GetDate("1992-04-01"), Rating: 9.1, ActorList: []string{"suize"},
+					CreatedAt: time.Date(2024, time.March, 16, 10, 0, 0, 0, time.UTC), UpdatedAt: time.Date(2024, time.March, 17, 10, 0, 0, 0, time.UTC)}},
+				PageInfo: models.PageInfo{Total: 2, Limit: 1, NextCursor: "next"},
+			}
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil).Once()
+			serv.On("GetMovieList", mock.AnythingOfType("*context.valueCtx"), models.MovieFilterParams{RatingMin: "7", ActorIDs: []string{"b0482c7a-1a4c-4a3c-9463-35f0036a0d60"}},
+				models.SortSpec{{Field: "name"}}, models.PageParams{Limit: "1"}).Return(result, nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
+		} else if test.name == "full text query" {
+			rank := 0.6
+			result := &models.MovieList{
+				Movies: []*models.Movie{{ID: uuid.MustParse("b19069b7-7296-4e67-a5f2-80c6e01a32d0"), Name: "hatiko", Description: "the movie about sad dog",
+					ReleaseDate: GetDate("1992-04-01"), Rating: 9.1, ActorList: []string{}, Rank: &rank,
+					Headline: "the movie about <mark>sad</mark> <mark>dog</mark>"}},
+				PageInfo: models.PageInfo{Total: 1, Limit: 20},
+			}
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil).Once()
+			serv.On("GetMovieList", mock.AnythingOfType("*context.valueCtx"), models.MovieFilterParams{Query: "sad dog"}, models.SortSpec(nil), models.PageParams{}).Return(result, nil).Once()
 			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
 		}
-		req, err := http.NewRequest(test.httpMethod, "/movie/get-list?"+test.urlValues, nil)
+		req, err := http.NewRequest(test.httpMethod, "/api/v1/movies?"+test.urlValues, nil)
 		req.Header.Set("Authorization", "Test-token")
 		r := httptest.NewRecorder()
 		mux.ServeHTTP(r, req)
@@ -170,6 +243,98 @@ func TestHandler_GetMoviesList(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, test.expectedStatusCode, r.Code)
 		assert.Equal(t, test.expectedResponseBody, responseBody)
+		if test.name == "positive" {
+			assert.Equal(t, []string{
+				`</api/v1/movies?actor_id=b0482c7a-1a4c-4a3c-9463-35f0036a0d60&limit=1&line=asc&rating_min=7&sortby=name>; rel="first"`,
+				`</api/v1/movies?actor_id=b0482c7a-1a4c-4a3c-9463-35f0036a0d60&cursor=next&limit=1&line=asc&rating_min=7&sortby=name>; rel="next"`,
+			}, r.Header().Values("Link"))
+		}
+	}
+}
+
+// Старый адрес /movie/get-list выдает весь список фильмов массивом, как до постраничного вывода
+func TestHandler_GetAllMovies(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+
+	testTable := []struct {
+		name                 string
+		urlValues            string
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			"wrong service",
+			"sortby=name&line=asc",
+			http.StatusInternalServerError,
+			problemBody("/movie/get-list", http.StatusInternalServerError, "internal_error", InternalError),
+		}, {
+			"unknown sort field",
+			"sort=description",
+			http.StatusBadRequest,
+			problemBody("/movie/get-list", http.StatusBadRequest, "invalid_sort", `unknown sort field "description", allowed fields: name, rating, release_date, rank`),
+		}, {
+			"positive",
+			"sortby=name&line=asc&limit=1",
+			http.StatusOK,
+			`[{"id":"b19069b7-7296-4e67-a5f2-80c6e01a32d0","name":"hatiko","description":"the movie about sad dog","release_date":"1992-04-01T00:00:00Z","rating":9.1,"actor_list":["suize"],` +
+				`"created_at":"2024-03-16T10:00:00Z","updated_at":"2024-03-17T10:00:00Z"}]`,
+		},
+	}
+	serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(testPrincipal, nil)
+	for _, test := range testTable {
+		if test.name == "wrong service" {
+			serv.On("GetAllMovies", mock.AnythingOfType("*context.valueCtx"), models.MovieFilterParams{}, models.SortSpec{{Field: "name"}}).Return(nil, errors.New("service error")).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New("service error")).Return(0).Once()
+		} else if test.name == "unknown sort field" {
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(models.ErrBadRequest)).Return(0).Once()
+		} else if test.name == "positive" {
+			result := []*models.Movie{{ID: uuid.MustParse("b19069b7-7296-4e67-a5f2-80c6e01a32d0"), Name: "hatiko", Description: "the movie about sad dog",
+				ReleaseDate: GetDate("1992-04-01"), Rating: 9.1, ActorList: []string{"suize"},
+				CreatedAt: time.Date(2024, time.March, 16, 10, 0, 0, 0, time.UTC), UpdatedAt: time.Date(2024, time.March, 17, 10, 0, 0, 0, time.UTC)}}
+			serv.On("GetAllMovies", mock.AnythingOfType("*context.valueCtx"), models.MovieFilterParams{}, models.SortSpec{{Field: "name"}}).Return(result, nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
+		}
+		req, err := http.NewRequest(http.MethodGet, "/movie/get-list?"+test.urlValues, nil)
+		req.Header.Set("Authorization", "Test-token")
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, r.Body.String(), test.name)
+		assert.Equal(t, []string{`</api/v1/movies>; rel="successor-version"`}, r.Header().Values("Link"), test.name)
+	}
+}
+
+func TestMovieSort(t *testing.T) {
+	testTable := []struct {
+		name     string
+		query    string
+		expected models.SortSpec
+		err      error
+	}{
+		{"default", "", nil, nil},
+		{"several fields", "sort=-rating,release_date,name", models.SortSpec{{Field: "rating", Desc: true}, {Field: "release_date"}, {Field: "name"}}, nil},
+		{"sort overrides sortby", "sort=name&sortby=rating&line=asc", models.SortSpec{{Field: "name"}}, nil},
+		{"sortby and line", "sortby=release_date&line=ASC", models.SortSpec{{Field: "release_date"}}, nil},
+		{"line only", "line=desc", models.SortSpec{{Field: "rating", Desc: true}}, nil},
+		{"unknown field", "sort=name,uuid", nil, models.ErrBadRequest},
+		{"empty field", "sort=rating,,name", nil, models.ErrInvalidSort},
+		{"repeated field", "sort=rating,-rating", nil, models.ErrInvalidSort},
+		{"unknown line", "sortby=name&line=up", nil, models.ErrInvalidSort},
+		{"several fields in sortby", "sortby=name,rating", nil, models.ErrInvalidSort},
+		{"injection", "sort=name%3B%20DROP%20TABLE%20movies", nil, models.ErrBadRequest},
+	}
+	for _, test := range testTable {
+		query, err := url.ParseQuery(test.query)
+		assert.Nil(t, err)
+		sort, err := movieSort(query)
+		assert.ErrorIs(t, err, test.err, test.name)
+		assert.Equal(t, test.expected, sort, test.name)
 	}
 }
 
@@ -189,44 +354,56 @@ func TestHandler_GetMovies(t *testing.T) {
 	}{
 		{
 			"wrong method",
-			http.MethodPost,
+			http.MethodDelete,
 			"actor=sui&movie=h",
 			http.StatusMethodNotAllowed,
-			[]byte(`invalid request method` + "\n"),
+			[]byte(problemBody("/movie/get-movie", http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed)),
 		}, {
 			"wrong token",
 			http.MethodGet,
 			"actor=sui&movie=h",
 			http.StatusUnauthorized,
-			[]byte(`invalid token` + "\n"),
+			[]byte(problemBody("/movie/get-movie", http.StatusUnauthorized, "invalid_token", InvalidToken)),
 		}, {
 			"wrong service",
 			http.MethodGet,
 			"actor=sui&movie=h",
+			http.StatusInternalServerError,
+			[]byte(problemBody("/movie/get-movie", http.StatusInternalServerError, "internal_error", InternalError)),
+		}, {
+			"invalid query",
+			http.MethodGet,
+			"actor=sui&movie=h;x",
 			http.StatusBadRequest,
-			[]byte(`service error` + "\n"),
+			[]byte(problemBody("/movie/get-movie", http.StatusBadRequest, "invalid_query", "invalid query string")),
 		}, {
 			"positive",
 			http.MethodGet,
 			"actor=sui&movie=h",
 			http.StatusOK,
-			[]byte(`[{"name":"hatiko","description":"the movie about sad dog","release_date":"1992-04-01T00:00:00Z","rating":9.1,"actor_list":["suize"]}]`),
+			[]byte(`[{"id":"b19069b7-7296-4e67-a5f2-80c6e01a32d0","name":"hatiko","description":"the movie about sad dog","release_date":"1992-04-01T00:00:00Z","rating":9.1,"actor_list":["suize"],` +
+				`"created_at":"2024-03-16T10:00:00Z","updated_at":"2024-03-17T10:00:00Z"}]`),
 		},
 	}
 	for _, test := range testTable {
 		if test.name == "wrong method" {
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New("invalid request method")).Return(0).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "", errMethodNotAllowed).Return(0)
 		} else if test.name == "wrong token" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(errors.New(InvalidToken)).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(InvalidToken)).Return(0).Once()
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(nil, models.ErrInvalidToken).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrInvalidToken).Return(0).Once()
 		} else if test.name == "wrong service" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil).Once()
-			serv.On("GetMovie", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, errors.New("service error")).Once()
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil).Once()
+			serv.On("GetMovie", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, errors.New("service error")).Once()
 			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New("service error")).Return(0).Once()
+		} else if test.name == "invalid query" {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errInvalidQuery)).Return(0).Once()
 		} else if test.name == "positive" {
-			result := []*models.Movie{{Name: "hatiko", Description: "the movie about sad dog", ReleaseDate: GetDate("1992-04-01"), Rating: 9.1, ActorList: []string{"suize"}}}
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil).Once()
-			serv.On("GetMovie", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(result, nil).Once()
+			result := []*models.Movie{{ID: uuid.MustParse("b19069b7-7296-4e67-a5f2-80c6e01a32d0"), Name: "hatiko", Description: "the movie about sad dog",
+				ReleaseDate: GetDate("1992-04-01"), Rating: 9.1, ActorList: []string{"suize"},
+				CreatedAt: time.Date(2024, time.March, 16, 10, 0, 0, 0, time.UTC), UpdatedAt: time.Date(2024, time.March, 17, 10, 0, 0, 0, time.UTC)}}
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil).Once()
+			serv.On("GetMovie", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(result, nil).Once()
 			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
 		}
 		req, err := http.NewRequest(test.httpMethod, "/movie/get-movie?"+test.urlValues, nil)
@@ -241,6 +418,68 @@ func TestHandler_GetMovies(t *testing.T) {
 	}
 }
 
+func TestHandler_GetMovieByID(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+	id := "20648636-b14e-4f88-a02a-8c4e3c2f534d"
+	actorID := uuid.MustParse("b0482c7a-1a4c-4a3c-9463-35f0036a0d60")
+	movie := &models.MovieDetails{
+		ID:          uuid.MustParse(id),
+		Name:        "Forrest Gump",
+		Description: "Description 1",
+		ReleaseDate: GetDate("1994-07-06"),
+		Rating:      8.8,
+		Cast:        []models.CastMember{{ID: &actorID, Name: "Tom Hanks"}, {Name: "Robin Wright"}},
+	}
+	testTable := []struct {
+		name                 string
+		id                   string
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			"invalid id",
+			"20648636",
+			http.StatusBadRequest,
+			problemBody("/api/v1/movies/20648636", http.StatusBadRequest, "invalid_id", models.ErrInvalidID.Error()),
+		}, {
+			"not found",
+			id,
+			http.StatusNotFound,
+			problemBody("/api/v1/movies/"+id, http.StatusNotFound, "movie_not_found", models.ErrMovieNotFound.Error()),
+		}, {
+			"positive",
+			id,
+			http.StatusOK,
+			`{"id":"20648636-b14e-4f88-a02a-8c4e3c2f534d","name":"Forrest Gump","description":"Description 1","release_date":"1994-07-06T00:00:00Z","rating":8.8,` +
+				`"cast":[{"id":"b0482c7a-1a4c-4a3c-9463-35f0036a0d60","name":"Tom Hanks"},{"id":null,"name":"Robin Wright"}],` +
+				`"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}`,
+		},
+	}
+	serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(testPrincipal, nil)
+	for _, test := range testTable {
+		if test.name == "invalid id" {
+			serv.On("GetMovieByID", mock.AnythingOfType("*context.valueCtx"), test.id).Return(nil, models.ErrInvalidID).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "Get movie", models.ErrInvalidID).Return(0).Once()
+		} else if test.name == "not found" {
+			serv.On("GetMovieByID", mock.AnythingOfType("*context.valueCtx"), test.id).Return(nil, models.ErrMovieNotFound).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "Get movie", models.ErrMovieNotFound).Return(0).Once()
+		} else if test.name == "positive" {
+			serv.On("GetMovieByID", mock.AnythingOfType("*context.valueCtx"), test.id).Return(movie, nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "Get movie").Return(0).Once()
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/movies/"+test.id, nil)
+		req.Header.Set("Authorization", "Test-token")
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, r.Body.String(), test.name)
+	}
+}
+
 func TestHandler_DeleteMovie(t *testing.T) {
 	mux := http.NewServeMux()
 	serv := mocks.NewService(t)
@@ -259,19 +498,19 @@ func TestHandler_DeleteMovie(t *testing.T) {
 			http.MethodPost,
 			"40d882f7-b027-4a07-85da-76e0f7d9b6e3",
 			http.StatusMethodNotAllowed,
-			[]byte(`invalid request method` + "\n"),
+			[]byte(problemBody("/api/v1/movies/40d882f7-b027-4a07-85da-76e0f7d9b6e3", http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed)),
 		}, {
 			"wrong token",
 			http.MethodDelete,
 			"40d882f7-b027-4a07-85da-76e0f7d9b6e3",
 			http.StatusUnauthorized,
-			[]byte(`invalid token` + "\n"),
+			[]byte(problemBody("/api/v1/movies/40d882f7-b027-4a07-85da-76e0f7d9b6e3", http.StatusUnauthorized, "invalid_token", InvalidToken)),
 		}, {
-			"service problem",
+			"not found",
 			http.MethodDelete,
 			"40d882f7-b027-4a07-85da-76e0f7d9b6e3",
-			http.StatusBadRequest,
-			[]byte(`service problem` + "\n"),
+			http.StatusNotFound,
+			[]byte(problemBody("/api/v1/movies/40d882f7-b027-4a07-85da-76e0f7d9b6e3", http.StatusNotFound, "movie_not_found", models.ErrMovieNotFound.Error())),
 		}, {
 			"positive",
 			http.MethodDelete,
@@ -282,20 +521,20 @@ func TestHandler_DeleteMovie(t *testing.T) {
 	}
 	for _, test := range testTable {
 		if test.name == "wrong method" {
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(MethodNotAllowed)).Return(0).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "", errMethodNotAllowed).Return(0)
 		} else if test.name == "wrong token" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(errors.New(InvalidToken)).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(InvalidToken)).Return(0).Once()
-		} else if test.name == "service problem" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil).Once()
-			serv.On("DeleteMovie", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(errors.New("service problem")).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New("service problem")).Return(0).Once()
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(nil, models.ErrInvalidToken).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrInvalidToken).Return(0).Once()
+		} else if test.name == "not found" {
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil).Once()
+			serv.On("DeleteMovie", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("string")).Return(models.ErrMovieNotFound).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrMovieNotFound).Return(0).Once()
 		} else if test.name == "positive" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil).Once()
-			serv.On("DeleteMovie", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(nil).Once()
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil).Once()
+			serv.On("DeleteMovie", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("string")).Return(nil).Once()
 			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
 		}
-		req, err := http.NewRequest(test.httpMethod, "/movie/delete/"+test.id, nil)
+		req, err := http.NewRequest(test.httpMethod, "/api/v1/movies/"+test.id, nil)
 		req.Header.Set("Authorization", "Test-token")
 		r := httptest.NewRecorder()
 		mux.ServeHTTP(r, req)
@@ -323,38 +562,38 @@ func TestHandler_UpdateMovie(t *testing.T) {
 		{
 			"wrong method",
 			[]byte(`{"name": "Borrest Gump", "description": "Description 1", "release_date": "1994-07-06", "rating": 8.5, "actor_list": ["Tom Hanks", "Brad Pitt"]}`),
-			http.MethodGet,
+			http.MethodPost,
 			"40d882f7-b027-4a07-85da-76e0f7d9b6e3",
 			http.StatusMethodNotAllowed,
-			[]byte(`invalid request method` + "\n"),
+			[]byte(problemBody("/api/v1/movies/40d882f7-b027-4a07-85da-76e0f7d9b6e3", http.StatusMethodNotAllowed, "method_not_allowed", MethodNotAllowed)),
 		}, {
 			"wrong token",
 			[]byte(`{"name": "Borrest Gump", "description": "Description 1", "release_date": "1994-07-06", "rating": 8.5, "actor_list": ["Tom Hanks", "Brad Pitt"]}`),
 			http.MethodPatch,
 			"40d882f7-b027-4a07-85da-76e0f7d9b6e3",
 			http.StatusUnauthorized,
-			[]byte(`invalid token` + "\n"),
+			[]byte(problemBody("/api/v1/movies/40d882f7-b027-4a07-85da-76e0f7d9b6e3", http.StatusUnauthorized, "invalid_token", InvalidToken)),
 		}, {
 			"wrong json",
 			[]byte(`"some data"`),
 			http.MethodPatch,
 			"40d882f7-b027-4a07-85da-76e0f7d9b6e3",
 			http.StatusUnprocessableEntity,
-			[]byte(`JSON parsing error` + "\n"),
+			[]byte(problemBody("/api/v1/movies/40d882f7-b027-4a07-85da-76e0f7d9b6e3", http.StatusUnprocessableEntity, "invalid_json", ParsingJSONError)),
 		}, {
 			"no valid data",
 			[]byte(`{"name": "Borrest Gump", "description": "Description 1", "release_date": "1994-07-06", "rating": 12, "actor_list": ["Tom Hanks", "Brad Pitt"]}`),
 			http.MethodPatch,
 			"40d882f7-b027-4a07-85da-76e0f7d9b6e3",
 			http.StatusUnprocessableEntity,
-			[]byte(`no valid data` + "\n"),
+			[]byte(problemBody("/api/v1/movies/40d882f7-b027-4a07-85da-76e0f7d9b6e3", http.StatusUnprocessableEntity, models.CodeValidation, "validation failed", models.FieldError{Field: "rating", Message: "must be between 0 and 10"})),
 		}, {
 			"service error",
 			[]byte(`{"name": "Borrest Gump", "description": "Description 1", "release_date": "1994-07-06", "rating": 8.5, "actor_list": ["Tom Hanks", "Brad Pitt"]}`),
 			http.MethodPatch,
 			"40d882f7-b027-4a07-85da-76e0f7d9b6e3",
-			http.StatusBadRequest,
-			[]byte(`service error` + "\n"),
+			http.StatusInternalServerError,
+			[]byte(problemBody("/api/v1/movies/40d882f7-b027-4a07-85da-76e0f7d9b6e3", http.StatusInternalServerError, "internal_error", InternalError)),
 		}, {
 			"positive",
 			[]byte(`{"name": "Borrest Gump", "description": "Description 1", "release_date": "1994-07-06", "rating": 8.5, "actor_list": ["Tom Hanks", "Brad Pitt"]}`),
@@ -366,27 +605,28 @@ func TestHandler_UpdateMovie(t *testing.T) {
 	}
 	for _, test := range testTable {
 		if test.name == "wrong method" {
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(MethodNotAllowed)).Return(0)
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "", errMethodNotAllowed).Return(0)
 		} else if test.name == "wrong token" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(errors.New(InvalidToken)).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(InvalidToken)).Return(0)
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(nil, models.ErrInvalidToken).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrInvalidToken).Return(0)
 		} else if test.name == "wrong json" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), mock.AnythingOfType("*json.UnmarshalTypeError")).Return(0)
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil)
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errInvalidJSON)).Return(0)
 		} else if test.name == "no valid data" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
-			serv.On("UpdateMovie", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("models.Movie")).Return(errors.New(UnprocessableEntity)).Once()
-			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New(UnprocessableEntity)).Return(0)
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil)
+			validationErr := &models.ValidationError{Fields: []models.FieldError{{Field: "rating", Message: "must be between 0 and 10"}}}
+			serv.On("UpdateMovie", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("models.Movie")).Return(validationErr).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), validationErr).Return(0)
 		} else if test.name == "service error" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
-			serv.On("UpdateMovie", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("models.Movie")).Return(errors.New("service error")).Once()
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil)
+			serv.On("UpdateMovie", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("models.Movie")).Return(errors.New("service error")).Once()
 			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errors.New("service error")).Return(0)
 		} else if test.name == "positive" {
-			serv.On("CheckToken", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
-			serv.On("UpdateMovie", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("models.Movie")).Return(nil).Once()
+			serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(testPrincipal, nil)
+			serv.On("UpdateMovie", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("string"), mock.AnythingOfType("models.Movie")).Return(nil).Once()
 			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0)
 		}
-		req, err := http.NewRequest(test.httpMethod, "/movie/update/"+test.id, bytes.NewBuffer(test.requestBody))
+		req, err := http.NewRequest(test.httpMethod, "/api/v1/movies/"+test.id, bytes.NewBuffer(test.requestBody))
 		req.Header.Set("Authorization", "Test-token")
 		r := httptest.NewRecorder()
 		mux.ServeHTTP(r, req)
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"net/http"
+	"path"
+)
+
+// oidcStateCookie связывает state с браузером, начавшим вход, и защищает callback от подстановки чужого кода.
+// Cookie действует для каталога /auth/oidc той версии API, через которую начат вход
+const oidcStateCookie = "oidc_state"
+
+// errOIDCLoginFailed - провайдер вернул ошибку вместо кода авторизации, код ошибки провайдера остается в логе
+var errOIDCLoginFailed = models.NewError(models.ErrBadRequest, "oidc_login_failed", "oidc login failed")
+
+// OIDCLogin godoc
+// @Summary Вход через OpenID Connect провайдер
+// @Description Перенаправление на страницу входа провайдера (authorization code с PKCE)
+// @Tags auth
+// @Success 302
+// @Failure 404,500,502 {object} Problem
+// @Router /api/v1/auth/oidc/login [get]
+func (h *Handler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	authURL, state, err := h.services.OIDCLogin(r.Context())
+	if err != nil {
+		h.problem(w, r, "OIDC login", err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     path.Dir(r.URL.Path),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+	h.log.HandlerLog(r, http.StatusFound, "OIDC login")
+}
+
+// OIDCCallback godoc
+// @Summary Завершение входа через OpenID Connect провайдер
+// @Description Обмен кода авторизации на пару токенов, state должен совпадать со значением cookie oidc_state
+// @Tags auth
+// @Produce json
+// @Param state query string true "state"
+// @Param code query string true "Код авторизации"
+// @Success 200 {object} models.TokenPair
+// @Failure 400,401,403,404,409,500,502 {object} Problem
+// @Router /api/v1/auth/oidc/callback [get]
+func (h *Handler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		h.problem(w, r, "OIDC callback", fmt.Errorf("%w: %s", errOIDCLoginFailed, providerErr))
+		return
+	}
+	state, code := query.Get("state"), query.Get("code")
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || state == "" || code == "" || cookie.Value != state {
+		h.problem(w, r, "OIDC callback", models.ErrInvalidOIDCState)
+		return
+	}
+	// state одноразовый, cookie больше не нужна
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     path.Dir(r.URL.Path),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	tokens, err := h.services.OIDCCallback(r.Context(), state, code)
+	if err != nil {
+		h.problem(w, r, "OIDC callback", err)
+		return
+	}
+
+	jsonData, err := json.Marshal(tokens)
+	if err != nil {
+		h.problem(w, r, "Can't marshal result", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusOK, "OIDC callback")
+}
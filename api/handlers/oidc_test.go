@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/ast3am/VKintern-movies/api/handlers/mocks"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_OIDCLogin(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+
+	//Вход не настроен
+	serv.On("OIDCLogin", mock.AnythingOfType("context.backgroundCtx")).Return("", "", models.ErrOIDCDisabled).Once()
+	log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), http.StatusNotFound, mock.AnythingOfType("string"), models.ErrOIDCDisabled).Return(0).Once()
+	req, err := http.NewRequest(http.MethodGet, "/api/v1/auth/oidc/login", nil)
+	require.NoError(t, err)
+	r := httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusNotFound, r.Code)
+
+	//positive
+	serv.On("OIDCLogin", mock.AnythingOfType("context.backgroundCtx")).Return("https://idp.example.com/authorize?state=test-state", "test-state", nil).Once()
+	log.On("HandlerLog", mock.AnythingOfType("*http.Request"), http.StatusFound, mock.AnythingOfType("string")).Return(0).Once()
+	r = httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusFound, r.Code)
+	assert.Equal(t, "https://idp.example.com/authorize?state=test-state", r.Header().Get("Location"))
+	cookies := r.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, oidcStateCookie, cookies[0].Name)
+	assert.Equal(t, "test-state", cookies[0].Value)
+	assert.Equal(t, "/api/v1/auth/oidc", cookies[0].Path)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+}
+
+func TestHandler_OIDCCallback(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+
+	testTable := []struct {
+		name                 string
+		query                string
+		cookie               string
+		serviceErr           error
+		expectedStatusCode   int
+		expectedResponseBody []byte
+	}{
+		{
+			"positive",
+			"?state=test-state&code=test-code",
+			"test-state",
+			nil,
+			http.StatusOK,
+			[]byte(`{"token":"some token","refresh_token":"some refresh token"}`),
+		}, {
+			"no cookie",
+			"?state=test-state&code=test-code",
+			"",
+			nil,
+			http.StatusBadRequest,
+			[]byte(problemBody("/api/v1/auth/oidc/callback", http.StatusBadRequest, "invalid_oidc_state", models.ErrInvalidOIDCState.Error())),
+		}, {
+			"state mismatch",
+			"?state=test-state&code=test-code",
+			"other-state",
+			nil,
+			http.StatusBadRequest,
+			[]byte(problemBody("/api/v1/auth/oidc/callback", http.StatusBadRequest, "invalid_oidc_state", models.ErrInvalidOIDCState.Error())),
+		}, {
+			"provider error",
+			"?error=access_denied&state=test-state",
+			"test-state",
+			nil,
+			http.StatusBadRequest,
+			[]byte(problemBody("/api/v1/auth/oidc/callback", http.StatusBadRequest, "oidc_login_failed", errOIDCLoginFailed.Error())),
+		}, {
+			"expired state",
+			"?state=test-state&code=test-code",
+			"test-state",
+			models.ErrInvalidOIDCState,
+			http.StatusBadRequest,
+			[]byte(problemBody("/api/v1/auth/oidc/callback", http.StatusBadRequest, "invalid_oidc_state", models.ErrInvalidOIDCState.Error())),
+		}, {
+			"provider unavailable",
+			"?state=test-state&code=test-code",
+			"test-state",
+			errors.Join(models.ErrOIDCProvider, errors.New("dial tcp: connection refused")),
+			http.StatusBadGateway,
+			[]byte(problemBody("/api/v1/auth/oidc/callback", http.StatusBadGateway, "oidc_provider_error", models.ErrOIDCProvider.Error())),
+		}, {
+			"invalid id token",
+			"?state=test-state&code=test-code",
+			"test-state",
+			errors.Join(models.ErrInvalidIDToken, errors.New("unexpected nonce")),
+			http.StatusUnauthorized,
+			[]byte(problemBody("/api/v1/auth/oidc/callback", http.StatusUnauthorized, "invalid_id_token", models.ErrInvalidIDToken.Error())),
+		}, {
+			"no roles",
+			"?state=test-state&code=test-code",
+			"test-state",
+			models.ErrOIDCNoRoles,
+			http.StatusForbidden,
+			[]byte(problemBody("/api/v1/auth/oidc/callback", http.StatusForbidden, "oidc_no_roles", models.ErrOIDCNoRoles.Error())),
+		}, {
+			"email conflict",
+			"?state=test-state&code=test-code",
+			"test-state",
+			models.ErrOIDCEmailConflict,
+			http.StatusConflict,
+			[]byte(problemBody("/api/v1/auth/oidc/callback", http.StatusConflict, "oidc_email_conflict", models.ErrOIDCEmailConflict.Error())),
+		},
+	}
+	for _, test := range testTable {
+		switch test.name {
+		case "positive":
+			serv.On("OIDCCallback", mock.AnythingOfType("context.backgroundCtx"), "test-state", "test-code").Return(&models.TokenPair{AccessToken: "some token", RefreshToken: "some refresh token"}, nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
+		case "no cookie", "state mismatch":
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), models.ErrInvalidOIDCState).Return(0).Once()
+		case "provider error":
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errOIDCLoginFailed)).Return(0).Once()
+		default:
+			serv.On("OIDCCallback", mock.AnythingOfType("context.backgroundCtx"), "test-state", "test-code").Return(nil, test.serviceErr).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), test.serviceErr).Return(0).Once()
+		}
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/auth/oidc/callback"+test.query, nil)
+		assert.Nil(t, err)
+		if test.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: test.cookie})
+		}
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, r.Body.Bytes(), test.name)
+		if test.name == "positive" {
+			assert.Equal(t, "application/json", r.Header().Get("Content-Type"))
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func pageParams(query url.Values) models.PageParams {
+	return models.PageParams{
+		Limit:   query.Get("limit"),
+		Cursor:  query.Get("cursor"),
+		Page:    query.Get("page"),
+		PerPage: query.Get("per_page"),
+	}
+}
+
+// setPageLinks добавляет заголовки Link (RFC 8288) на соседние страницы. Остальные параметры запроса,
+// например сортировка, сохраняются. Заголовок добавляется, а не заменяется, чтобы не потерять Link
+// на новый адрес у устаревших адресов
+func setPageLinks(w http.ResponseWriter, r *http.Request, info models.PageInfo) {
+	// link добавляет ссылку rel на адрес запроса с измененным параметром key, пустое value удаляет параметр
+	link := func(rel, key, value string) {
+		query := r.URL.Query()
+		if value == "" {
+			query.Del(key)
+		} else {
+			query.Set(key, value)
+		}
+		u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+		w.Header().Add("Link", "<"+u.String()+`>; rel="`+rel+`"`)
+	}
+
+	if info.PerPage == 0 {
+		link("first", "cursor", "")
+		if info.NextCursor != "" {
+			link("next", "cursor", info.NextCursor)
+		}
+		return
+	}
+	last := (info.Total + info.PerPage - 1) / info.PerPage
+	if last < 1 {
+		last = 1
+	}
+	link("first", "page", "1")
+	if info.Page > 1 {
+		link("prev", "page", strconv.Itoa(min(info.Page-1, last)))
+	}
+	if info.Page < last {
+		link("next", "page", strconv.Itoa(info.Page+1))
+	}
+	link("last", "page", strconv.Itoa(last))
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// PasswordResetSent - ответ на запрос восстановления пароля, одинаковый для существующих и несуществующих пользователей
+const PasswordResetSent = "if the account exists, a password reset link has been sent"
+
+type ForgotPasswordDTO struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordDTO struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// ForgotPassword godoc
+// @Summary Запрос восстановления пароля
+// @Description Отправка письма со ссылкой для смены пароля, ответ не зависит от того, существует ли пользователь
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param data body ForgotPasswordDTO true "Входные параметры"
+// @Success 202 {object} string
+// @Failure 422,500 {object} Problem
+// @Router /api/v1/auth/password/forgot [post]
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.problem(w, r, UnprocessableEntity, unreadableBody(err))
+		return
+	}
+	defer r.Body.Close()
+
+	var forgot ForgotPasswordDTO
+	err = json.Unmarshal(body, &forgot)
+	if err != nil || forgot.Email == "" {
+		h.problem(w, r, ParsingJSONError, invalidJSON(err))
+		return
+	}
+
+	err = h.services.ForgotPassword(r.Context(), forgot.Email)
+	if err != nil {
+		h.problem(w, r, "Forgot password", err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(PasswordResetSent))
+	h.log.HandlerLog(r, http.StatusAccepted, "Forgot password")
+}
+
+// ResetPassword godoc
+// @Summary Смена пароля по ссылке из письма
+// @Description Установка нового пароля по одноразовому токену, все токены пользователя отзываются
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param data body ResetPasswordDTO true "Входные параметры"
+// @Success 200 {object} string
+// @Failure 400,422,500 {object} Problem
+// @Router /api/v1/auth/password/reset [post]
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.problem(w, r, UnprocessableEntity, unreadableBody(err))
+		return
+	}
+	defer r.Body.Close()
+
+	var reset ResetPasswordDTO
+	err = json.Unmarshal(body, &reset)
+	if err != nil || reset.Token == "" {
+		h.problem(w, r, ParsingJSONError, invalidJSON(err))
+		return
+	}
+
+	err = h.services.ResetPassword(r.Context(), reset.Token, reset.Password)
+	if err != nil {
+		h.problem(w, r, "Reset password", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("password changed"))
+	h.log.HandlerLog(r, http.StatusOK, "Reset password")
+}
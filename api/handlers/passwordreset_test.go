@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"github.com/ast3am/VKintern-movies/api/handlers/mocks"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_ForgotPassword(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+
+	testTable := []struct {
+		name                 string
+		requestBody          []byte
+		serviceErr           error
+		expectedStatusCode   int
+		expectedResponseBody []byte
+	}{
+		{
+			"positive",
+			[]byte(`{"email": "user@mail.com"}`),
+			nil,
+			http.StatusAccepted,
+			[]byte(PasswordResetSent),
+		}, {
+			"no email",
+			[]byte(`{}`),
+			nil,
+			http.StatusUnprocessableEntity,
+			[]byte(problemBody("/api/v1/auth/password/forgot", http.StatusUnprocessableEntity, "invalid_json", ParsingJSONError)),
+		}, {
+			"db error",
+			[]byte(`{"email": "user@mail.com"}`),
+			errors.New("db error"),
+			http.StatusInternalServerError,
+			[]byte(problemBody("/api/v1/auth/password/forgot", http.StatusInternalServerError, "internal_error", InternalError)),
+		},
+	}
+	for _, test := range testTable {
+		switch test.name {
+		case "positive":
+			serv.On("ForgotPassword", mock.AnythingOfType("context.backgroundCtx"), "user@mail.com").Return(nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
+		case "no email":
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errInvalidJSON)).Return(0).Once()
+		default:
+			serv.On("ForgotPassword", mock.AnythingOfType("context.backgroundCtx"), "user@mail.com").Return(test.serviceErr).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), test.serviceErr).Return(0).Once()
+		}
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/auth/password/forgot", bytes.NewBuffer(test.requestBody))
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, r.Body.Bytes(), test.name)
+	}
+}
+
+func TestHandler_ResetPassword(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+
+	testTable := []struct {
+		name                 string
+		requestBody          []byte
+		serviceErr           error
+		expectedStatusCode   int
+		expectedResponseBody []byte
+	}{
+		{
+			"positive",
+			[]byte(`{"token": "reset token", "password": "newPassword1"}`),
+			nil,
+			http.StatusOK,
+			[]byte("password changed"),
+		}, {
+			"no token",
+			[]byte(`{"password": "newPassword1"}`),
+			nil,
+			http.StatusUnprocessableEntity,
+			[]byte(problemBody("/api/v1/auth/password/reset", http.StatusUnprocessableEntity, "invalid_json", ParsingJSONError)),
+		}, {
+			"invalid token",
+			[]byte(`{"token": "reset token", "password": "newPassword1"}`),
+			models.ErrInvalidResetToken,
+			http.StatusBadRequest,
+			[]byte(problemBody("/api/v1/auth/password/reset", http.StatusBadRequest, "invalid_reset_token", models.ErrInvalidResetToken.Error())),
+		}, {
+			"weak password",
+			[]byte(`{"token": "reset token", "password": "newPassword1"}`),
+			models.ErrWeakPassword,
+			http.StatusUnprocessableEntity,
+			[]byte(problemBody("/api/v1/auth/password/reset", http.StatusUnprocessableEntity, "weak_password", models.ErrWeakPassword.Error())),
+		}, {
+			"db error",
+			[]byte(`{"token": "reset token", "password": "newPassword1"}`),
+			errors.New("db error"),
+			http.StatusInternalServerError,
+			[]byte(problemBody("/api/v1/auth/password/reset", http.StatusInternalServerError, "internal_error", InternalError)),
+		},
+	}
+	for _, test := range testTable {
+		switch test.name {
+		case "positive":
+			serv.On("ResetPassword", mock.AnythingOfType("context.backgroundCtx"), "reset token", "newPassword1").Return(nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string")).Return(0).Once()
+		case "no token":
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), errorIs(errInvalidJSON)).Return(0).Once()
+		default:
+			serv.On("ResetPassword", mock.AnythingOfType("context.backgroundCtx"), "reset token", "newPassword1").Return(test.serviceErr).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, mock.AnythingOfType("string"), test.serviceErr).Return(0).Once()
+		}
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/auth/password/reset", bytes.NewBuffer(test.requestBody))
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, r.Body.Bytes(), test.name)
+	}
+}
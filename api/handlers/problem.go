@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	codeInternalError  = "internal_error"
+)
+
+var errMethodNotAllowedKind = errors.New("method not allowed")
+
+// Ошибки разбора запроса, которые возникают до вызова сервиса
+var (
+	errMethodNotAllowed = models.NewError(errMethodNotAllowedKind, "method_not_allowed", MethodNotAllowed)
+	errRouteNotFound    = models.NewError(models.ErrNotFound, "route_not_found", "route not found")
+	errInvalidQuery     = models.NewError(models.ErrBadRequest, "invalid_query", "invalid query string")
+	errUnreadableBody   = models.NewError(models.ErrValidation, "unreadable_body", UnprocessableEntity)
+	errInvalidJSON      = models.NewError(models.ErrValidation, "invalid_json", ParsingJSONError)
+)
+
+// problemKinds сопоставляет категории доменных ошибок с кодами ответа, code используется, если у ошибки
+// нет своего кода. Ошибка без категории считается внутренней и отдается как 500 без подробностей
+var problemKinds = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{models.ErrBadRequest, http.StatusBadRequest, "bad_request"},
+	{models.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{models.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{models.ErrNotFound, http.StatusNotFound, "not_found"},
+	{errMethodNotAllowedKind, http.StatusMethodNotAllowed, "method_not_allowed"},
+	{models.ErrConflict, http.StatusConflict, "conflict"},
+	{models.ErrValidation, http.StatusUnprocessableEntity, models.CodeValidation},
+	{models.ErrTooManyRequests, http.StatusTooManyRequests, "too_many_requests"},
+	{models.ErrUpstream, http.StatusBadGateway, "upstream_error"},
+}
+
+// Problem - тело ответа с ошибкой в формате RFC 7807, code не меняется вместе с текстом detail
+type Problem struct {
+	Type     string              `json:"type" example:"about:blank"`
+	Title    string              `json:"title" example:"Not Found"`
+	Status   int                 `json:"status" example:"404"`
+	Code     string              `json:"code" example:"movie_not_found"`
+	Detail   string              `json:"detail,omitempty" example:"movie not found"`
+	Instance string              `json:"instance,omitempty" example:"/api/v1/movies/b0482c7a-1a4c-4a3c-9463-35f0036a0d60"`
+	Errors   []models.FieldError `json:"errors,omitempty"`
+}
+
+// newProblem строит ответ по ошибке: клиент получает только категорию, код и сообщение доменной ошибки,
+// текст обернутых ошибок драйвера и библиотек в ответ не попадает
+func newProblem(r *http.Request, err error) Problem {
+	problem := Problem{
+		Type:     "about:blank",
+		Status:   http.StatusInternalServerError,
+		Code:     codeInternalError,
+		Detail:   InternalError,
+		Instance: r.URL.Path,
+	}
+	for _, kind := range problemKinds {
+		if errors.Is(err, kind.kind) {
+			problem.Status = kind.status
+			problem.Code = kind.code
+			problem.Detail = kind.kind.Error()
+			break
+		}
+	}
+
+	var validationErr *models.ValidationError
+	var domainErr *models.Error
+	switch {
+	case problem.Status == http.StatusInternalServerError:
+	case errors.As(err, &validationErr):
+		problem.Code = models.CodeValidation
+		problem.Detail = models.ErrValidation.Error()
+		problem.Errors = validationErr.Fields
+	case errors.As(err, &domainErr):
+		problem.Code = domainErr.Code
+		problem.Detail = domainErr.Message
+	}
+	problem.Title = http.StatusText(problem.Status)
+	return problem
+}
+
+// problem - единственное место, где ошибка превращается в ответ: исходная ошибка пишется в лог целиком,
+// клиент получает application/problem+json
+func (h *Handler) problem(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	problem := newProblem(r, err)
+	var locked *models.LoginLockedError
+	if errors.As(err, &locked) {
+		retryAfter := int(math.Ceil(time.Until(locked.Until).Seconds()))
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+	if problem.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	h.log.HandlerErrorLog(r, problem.Status, msg, err)
+	writeProblem(w, problem)
+}
+
+// unreadableBody оборачивает ошибку чтения тела запроса
+func unreadableBody(err error) error {
+	return fmt.Errorf("%w: %w", errUnreadableBody, err)
+}
+
+// invalidJSON оборачивает ошибку разбора тела запроса, err равен nil, если в теле нет обязательного поля
+func invalidJSON(err error) error {
+	if err == nil {
+		return errInvalidJSON
+	}
+	return fmt.Errorf("%w: %w", errInvalidJSON, err)
+}
+
+func writeProblem(w http.ResponseWriter, problem Problem) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewProblem(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/movies/08ed098c-e3a0-11ee-8751-2e8752a1069e?force=1", nil)
+	driverErr := errors.New(`ERROR: duplicate key value violates unique constraint "movie_name_key" (SQLSTATE 23505)`)
+
+	testTable := []struct {
+		name     string
+		err      error
+		expected Problem
+	}{
+		{
+			"domain error",
+			models.ErrMovieNotFound,
+			Problem{Status: http.StatusNotFound, Code: "movie_not_found", Detail: "movie not found"},
+		}, {
+			"wrapped driver error",
+			fmt.Errorf("%w: %w", models.ErrMovieExists, driverErr),
+			Problem{Status: http.StatusConflict, Code: "movie_exists", Detail: models.ErrMovieExists.Error()},
+		}, {
+			"kind without code",
+			fmt.Errorf("%w: actor", models.ErrNotFound),
+			Problem{Status: http.StatusNotFound, Code: "not_found", Detail: models.ErrNotFound.Error()},
+		}, {
+			"validation",
+			fmt.Errorf("create movie: %w", &models.ValidationError{Fields: []models.FieldError{{Field: "rating", Message: "must be between 0 and 10"}}}),
+			Problem{Status: http.StatusUnprocessableEntity, Code: models.CodeValidation, Detail: models.ErrValidation.Error(),
+				Errors: []models.FieldError{{Field: "rating", Message: "must be between 0 and 10"}}},
+		}, {
+			"unknown error",
+			driverErr,
+			Problem{Status: http.StatusInternalServerError, Code: "internal_error", Detail: InternalError},
+		},
+	}
+	for _, test := range testTable {
+		test.expected.Type = "about:blank"
+		test.expected.Title = http.StatusText(test.expected.Status)
+		test.expected.Instance = "/api/v1/movies/08ed098c-e3a0-11ee-8751-2e8752a1069e"
+		assert.Equal(t, test.expected, newProblem(req, test.err), test.name)
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	r := httptest.NewRecorder()
+	writeProblem(r, Problem{Type: "about:blank", Title: "Conflict", Status: http.StatusConflict, Code: "movie_exists"})
+
+	assert.Equal(t, http.StatusConflict, r.Code)
+	assert.Equal(t, problemContentType, r.Header().Get("Content-Type"))
+	assert.Equal(t, `{"type":"about:blank","title":"Conflict","status":409,"code":"movie_exists"}`+"\n", r.Body.String())
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiV1 - префикс текущей версии API. Следующая версия регистрируется своей функцией маршрутов
+// с префиксом /api/v2 и своими DTO, обработчики всех версий работают с одним service
+const apiV1 = "/api/v1"
+
+var (
+	// legacyDeprecatedAt - дата, с которой адреса без версии считаются устаревшими
+	legacyDeprecatedAt = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+	// legacySunsetAt - дата, после которой адреса без версии будут удалены
+	legacySunsetAt = time.Date(2027, time.April, 18, 0, 0, 0, 0, time.UTC)
+)
+
+// router регистрирует маршруты одной версии API. В режиме legacy маршруты регистрируются без префикса
+// и отвечают с заголовками устаревшего адреса, новым адресом считается тот же путь с префиксом
+type router struct {
+	mux    *http.ServeMux
+	prefix string
+	legacy bool
+}
+
+// handle принимает шаблон ServeMux, метод в шаблоне необязателен
+func (rt router) handle(pattern string, handler http.HandlerFunc) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		method, path = "", pattern
+	}
+	if rt.legacy {
+		handler = deprecated(func(r *http.Request) string {
+			return rt.prefix + r.URL.Path
+		}, handler)
+	} else {
+		path = rt.prefix + path
+	}
+	rt.mux.HandleFunc(strings.TrimSpace(method+" "+path), handler)
+}
+
+// alias регистрирует старый адрес, у которого нет пары с тем же путем, successor - шаблон нового адреса
+// без префикса, {id} в нем заменяется значением из пути запроса
+func (rt router) alias(pattern, successor string, handler http.HandlerFunc) {
+	rt.mux.HandleFunc(pattern, deprecated(func(r *http.Request) string {
+		return rt.prefix + strings.ReplaceAll(successor, "{id}", r.PathValue("id"))
+	}, handler))
+}
+
+// deprecated добавляет к ответу заголовки Deprecation (RFC 9745), Sunset (RFC 8594) и Link на новый адрес
+func deprecated(successor func(r *http.Request) string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "@"+strconv.FormatInt(legacyDeprecatedAt.Unix(), 10))
+		w.Header().Set("Sunset", legacySunsetAt.Format(http.TimeFormat))
+		w.Header().Set("Link", "<"+successor(r)+`>; rel="successor-version"`)
+		next(w, r)
+	}
+}
+
+// routeProblems заменяет текстовые ответы 404 и 405 маршрутизатора на problem+json. Заголовок Allow, выставленный
+// маршрутизатором, сохраняется. Ответы обработчиков не меняются
+func (h *Handler) routeProblems(routes *http.ServeMux) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := routes.Handler(r); pattern == "" {
+			w = &routeProblemWriter{ResponseWriter: w, h: h, r: r}
+		}
+		routes.ServeHTTP(w, r)
+	}
+}
+
+// routeProblemWriter перехватывает ответ маршрутизатора на запрос, не подошедший ни к одному маршруту
+type routeProblemWriter struct {
+	http.ResponseWriter
+	h        *Handler
+	r        *http.Request
+	replaced bool
+}
+
+func (w *routeProblemWriter) WriteHeader(status int) {
+	switch status {
+	case http.StatusNotFound:
+		w.replaced = true
+		w.h.problem(w.ResponseWriter, w.r, "", errRouteNotFound)
+	case http.StatusMethodNotAllowed:
+		w.replaced = true
+		w.h.problem(w.ResponseWriter, w.r, "", errMethodNotAllowed)
+	default:
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *routeProblemWriter) Write(body []byte) (int, error) {
+	if w.replaced {
+		return len(body), nil
+	}
+	return w.ResponseWriter.Write(body)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"net/http"
+)
+
+// Search godoc
+// @Summary Поиск фильмов и актеров
+// @Description Нечеткий поиск по названию фильма и имени актера без учета регистра, находит названия с опечатками и по началу слова.
+// @Description Результаты упорядочены по убыванию похожести score, в highlight похожие слова выделены тегом mark.
+// @Description Ищутся только фильмы и актеры, на чтение которых есть право
+// @Tags search
+// @Accept json
+// @Produce json
+// @Param q query string true "Поисковый запрос"
+// @Param kind query string false "Искать только фильмы или только актеров" Enums(movie, actor)
+// @Param limit query int false "Количество результатов"
+// @Success 200 {object} models.SearchResults
+// @Failure 401,403,422 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/v1/search [get]
+// @Security ApiKeyAuth
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	result, err := h.services.Search(r.Context(), models.SearchParams{
+		Query: query.Get("q"),
+		Kind:  query.Get("kind"),
+		Limit: query.Get("limit"),
+	})
+	if err != nil {
+		h.problem(w, r, "Search", err)
+		return
+	}
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		h.problem(w, r, "Can't marshal result", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusOK, "Search results created")
+}
+
+// Suggest godoc
+// @Summary Подсказки при вводе названия фильма или имени актера
+// @Description Фильмы или актеры, название которых начинается с prefix без учета регистра, упорядоченные по названию.
+// @Description Количество подсказок задается в настройках, подсказки кешируются и обновляются при изменении фильмов и актеров
+// @Tags search
+// @Accept json
+// @Produce json
+// @Param prefix query string true "Начало названия"
+// @Param kind query string true "Подсказывать фильмы или актеров" Enums(movie, actor)
+// @Success 200 {object} models.Suggestions
+// @Failure 401,403,422 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /api/v1/suggest [get]
+// @Security ApiKeyAuth
+func (h *Handler) Suggest(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	result, err := h.services.Suggest(r.Context(), models.SuggestParams{
+		Prefix: query.Get("prefix"),
+		Kind:   query.Get("kind"),
+	})
+	if err != nil {
+		h.problem(w, r, "Suggest", err)
+		return
+	}
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		h.problem(w, r, "Can't marshal result", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+	h.log.HandlerLog(r, http.StatusOK, "Suggestions created")
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"github.com/ast3am/VKintern-movies/api/handlers/mocks"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_Search(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+	emptyQuery := &models.ValidationError{Fields: []models.FieldError{{Field: "q", Message: "must not be empty"}}}
+	results := &models.SearchResults{Query: "inceptoin", Results: []*models.SearchResult{{
+		Kind:      models.SearchKindMovie,
+		ID:        uuid.MustParse("20648636-b14e-4f88-a02a-8c4e3c2f534d"),
+		Name:      "Inception",
+		Score:     0.5,
+		Highlight: "<mark>Inception</mark>",
+	}}}
+	testTable := []struct {
+		name                 string
+		url                  string
+		params               models.SearchParams
+		result               *models.SearchResults
+		err                  error
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			"empty query",
+			"/api/v1/search?q=",
+			models.SearchParams{},
+			nil,
+			emptyQuery,
+			http.StatusUnprocessableEntity,
+			problemBody("/api/v1/search", http.StatusUnprocessableEntity, "validation_failed", "validation failed", emptyQuery.Fields...),
+		}, {
+			"no permission for kind",
+			"/api/v1/search?q=hanks&kind=actor",
+			models.SearchParams{Query: "hanks", Kind: "actor"},
+			nil,
+			models.ErrPermissionDenied,
+			http.StatusForbidden,
+			problemBody("/api/v1/search", http.StatusForbidden, "permission_denied", "permission denied"),
+		}, {
+			"positive",
+			"/api/v1/search?q=inceptoin&limit=5",
+			models.SearchParams{Query: "inceptoin", Limit: "5"},
+			results,
+			nil,
+			http.StatusOK,
+			`{"query":"inceptoin","results":[{"kind":"movie","id":"20648636-b14e-4f88-a02a-8c4e3c2f534d","name":"Inception","score":0.5,` +
+				`"highlight":"\u003cmark\u003eInception\u003c/mark\u003e"}]}`,
+		},
+	}
+	serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(testPrincipal, nil)
+	for _, test := range testTable {
+		if test.err != nil {
+			serv.On("Search", mock.AnythingOfType("*context.valueCtx"), test.params).Return(nil, test.err).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "Search", test.err).Return(0).Once()
+		} else {
+			serv.On("Search", mock.AnythingOfType("*context.valueCtx"), test.params).Return(test.result, nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "Search results created").Return(0).Once()
+		}
+		req := httptest.NewRequest(http.MethodGet, test.url, nil)
+		req.Header.Set("Authorization", "Test-token")
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, r.Body.String(), test.name)
+	}
+
+	//Достаточно права на чтение фильмов или актеров, без обоих поиск запрещен
+	principal := &models.Principal{UserID: testPrincipal.UserID, Permissions: []models.Permission{models.PermMovieWrite}}
+	serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Writer-token").Return(principal, nil).Once()
+	log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), http.StatusForbidden, "movie:read or actor:read", models.ErrPermissionDenied).Return(0).Once()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/search?q=hanks", nil)
+	req.Header.Set("Authorization", "Writer-token")
+	r := httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusForbidden, r.Code)
+
+	principal = &models.Principal{UserID: testPrincipal.UserID, Permissions: []models.Permission{models.PermActorRead}}
+	serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Reader-token").Return(principal, nil).Once()
+	serv.On("Search", mock.AnythingOfType("*context.valueCtx"), models.SearchParams{Query: "hanks"}).Return(&models.SearchResults{Query: "hanks", Results: []*models.SearchResult{}}, nil).Once()
+	log.On("HandlerLog", mock.AnythingOfType("*http.Request"), http.StatusOK, "Search results created").Return(0).Once()
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/search?q=hanks", nil)
+	req.Header.Set("Authorization", "Reader-token")
+	r = httptest.NewRecorder()
+	mux.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, `{"query":"hanks","results":[]}`, r.Body.String())
+}
+
+func TestHandler_Suggest(t *testing.T) {
+	mux := http.NewServeMux()
+	serv := mocks.NewService(t)
+	log := mocks.NewLogger(t)
+	h := NewHandler(serv, log)
+	h.RegisterHandlers(mux)
+	noKind := &models.ValidationError{Fields: []models.FieldError{{Field: "kind", Message: "must be movie or actor"}}}
+	testTable := []struct {
+		name                 string
+		url                  string
+		params               models.SuggestParams
+		result               *models.Suggestions
+		err                  error
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			"no kind",
+			"/api/v1/suggest?prefix=tom",
+			models.SuggestParams{Prefix: "tom"},
+			nil,
+			noKind,
+			http.StatusUnprocessableEntity,
+			problemBody("/api/v1/suggest", http.StatusUnprocessableEntity, "validation_failed", "validation failed", noKind.Fields...),
+		}, {
+			"positive",
+			"/api/v1/suggest?prefix=Tom+&kind=actor",
+			models.SuggestParams{Prefix: "Tom ", Kind: "actor"},
+			&models.Suggestions{Kind: "actor", Prefix: "tom ", Suggestions: []*models.Suggestion{
+				{ID: uuid.MustParse("b0482c7a-1a4c-4a3c-9463-35f0036a0d60"), Name: "Tom Hanks"},
+			}},
+			nil,
+			http.StatusOK,
+			`{"kind":"actor","prefix":"tom ","suggestions":[{"id":"b0482c7a-1a4c-4a3c-9463-35f0036a0d60","name":"Tom Hanks"}]}`,
+		},
+	}
+	serv.On("Authenticate", mock.AnythingOfType("context.backgroundCtx"), "Test-token").Return(testPrincipal, nil)
+	for _, test := range testTable {
+		if test.err != nil {
+			serv.On("Suggest", mock.AnythingOfType("*context.valueCtx"), test.params).Return(nil, test.err).Once()
+			log.On("HandlerErrorLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "Suggest", test.err).Return(0).Once()
+		} else {
+			serv.On("Suggest", mock.AnythingOfType("*context.valueCtx"), test.params).Return(test.result, nil).Once()
+			log.On("HandlerLog", mock.AnythingOfType("*http.Request"), test.expectedStatusCode, "Suggestions created").Return(0).Once()
+		}
+		req := httptest.NewRequest(http.MethodGet, test.url, nil)
+		req.Header.Set("Authorization", "Test-token")
+		r := httptest.NewRecorder()
+		mux.ServeHTTP(r, req)
+		assert.Equal(t, test.expectedStatusCode, r.Code, test.name)
+		assert.Equal(t, test.expectedResponseBody, r.Body.String(), test.name)
+	}
+}
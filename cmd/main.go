@@ -6,14 +6,21 @@ import (
 	"github.com/ast3am/VKintern-movies/api/handlers"
 	"github.com/ast3am/VKintern-movies/internal/config"
 	"github.com/ast3am/VKintern-movies/internal/db"
+	"github.com/ast3am/VKintern-movies/internal/mail"
+	"github.com/ast3am/VKintern-movies/internal/oidc"
 	"github.com/ast3am/VKintern-movies/internal/service"
+	"github.com/ast3am/VKintern-movies/internal/utils"
 	"github.com/ast3am/VKintern-movies/pkg/logging"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
+// shutdownTimeout - время на завершение текущих запросов и отправку писем при остановке
+const shutdownTimeout = time.Minute
+
 //@title VKintern api doc
 //@version 1.0
 
@@ -33,9 +40,35 @@ func main() {
 	}
 	defer db.Close(ctx)
 
+	tokens, err := utils.NewTokenManager(cfg.JWT)
+	if err != nil {
+		log.FatalMsg("", err)
+	}
+
+	mailer, err := mail.New(cfg.Mail)
+	if err != nil {
+		log.FatalMsg("", err)
+	}
+
+	// вход через провайдер включается, только если задан issuer. Без провайдера в сервис передается nil без типа:
+	// nil указатель *oidc.Provider внутри интерфейса не равен nil
+	var serv *service.Service
+	if cfg.OIDC.Issuer != "" {
+		provider, err := oidc.NewProvider(cfg.OIDC, nil)
+		if err != nil {
+			log.FatalMsg("", err)
+		}
+		serv = service.NewService(db, log, tokens, provider, mailer, cfg)
+	} else {
+		serv = service.NewService(db, log, tokens, nil, mailer, cfg)
+	}
+
 	mux := http.NewServeMux()
-	service := service.NewService(db, log)
-	handler := handlers.NewHandler(service, log)
+	handler := handlers.NewHandler(serv, log)
+	err = handler.SetTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.FatalMsg("", err)
+	}
 	handler.RegisterHandlers(mux)
 
 	srv := &http.Server{
@@ -51,7 +84,19 @@ func main() {
 	}()
 
 	log.InfoMsg("service is running")
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
+
+	log.InfoMsg("service is shutting down")
+	ctx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+	defer cancel()
+	err = srv.Shutdown(ctx)
+	if err != nil {
+		log.ErrorMsg("server shutdown", err)
+	}
+	err = serv.Close(ctx)
+	if err != nil {
+		log.ErrorMsg("unsent mails are lost", err)
+	}
 }
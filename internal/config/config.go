@@ -1,10 +1,12 @@
 package config
 
 import (
+	"errors"
 	"github.com/ast3am/VKintern-movies/internal/models"
 	"github.com/ast3am/VKintern-movies/pkg/logging"
 	"github.com/ilyakaznacheev/cleanenv"
 	"os"
+	"strings"
 )
 
 var cfg *models.Config
@@ -17,5 +19,25 @@ func GetConfig(path string) *models.Config {
 	if err != nil {
 		logger.FatalMsg("", err)
 	}
+	err = readJWTSecret(&cfg.JWT)
+	if err != nil {
+		logger.FatalMsg("jwt config", err)
+	}
 	return cfg
 }
+
+// readJWTSecret подставляет секрет из файла, если он не задан в конфиге напрямую
+func readJWTSecret(jwtCfg *models.JWTConfig) error {
+	if jwtCfg.Secret != "" || jwtCfg.SecretFile == "" {
+		return nil
+	}
+	secret, err := os.ReadFile(jwtCfg.SecretFile)
+	if err != nil {
+		return err
+	}
+	jwtCfg.Secret = strings.TrimSpace(string(secret))
+	if jwtCfg.Secret == "" {
+		return errors.New("jwt secret file is empty")
+	}
+	return nil
+}
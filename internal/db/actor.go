@@ -2,23 +2,32 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"github.com/ast3am/VKintern-movies/internal/models"
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 )
 
-func (db *DB) CreateActor(ctx context.Context, id uuid.UUID, actor models.Actor) error {
+// CreateActor возвращает сохраненного актера, фильмы, в которых актер уже указан по имени, попадают в его список
+func (db *DB) CreateActor(ctx context.Context, id uuid.UUID, actor models.Actor) (*models.ActorDetails, error) {
 	tx, err := db.dbConnect.Begin(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer tx.Rollback(ctx)
 
 	createOrder := `
-	INSERT INTO actors values ($1, $2, $3, $4)
+	INSERT INTO actors (uuid, name, gender, birth_date) values ($1, $2, $3, $4)
 	`
 	_, err = tx.Exec(ctx, createOrder, id, actor.Name, actor.Gender, actor.BirthDate)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return nil, models.ErrActorExists
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 	updateFilmOrder := `
 	UPDATE movie_actors
@@ -27,13 +36,17 @@ func (db *DB) CreateActor(ctx context.Context, id uuid.UUID, actor models.Actor)
 `
 	_, err = tx.Exec(ctx, updateFilmOrder, id, actor.Name)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	result, err := getActorByUUID(ctx, tx, id)
+	if err != nil {
+		return nil, err
 	}
 	err = tx.Commit(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return result, nil
 }
 
 func (db *DB) DeleteActor(ctx context.Context, uid uuid.UUID) error {
@@ -45,10 +58,13 @@ func (db *DB) DeleteActor(ctx context.Context, uid uuid.UUID) error {
 	deleteOrder := `
 	DELETE FROM actors WHERE uuid = $1
 	`
-	_, err = tx.Exec(ctx, deleteOrder, uid)
+	tag, err := tx.Exec(ctx, deleteOrder, uid)
 	if err != nil {
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrActorNotFound
+	}
 	err = tx.Commit(ctx)
 	if err != nil {
 		return err
@@ -56,16 +72,48 @@ func (db *DB) DeleteActor(ctx context.Context, uid uuid.UUID) error {
 	return nil
 }
 
-func (db *DB) GetActorByUUID(ctx context.Context, id uuid.UUID) (*models.Actor, error) {
-	result := models.Actor{}
+// GetActorByUUID возвращает актера со списком фильмов, отсортированным по дате выхода
+func (db *DB) GetActorByUUID(ctx context.Context, id uuid.UUID) (*models.ActorDetails, error) {
+	return getActorByUUID(ctx, db.dbConnect, id)
+}
+
+func getActorByUUID(ctx context.Context, q querier, id uuid.UUID) (*models.ActorDetails, error) {
+	result := models.ActorDetails{ID: id, Filmography: make([]models.ActorMovie, 0)}
 	queryOrder := `
-	SELECT name, gender, birth_date FROM actors WHERE uuid = $1
+	SELECT name, gender, birth_date, created_at, updated_at FROM actors WHERE uuid = $1
 	`
-	err := db.dbConnect.QueryRow(ctx, queryOrder, id).Scan(&result.Name, &result.Gender, &result.BirthDate)
-	if err != nil {
+	err := q.QueryRow(ctx, queryOrder, id).Scan(&result.Name, &result.Gender, &result.BirthDate, &result.CreatedAt, &result.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrActorNotFound
+	} else if err != nil {
 		return nil, err
 	}
 
+	filmographyOrder := `
+	SELECT movies.uuid, movies.name, movies.release_date FROM movies
+	JOIN movie_actors ma ON ma.movie_uuid = movies.uuid
+	WHERE ma.actor_uuid = $1
+	ORDER BY movies.release_date, movies.name`
+	rows, err := q.Query(ctx, filmographyOrder, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var movie models.ActorMovie
+		var timestamp sql.NullTime
+		err = rows.Scan(&movie.ID, &movie.Name, &timestamp)
+		if err != nil {
+			return nil, err
+		}
+		if timestamp.Valid {
+			movie.ReleaseDate = timestamp.Time
+		}
+		result.Filmography = append(result.Filmography, movie)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
 	return &result, nil
 }
 
@@ -78,13 +126,20 @@ func (db *DB) UpdateActor(ctx context.Context, id uuid.UUID, actor models.Actor)
 
 	updateOrder := `
 	UPDATE actors
-	SET name = $2, gender = $3, birth_date = $4
+	SET name = $2, gender = $3, birth_date = $4, updated_at = now()
 	WHERE uuid = $1
 	`
-	_, err = tx.Exec(ctx, updateOrder, id, actor.Name, actor.Gender, actor.BirthDate)
+	tag, err := tx.Exec(ctx, updateOrder, id, actor.Name, actor.Gender, actor.BirthDate)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return models.ErrActorExists
+	}
 	if err != nil {
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrActorNotFound
+	}
 	updateFilmOrder := `
 	UPDATE movie_actors
 	SET actor_name = $2
@@ -101,26 +156,70 @@ func (db *DB) UpdateActor(ctx context.Context, id uuid.UUID, actor models.Actor)
 	return nil
 }
 
-func (db *DB) GetActorList(ctx context.Context) (map[string][]string, error) {
-	result := make(map[string][]string)
+var actorSortColumns = sortColumns{
+	"name": {"name", "varchar", false},
+}
+
+// GetActorList возвращает страницу актеров, упорядоченных по имени, и общее количество актеров. Актеры без фильмов
+// выдаются с пустым списком фильмов. Страница выбирается из actors до соединения с фильмами
+func (db *DB) GetActorList(ctx context.Context, after *models.Cursor, limit, offset int) ([]*models.ActorDetails, int, error) {
+	order, err := actorSortColumns.order(models.ActorListSort)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = db.dbConnect.QueryRow(ctx, `SELECT count(*) FROM actors`).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var q queryBuilder
+	if after != nil {
+		err = order.after(&q, after)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
 	getActorsOrder := `
-	SELECT actors.name AS actor_name, movies.name AS movie_name
-	FROM movies
-	JOIN movie_actors ON movies.uuid = movie_actors.movie_uuid
-	JOIN actors ON movie_actors.actor_uuid = actors.uuid
-	ORDER BY actor_name, movie_name`
-	rows, err := db.dbConnect.Query(ctx, getActorsOrder)
+	WITH page AS (
+		SELECT uuid, name, gender, birth_date, created_at, updated_at FROM actors
+		` + q.whereClause() + `
+		ORDER BY ` + order.orderBy("") + `
+		` + q.limit(limit, offset) + `
+	)
+	SELECT page.uuid, page.name, page.gender, page.birth_date, page.created_at, page.updated_at,
+		movies.uuid, movies.name, movies.release_date
+	FROM page
+	LEFT JOIN movie_actors ON movie_actors.actor_uuid = page.uuid
+	LEFT JOIN movies ON movies.uuid = movie_actors.movie_uuid
+	ORDER BY page.name, page.uuid, movies.release_date, movies.name`
+	rows, err := db.dbConnect.Query(ctx, getActorsOrder, q.args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
+	result := make([]*models.ActorDetails, 0)
 	for rows.Next() {
-		var actorName, film string
-		err = rows.Scan(&actorName, &film)
+		actor := models.ActorDetails{Filmography: make([]models.ActorMovie, 0)}
+		var movieID *uuid.UUID
+		var movieName sql.NullString
+		var releaseDate sql.NullTime
+		err = rows.Scan(&actor.ID, &actor.Name, &actor.Gender, &actor.BirthDate, &actor.CreatedAt, &actor.UpdatedAt,
+			&movieID, &movieName, &releaseDate)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
+		}
+		if len(result) == 0 || result[len(result)-1].ID != actor.ID {
+			result = append(result, &actor)
+		}
+		if movieID != nil {
+			last := result[len(result)-1]
+			last.Filmography = append(last.Filmography, models.ActorMovie{ID: *movieID, Name: movieName.String, ReleaseDate: releaseDate.Time})
 		}
-		result[actorName] = append(result[actorName], film)
 	}
-	return result, nil
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return result, total, nil
 }
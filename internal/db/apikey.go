@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+// apiKeyQuery выбирает ключи вместе с признаком блокировки создателя и текущими правами его ролей,
+// ключ удаленного пользователя имеет пустой created_by
+const apiKeyQuery = `
+	SELECT k.uuid, k.name, k.prefix, k.key_hash, k.permissions, k.created_by, COALESCE(u.disabled, false),
+	       ARRAY(
+	           SELECT DISTINCT rp.permission
+	           FROM user_roles ur
+	           JOIN role_permissions rp ON rp.role = ur.role
+	           WHERE ur.user_uuid = k.created_by
+	           ORDER BY rp.permission
+	       ),
+	       k.created_at, k.expires_at, k.last_used_at, k.revoked_at
+	FROM api_keys k
+	LEFT JOIN users u ON u.uuid = k.created_by
+	`
+
+func scanAPIKey(row pgx.Row) (*models.APIKey, error) {
+	result := models.APIKey{}
+	var permissions, creatorPermissions []string
+	err := row.Scan(&result.ID, &result.Name, &result.Prefix, &result.Hash, &permissions, &result.CreatedBy,
+		&result.CreatorDisabled, &creatorPermissions, &result.CreatedAt, &result.ExpiresAt, &result.LastUsedAt, &result.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	result.Permissions = toPermissions(permissions)
+	result.CreatorPermissions = toPermissions(creatorPermissions)
+	return &result, nil
+}
+
+func toPermissions(values []string) []models.Permission {
+	result := make([]models.Permission, 0, len(values))
+	for _, value := range values {
+		result = append(result, models.Permission(value))
+	}
+	return result
+}
+
+func (db *DB) CreateAPIKey(ctx context.Context, key models.APIKey) error {
+	permissions := make([]string, 0, len(key.Permissions))
+	for _, permission := range key.Permissions {
+		permissions = append(permissions, string(permission))
+	}
+	createOrder := `
+	INSERT INTO api_keys (uuid, name, prefix, key_hash, permissions, created_by, created_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := db.dbConnect.Exec(ctx, createOrder, key.ID, key.Name, key.Prefix, key.Hash, permissions, key.CreatedBy,
+		key.CreatedAt, key.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (db *DB) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	queryOrder := apiKeyQuery + `WHERE k.key_hash = $1`
+	result, err := scanAPIKey(db.dbConnect.QueryRow(ctx, queryOrder, hash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrAPIKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetAPIKeyList возвращает все ключи, включая отозванные и просроченные, начиная с новых
+func (db *DB) GetAPIKeyList(ctx context.Context) ([]*models.APIKey, error) {
+	result := make([]*models.APIKey, 0)
+	queryOrder := apiKeyQuery + `ORDER BY k.created_at DESC`
+	rows, err := db.dbConnect.Query(ctx, queryOrder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (db *DB) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	revokeOrder := `
+	UPDATE api_keys
+	SET revoked_at = COALESCE(revoked_at, now())
+	WHERE uuid = $1
+	`
+	tag, err := db.dbConnect.Exec(ctx, revokeOrder, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey обновляет время последнего использования ключа, если предыдущее значение старше notAfter.
+// Это ограничивает количество записей в БД при частых запросах с одним ключом
+func (db *DB) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt, notAfter time.Time) error {
+	touchOrder := `
+	UPDATE api_keys
+	SET last_used_at = $2
+	WHERE uuid = $1 AND (last_used_at IS NULL OR last_used_at < $3)
+	`
+	_, err := db.dbConnect.Exec(ctx, touchOrder, id, usedAt, notAfter)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -14,7 +15,9 @@ import (
 const (
 	newActorUUID = "b0482c7a-1a4c-4a3c-9463-35f0036a0d62"
 	newMovieUUID = "f44d4a8f-7f16-4c1d-836b-02e0b8de4a00"
+	newUserUUID  = "5b2c0e0e-7a43-4a4c-9d3e-6f0f9a1c2b11"
 	userEmail    = "testuser@mail.com"
+	userUUID     = "2300a1f6-b2aa-4f5b-b6ca-8f495582e255"
 )
 
 func GetDate(date string) time.Time {
@@ -23,6 +26,13 @@ func GetDate(date string) time.Time {
 	return t
 }
 
+// resetTimestamps проверяет, что created_at и updated_at заполнены БД, и обнуляет их для сравнения с ожидаемым значением
+func resetTimestamps(t *testing.T, createdAt, updatedAt *time.Time) {
+	assert.False(t, createdAt.IsZero())
+	assert.False(t, updatedAt.Before(*createdAt))
+	*createdAt, *updatedAt = time.Time{}, time.Time{}
+}
+
 var testCfg = models.Config{
 	ListenPort: "",
 	SqlConfig: models.SqlConfig{
@@ -112,10 +122,18 @@ func TestDB_CreateActor(t *testing.T) {
 	}
 	for _, test := range testTable {
 		if test.name == "positive" {
-			err := db.CreateActor(ctx, test.id, test.testActor)
+			result, err := db.CreateActor(ctx, test.id, test.testActor)
 			assert.Nil(t, err)
+			resetTimestamps(t, &result.CreatedAt, &result.UpdatedAt)
+			assert.Equal(t, &models.ActorDetails{
+				ID:          test.id,
+				Name:        "test_actor_1",
+				Gender:      "male",
+				BirthDate:   GetDate("2002-01-01"),
+				Filmography: []models.ActorMovie{},
+			}, result)
 		} else {
-			err := db.CreateActor(ctx, test.id, test.testActor)
+			_, err := db.CreateActor(ctx, test.id, test.testActor)
 			assert.NotNil(t, err)
 		}
 	}
@@ -145,14 +163,29 @@ func TestDB_GetActorByUUID(t *testing.T) {
 	db, _ := NewClient(ctx, &testCfg, log)
 	defer db.dbConnect.Close(ctx)
 	id := uuid.MustParse(newActorUUID)
-	expected := &models.Actor{
-		Name:      "test_actor_1",
-		Gender:    "female",
-		BirthDate: GetDate("2002-01-01"),
+	expected := &models.ActorDetails{
+		ID:          id,
+		Name:        "test_actor_1",
+		Gender:      "female",
+		BirthDate:   GetDate("2002-01-01"),
+		Filmography: []models.ActorMovie{},
 	}
 	result, err := db.GetActorByUUID(ctx, id)
+	require.Nil(t, err)
+	assert.True(t, result.UpdatedAt.After(result.CreatedAt))
+	resetTimestamps(t, &result.CreatedAt, &result.UpdatedAt)
 	assert.Equal(t, expected, result)
+	//фильмы актера
+	result, err = db.GetActorByUUID(ctx, uuid.MustParse("b0482c7a-1a4c-4a3c-9463-35f0036a0d60"))
 	assert.Nil(t, err)
+	assert.Equal(t, []models.ActorMovie{
+		{ID: uuid.MustParse("43a4b2df-8ff8-4d32-9203-0367c2714431"), Name: "Pretty Woman", ReleaseDate: GetDate("1990-03-23")},
+		{ID: uuid.MustParse("20648636-b14e-4f88-a02a-8c4e3c2f534d"), Name: "Forrest Gump", ReleaseDate: GetDate("1994-07-06")},
+		{ID: uuid.MustParse("a5e85bc9-5e75-4e5f-b890-0b156e15e40f"), Name: "Training Day", ReleaseDate: GetDate("2001-10-05")},
+	}, result.Filmography)
+	//not found
+	_, err = db.GetActorByUUID(ctx, uuid.MustParse("b0482c7a-1a4c-4a3c-9463-35f0036a0d99"))
+	assert.ErrorIs(t, err, models.ErrActorNotFound)
 }
 
 func TestDB_DeleteActor(t *testing.T) {
@@ -164,6 +197,8 @@ func TestDB_DeleteActor(t *testing.T) {
 	id := uuid.MustParse(newActorUUID)
 	err := db.DeleteActor(ctx, id)
 	assert.Nil(t, err)
+	err = db.DeleteActor(ctx, id)
+	assert.ErrorIs(t, err, models.ErrActorNotFound)
 }
 
 func TestDB_GetActorList(t *testing.T) {
@@ -172,16 +207,54 @@ func TestDB_GetActorList(t *testing.T) {
 	log.On("DebugMsg", "connection to DB is OK").Return()
 	db, _ := NewClient(ctx, &testCfg, log)
 	defer db.dbConnect.Close(ctx)
-	expected := map[string][]string{
-		"Brad Pitt":         {"Inception", "The Devil Wears Prada"},
-		"Cate Blanchett":    {"Inception", "Pretty Woman"},
-		"Julia Roberts":     {"Forrest Gump", "The Devil Wears Prada"},
-		"Leonardo DiCaprio": {"Training Day"},
-		"Tom Hanks":         {"Forrest Gump", "Pretty Woman", "Training Day"},
-	}
-	result, err := db.GetActorList(ctx)
+	forrestGump := models.ActorMovie{ID: uuid.MustParse("20648636-b14e-4f88-a02a-8c4e3c2f534d"), Name: "Forrest Gump", ReleaseDate: GetDate("1994-07-06")}
+	devilWearsPrada := models.ActorMovie{ID: uuid.MustParse("f44d4a8f-7f16-4c1d-836b-02e0b8de4a99"), Name: "The Devil Wears Prada", ReleaseDate: GetDate("2006-06-30")}
+	trainingDay := models.ActorMovie{ID: uuid.MustParse("a5e85bc9-5e75-4e5f-b890-0b156e15e40f"), Name: "Training Day", ReleaseDate: GetDate("2001-10-05")}
+	prettyWoman := models.ActorMovie{ID: uuid.MustParse("43a4b2df-8ff8-4d32-9203-0367c2714431"), Name: "Pretty Woman", ReleaseDate: GetDate("1990-03-23")}
+	inception := models.ActorMovie{ID: uuid.MustParse("b19069b7-7296-4e67-a5f2-80c6e01a32d0"), Name: "Inception", ReleaseDate: GetDate("2010-07-16")}
+	expected := []*models.ActorDetails{
+		{
+			ID: uuid.MustParse("40d882f7-b027-4a07-85da-76e0f7d9b6e3"), Name: "Brad Pitt", Gender: "Male", BirthDate: GetDate("1963-12-18"),
+			Filmography: []models.ActorMovie{devilWearsPrada, inception},
+		}, {
+			ID: uuid.MustParse("d86d5541-bda1-4b89-9256-5cfbba11dc89"), Name: "Cate Blanchett", Gender: "Female", BirthDate: GetDate("1969-05-14"),
+			Filmography: []models.ActorMovie{prettyWoman, inception},
+		}, {
+			ID: uuid.MustParse("7cfd7e7f-3a27-46c5-9c3e-3b3d3b1c1416"), Name: "Julia Roberts", Gender: "Female", BirthDate: GetDate("1967-10-28"),
+			Filmography: []models.ActorMovie{forrestGump, devilWearsPrada},
+		}, {
+			ID: uuid.MustParse("eb1b5f32-82c3-4dfb-aad2-9432908d12b7"), Name: "Leonardo DiCaprio", Gender: "Male", BirthDate: GetDate("1974-11-11"),
+			Filmography: []models.ActorMovie{trainingDay},
+		}, {
+			ID: uuid.MustParse("b0482c7a-1a4c-4a3c-9463-35f0036a0d60"), Name: "Tom Hanks", Gender: "Male", BirthDate: GetDate("1956-07-09"),
+			Filmography: []models.ActorMovie{prettyWoman, forrestGump, trainingDay},
+		},
+	}
+	result, total, err := db.GetActorList(ctx, nil, 10, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, total)
+	for _, val := range result {
+		resetTimestamps(t, &val.CreatedAt, &val.UpdatedAt)
+	}
 	assert.Equal(t, expected, result)
+
+	//страница по номеру, фильмы актера не делятся между страницами
+	result, total, err = db.GetActorList(ctx, nil, 2, 4)
 	assert.Nil(t, err)
+	assert.Equal(t, 5, total)
+	for _, val := range result {
+		resetTimestamps(t, &val.CreatedAt, &val.UpdatedAt)
+	}
+	assert.Equal(t, expected[4:], result)
+
+	//страница по курсору после Cate Blanchett
+	after := &models.Cursor{Values: []string{"Cate Blanchett"}, ID: expected[1].ID}
+	result, _, err = db.GetActorList(ctx, after, 2, 0)
+	assert.Nil(t, err)
+	for _, val := range result {
+		resetTimestamps(t, &val.CreatedAt, &val.UpdatedAt)
+	}
+	assert.Equal(t, expected[2:4], result)
 }
 
 func TestDB_GetUserByEmail(t *testing.T) {
@@ -192,13 +265,497 @@ func TestDB_GetUserByEmail(t *testing.T) {
 	defer db.dbConnect.Close(ctx)
 	email := userEmail
 	expected := &models.User{
-		Email:    "testuser@mail.com",
-		Password: "userPassword",
-		Role:     "user",
+		ID:           uuid.MustParse(userUUID),
+		Email:        "testuser@mail.com",
+		Password:     "userPassword",
+		PasswordAlgo: models.PasswordAlgoPlain,
+		Roles:        []string{"user"},
 	}
 	result, err := db.GetUserByEmail(ctx, email)
 	assert.Equal(t, expected, result)
 	assert.Nil(t, err)
+	//Регистр email не учитывается
+	result, err = db.GetUserByEmail(ctx, "TestUser@Mail.com")
+	assert.Equal(t, expected, result)
+	assert.Nil(t, err)
+}
+
+func TestDB_UpdateUserPassword(t *testing.T) {
+	ctx := context.Background()
+	log := mocks.NewLogger(t)
+	log.On("DebugMsg", "connection to DB is OK").Return()
+	db, _ := NewClient(ctx, &testCfg, log)
+	defer db.dbConnect.Close(ctx)
+	user := models.User{Email: userEmail}
+	err := user.SetPassword("userPassword")
+	assert.Nil(t, err)
+	err = db.UpdateUserPassword(ctx, user.Email, user.Password, user.PasswordAlgo)
+	assert.Nil(t, err)
+	result, err := db.GetUserByEmail(ctx, userEmail)
+	assert.Nil(t, err)
+	assert.Equal(t, models.PasswordAlgoBcrypt, result.PasswordAlgo)
+	assert.True(t, result.CheckCreds(userEmail, "userPassword"))
+	err = db.UpdateUserPassword(ctx, userEmail, "userPassword", models.PasswordAlgoPlain)
+	assert.Nil(t, err)
+}
+
+func TestDB_CreateUser(t *testing.T) {
+	ctx := context.Background()
+	log := mocks.NewLogger(t)
+	log.On("DebugMsg", "connection to DB is OK").Return()
+	db, _ := NewClient(ctx, &testCfg, log)
+	defer db.dbConnect.Close(ctx)
+	user := models.User{ID: uuid.MustParse(newUserUUID), Email: "newuser@mail.com", Roles: []string{models.RoleEditor, models.RoleUser}}
+	err := user.SetPassword("newPassword1")
+	assert.Nil(t, err)
+	err = db.CreateUser(ctx, user.ID, user)
+	assert.Nil(t, err)
+	result, err := db.GetUserByEmail(ctx, user.Email)
+	assert.Nil(t, err)
+	assert.Equal(t, &user, result)
+	permissions, err := db.GetUserPermissions(ctx, user.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Permission{models.PermActorRead, models.PermActorWrite, models.PermMovieRead, models.PermMovieWrite}, permissions)
+	//Повторный email, в том числе в другом регистре
+	err = db.CreateUser(ctx, uuid.New(), user)
+	assert.ErrorIs(t, err, models.ErrUserExists)
+	user.Email = "NewUser@mail.com"
+	err = db.CreateUser(ctx, uuid.New(), user)
+	assert.ErrorIs(t, err, models.ErrUserExists)
+}
+
+func TestDB_GetUserByUUID(t *testing.T) {
+	ctx := context.Background()
+	log := mocks.NewLogger(t)
+	log.On("DebugMsg", "connection to DB is OK").Return()
+	db, _ := NewClient(ctx, &testCfg, log)
+	defer db.dbConnect.Close(ctx)
+	result, err := db.GetUserByUUID(ctx, uuid.MustParse(userUUID))
+	assert.Nil(t, err)
+	assert.Equal(t, userEmail, result.Email)
+	_, err = db.GetUserByUUID(ctx, uuid.New())
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+}
+
+func TestDB_GetUserPermissions(t *testing.T) {
+	ctx := context.Background()
+	log := mocks.NewLogger(t)
+	log.On("DebugMsg", "connection to DB is OK").Return()
+	db, _ := NewClient(ctx, &testCfg, log)
+	defer db.dbConnect.Close(ctx)
+
+	result, err := db.GetUserPermissions(ctx, uuid.MustParse(userUUID))
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Permission{models.PermActorRead, models.PermMovieRead}, result)
+	result, err = db.GetUserPermissions(ctx, uuid.MustParse("482d6f53-b2ee-4684-887e-2588ae6c9d48"))
+	assert.Nil(t, err)
+	assert.Contains(t, result, models.PermUserManage)
+	assert.Contains(t, result, models.PermMovieDelete)
+	//У пользователя без ролей нет прав
+	result, err = db.GetUserPermissions(ctx, uuid.New())
+	assert.Nil(t, err)
+	assert.Empty(t, result)
+}
+
+func TestDB_UserManagement(t *testing.T) {
+	ctx := context.Background()
+	log := mocks.NewLogger(t)
+	log.On("DebugMsg", "connection to DB is OK").Return()
+	db, _ := NewClient(ctx, &testCfg, log)
+	defer db.dbConnect.Close(ctx)
+	id := uuid.New()
+	user := models.User{Email: "manage@mail.com", Password: "managePassword1", PasswordAlgo: models.PasswordAlgoPlain, Roles: []string{models.RoleUser}}
+	err := db.CreateUser(ctx, id, user)
+	require.Nil(t, err)
+
+	//Список отсортирован по email
+	users, total, err := db.GetUserList(ctx, 2, 0)
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, total, 3)
+	require.Len(t, users, 2)
+	assert.Equal(t, "admin@vk.ru", users[0].Email)
+	assert.Equal(t, []string{"admin"}, users[0].Roles)
+	assert.Equal(t, "manage@mail.com", users[1].Email)
+	users, _, err = db.GetUserList(ctx, 2, total)
+	assert.Nil(t, err)
+	assert.Empty(t, users)
+
+	err = db.SetUserRoles(ctx, id, []string{models.RoleEditor, models.RoleAdmin})
+	assert.Nil(t, err)
+	result, err := db.GetUserByUUID(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{models.RoleAdmin, models.RoleEditor}, result.Roles)
+	//Неизвестная роль не меняет роли пользователя
+	err = db.SetUserRoles(ctx, id, []string{"unknown"})
+	assert.ErrorIs(t, err, models.ErrUnknownRole)
+	result, err = db.GetUserByUUID(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{models.RoleAdmin, models.RoleEditor}, result.Roles)
+	err = db.SetUserRoles(ctx, uuid.New(), []string{models.RoleUser})
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+
+	err = db.SetUserDisabled(ctx, id, true)
+	assert.Nil(t, err)
+	result, err = db.GetUserByEmail(ctx, user.Email)
+	assert.Nil(t, err)
+	assert.True(t, result.Disabled)
+	err = db.SetUserDisabled(ctx, uuid.New(), true)
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+
+	err = db.DeleteUser(ctx, id)
+	assert.Nil(t, err)
+	_, err = db.GetUserByUUID(ctx, id)
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+	err = db.DeleteUser(ctx, id)
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+}
+
+func TestDB_RefreshTokens(t *testing.T) {
+	ctx := context.Background()
+	log := mocks.NewLogger(t)
+	log.On("DebugMsg", "connection to DB is OK").Return()
+	db, _ := NewClient(ctx, &testCfg, log)
+	defer db.dbConnect.Close(ctx)
+	first := models.RefreshToken{
+		Hash:      "first_hash",
+		FamilyID:  uuid.New(),
+		UserID:    uuid.MustParse(userUUID),
+		ExpiresAt: time.Now().Add(time.Hour).Truncate(time.Microsecond),
+	}
+	second := first
+	second.Hash = "second_hash"
+
+	err := db.CreateRefreshToken(ctx, first)
+	assert.Nil(t, err)
+	result, err := db.GetRefreshToken(ctx, first.Hash)
+	assert.Nil(t, err)
+	assert.Equal(t, first.FamilyID, result.FamilyID)
+	assert.True(t, first.ExpiresAt.Equal(result.ExpiresAt))
+	assert.Nil(t, result.RotatedAt)
+
+	err = db.RotateRefreshToken(ctx, first.Hash, second)
+	assert.Nil(t, err)
+	result, err = db.GetRefreshToken(ctx, first.Hash)
+	assert.Nil(t, err)
+	assert.NotNil(t, result.RotatedAt)
+	//Повторная ротация того же токена
+	third := first
+	third.Hash = "third_hash"
+	err = db.RotateRefreshToken(ctx, first.Hash, third)
+	assert.ErrorIs(t, err, models.ErrRefreshTokenReused)
+
+	err = db.RevokeRefreshTokenFamily(ctx, first.FamilyID)
+	assert.Nil(t, err)
+	result, err = db.GetRefreshToken(ctx, second.Hash)
+	assert.Nil(t, err)
+	assert.NotNil(t, result.RevokedAt)
+
+	_, err = db.GetRefreshToken(ctx, "unknown_hash")
+	assert.ErrorIs(t, err, models.ErrInvalidRefreshToken)
+}
+
+func TestDB_RevokeTokens(t *testing.T) {
+	ctx := context.Background()
+	log := mocks.NewLogger(t)
+	log.On("DebugMsg", "connection to DB is OK").Return()
+	db, _ := NewClient(ctx, &testCfg, log)
+	defer db.dbConnect.Close(ctx)
+	id := uuid.MustParse(userUUID)
+
+	revoked, err := db.IsTokenRevoked(ctx, "test_jti")
+	assert.Nil(t, err)
+	assert.False(t, revoked)
+	err = db.RevokeToken(ctx, "test_jti", id, time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+	//Повторный отзыв не является ошибкой
+	err = db.RevokeToken(ctx, "test_jti", id, time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+	revoked, err = db.IsTokenRevoked(ctx, "test_jti")
+	assert.Nil(t, err)
+	assert.True(t, revoked)
+	//Просроченные записи удаляются при следующем отзыве
+	err = db.RevokeToken(ctx, "expired_jti", id, time.Now().Add(-time.Hour))
+	assert.Nil(t, err)
+	err = db.RevokeToken(ctx, "another_jti", id, time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+	revoked, err = db.IsTokenRevoked(ctx, "expired_jti")
+	assert.Nil(t, err)
+	assert.False(t, revoked)
+
+	revokedAt, err := db.GetUserTokensRevokedAt(ctx, id)
+	assert.Nil(t, err)
+	assert.Nil(t, revokedAt)
+	err = db.RevokeUserTokens(ctx, id)
+	assert.Nil(t, err)
+	revokedAt, err = db.GetUserTokensRevokedAt(ctx, id)
+	assert.Nil(t, err)
+	assert.NotNil(t, revokedAt)
+
+	err = db.RevokeUserTokens(ctx, uuid.New())
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+	_, err = db.GetUserTokensRevokedAt(ctx, uuid.New())
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+}
+
+func TestDB_LoginAttempts(t *testing.T) {
+	ctx := context.Background()
+	log := mocks.NewLogger(t)
+	log.On("DebugMsg", "connection to DB is OK").Return()
+	db, _ := NewClient(ctx, &testCfg, log)
+	defer db.dbConnect.Close(ctx)
+	now := time.Now().Truncate(time.Microsecond)
+
+	for i := 1; i <= 3; i++ {
+		failures, err := db.RegisterLoginFailure(ctx, models.LoginKeyEmail, userEmail, now, time.Minute)
+		assert.Nil(t, err)
+		assert.Equal(t, i, failures)
+	}
+	//После окна счетчик начинается заново
+	failures, err := db.RegisterLoginFailure(ctx, models.LoginKeyEmail, userEmail, now.Add(2*time.Minute), time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, failures)
+
+	lockedUntil, err := db.GetLoginLockedUntil(ctx, userEmail, "127.0.0.1", now)
+	assert.Nil(t, err)
+	assert.Nil(t, lockedUntil)
+	_, err = db.RegisterLoginFailure(ctx, models.LoginKeyIP, "127.0.0.1", now, time.Minute)
+	assert.Nil(t, err)
+	err = db.LockLogin(ctx, models.LoginKeyEmail, userEmail, now.Add(time.Minute))
+	assert.Nil(t, err)
+	err = db.LockLogin(ctx, models.LoginKeyIP, "127.0.0.1", now.Add(time.Hour))
+	assert.Nil(t, err)
+	lockedUntil, err = db.GetLoginLockedUntil(ctx, userEmail, "127.0.0.2", now)
+	assert.Nil(t, err)
+	require.NotNil(t, lockedUntil)
+	assert.True(t, now.Add(time.Minute).Equal(*lockedUntil))
+	lockedUntil, err = db.GetLoginLockedUntil(ctx, userEmail, "127.0.0.1", now)
+	assert.Nil(t, err)
+	require.NotNil(t, lockedUntil)
+	assert.True(t, now.Add(time.Hour).Equal(*lockedUntil))
+	//Истекшая блокировка не учитывается
+	lockedUntil, err = db.GetLoginLockedUntil(ctx, userEmail, "127.0.0.2", now.Add(2*time.Minute))
+	assert.Nil(t, err)
+	assert.Nil(t, lockedUntil)
+
+	err = db.ResetLoginFailures(ctx, models.LoginKeyEmail, userEmail)
+	assert.Nil(t, err)
+	err = db.ResetLoginFailures(ctx, models.LoginKeyIP, "127.0.0.1")
+	assert.Nil(t, err)
+	lockedUntil, err = db.GetLoginLockedUntil(ctx, userEmail, "127.0.0.1", now)
+	assert.Nil(t, err)
+	assert.Nil(t, lockedUntil)
+}
+
+func TestDB_TOTP(t *testing.T) {
+	ctx := context.Background()
+	log := mocks.NewLogger(t)
+	log.On("DebugMsg", "connection to DB is OK").Return()
+	db, _ := NewClient(ctx, &testCfg, log)
+	defer db.dbConnect.Close(ctx)
+	id := uuid.MustParse(userUUID)
+
+	totp, err := db.GetTOTP(ctx, id)
+	assert.Nil(t, err)
+	assert.Nil(t, totp)
+
+	//Неподтвержденный секрет можно заменить
+	err = db.SaveTOTPSecret(ctx, id, "FIRSTSECRET")
+	assert.Nil(t, err)
+	err = db.SaveTOTPSecret(ctx, id, "SECONDSECRET")
+	assert.Nil(t, err)
+	totp, err = db.GetTOTP(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, &models.TOTP{UserID: id, Secret: "SECONDSECRET"}, totp)
+	err = db.UseTOTPStep(ctx, id, 100)
+	assert.ErrorIs(t, err, models.ErrInvalidMFACode)
+
+	err = db.EnableTOTP(ctx, id, 100, []string{"hash1", "hash2"})
+	assert.Nil(t, err)
+	err = db.EnableTOTP(ctx, id, 100, []string{"hash3"})
+	assert.ErrorIs(t, err, models.ErrMFAAlreadyEnabled)
+	err = db.SaveTOTPSecret(ctx, id, "THIRDSECRET")
+	assert.ErrorIs(t, err, models.ErrMFAAlreadyEnabled)
+	totp, err = db.GetTOTP(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, &models.TOTP{UserID: id, Secret: "SECONDSECRET", Enabled: true, LastStep: 100}, totp)
+
+	//Код того же или более раннего периода не принимается повторно
+	err = db.UseTOTPStep(ctx, id, 100)
+	assert.ErrorIs(t, err, models.ErrInvalidMFACode)
+	err = db.UseTOTPStep(ctx, id, 101)
+	assert.Nil(t, err)
+
+	//Код восстановления одноразовый
+	err = db.UseRecoveryCode(ctx, id, "hash1")
+	assert.Nil(t, err)
+	err = db.UseRecoveryCode(ctx, id, "hash1")
+	assert.ErrorIs(t, err, models.ErrInvalidMFACode)
+	err = db.UseRecoveryCode(ctx, id, "hash3")
+	assert.ErrorIs(t, err, models.ErrInvalidMFACode)
+	err = db.UseRecoveryCode(ctx, id, "hash2")
+	assert.Nil(t, err)
+}
+
+func TestDB_APIKeys(t *testing.T) {
+	ctx := context.Background()
+	log := mocks.NewLogger(t)
+	log.On("DebugMsg", "connection to DB is OK").Return()
+	db, _ := NewClient(ctx, &testCfg, log)
+	defer db.dbConnect.Close(ctx)
+	createdBy := uuid.MustParse(userUUID)
+	createdAt := time.Now().Truncate(time.Microsecond)
+	expiresAt := createdAt.Add(time.Hour)
+	key := models.APIKey{
+		ID:          uuid.New(),
+		Name:        "importer",
+		Prefix:      "vkm_test0001",
+		Hash:        "api key hash",
+		Permissions: []models.Permission{models.PermMovieRead, models.PermMovieWrite},
+		CreatedBy:   &createdBy,
+		CreatedAt:   createdAt,
+		ExpiresAt:   &expiresAt,
+	}
+	err := db.CreateAPIKey(ctx, key)
+	assert.Nil(t, err)
+
+	result, err := db.GetAPIKeyByHash(ctx, key.Hash)
+	assert.Nil(t, err)
+	assert.Equal(t, key.ID, result.ID)
+	assert.Equal(t, key.Permissions, result.Permissions)
+	assert.Equal(t, key.CreatedBy, result.CreatedBy)
+	assert.False(t, result.CreatorDisabled)
+	creatorPermissions, err := db.GetUserPermissions(ctx, createdBy)
+	assert.Nil(t, err)
+	assert.Equal(t, creatorPermissions, result.CreatorPermissions)
+	assert.True(t, expiresAt.Equal(*result.ExpiresAt))
+	assert.Nil(t, result.LastUsedAt)
+	_, err = db.GetAPIKeyByHash(ctx, "unknown hash")
+	assert.ErrorIs(t, err, models.ErrAPIKeyNotFound)
+
+	//Время использования не обновляется чаще, чем задано
+	err = db.TouchAPIKey(ctx, key.ID, createdAt, createdAt.Add(-time.Minute))
+	assert.Nil(t, err)
+	err = db.TouchAPIKey(ctx, key.ID, createdAt.Add(time.Second), createdAt.Add(-time.Minute))
+	assert.Nil(t, err)
+	result, err = db.GetAPIKeyByHash(ctx, key.Hash)
+	assert.Nil(t, err)
+	assert.True(t, createdAt.Equal(*result.LastUsedAt))
+
+	//Блокировка создателя видна вместе с ключом
+	err = db.SetUserDisabled(ctx, createdBy, true)
+	assert.Nil(t, err)
+	result, err = db.GetAPIKeyByHash(ctx, key.Hash)
+	assert.Nil(t, err)
+	assert.True(t, result.CreatorDisabled)
+	err = db.SetUserDisabled(ctx, createdBy, false)
+	assert.Nil(t, err)
+
+	err = db.RevokeAPIKey(ctx, key.ID)
+	assert.Nil(t, err)
+	err = db.RevokeAPIKey(ctx, uuid.New())
+	assert.ErrorIs(t, err, models.ErrAPIKeyNotFound)
+	list, err := db.GetAPIKeyList(ctx)
+	assert.Nil(t, err)
+	require.NotEmpty(t, list)
+	assert.Equal(t, key.ID, list[0].ID)
+	assert.NotNil(t, list[0].RevokedAt)
+}
+
+func TestDB_OIDC(t *testing.T) {
+	ctx := context.Background()
+	log := mocks.NewLogger(t)
+	log.On("DebugMsg", "connection to DB is OK").Return()
+	db, _ := NewClient(ctx, &testCfg, log)
+	defer db.dbConnect.Close(ctx)
+	now := time.Now().Truncate(time.Microsecond)
+
+	//state принимается только один раз и только до истечения
+	state := models.OIDCState{Hash: "state hash", Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: now.Add(time.Minute)}
+	err := db.CreateOIDCState(ctx, state)
+	assert.Nil(t, err)
+	err = db.CreateOIDCState(ctx, models.OIDCState{Hash: "expired hash", Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: now})
+	assert.Nil(t, err)
+	result, err := db.ConsumeOIDCState(ctx, state.Hash, now)
+	assert.Nil(t, err)
+	assert.Equal(t, state.Nonce, result.Nonce)
+	assert.Equal(t, state.CodeVerifier, result.CodeVerifier)
+	_, err = db.ConsumeOIDCState(ctx, state.Hash, now)
+	assert.ErrorIs(t, err, models.ErrInvalidOIDCState)
+	_, err = db.ConsumeOIDCState(ctx, "expired hash", now)
+	assert.ErrorIs(t, err, models.ErrInvalidOIDCState)
+
+	issuer := "https://idp.example.com"
+	_, _, err = db.GetUserByIdentity(ctx, issuer, "subject-1")
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+
+	id := uuid.New()
+	user := models.User{Email: "oidcuser@mail.com", PasswordAlgo: models.PasswordAlgoNone, Roles: []string{models.RoleUser}}
+	err = db.CreateOIDCUser(ctx, id, user, issuer, "subject-1")
+	assert.Nil(t, err)
+	err = db.CreateOIDCUser(ctx, uuid.New(), models.User{Email: "other@mail.com"}, issuer, "subject-1")
+	assert.ErrorIs(t, err, models.ErrUserExists)
+	created, provisioned, err := db.GetUserByIdentity(ctx, issuer, "subject-1")
+	assert.Nil(t, err)
+	assert.True(t, provisioned)
+	assert.Equal(t, id, created.ID)
+	assert.Equal(t, []string{models.RoleUser}, created.Roles)
+	assert.False(t, created.CheckCreds(user.Email, ""))
+
+	//Пользователь связывается только с одной учетной записью провайдера
+	err = db.LinkUserIdentity(ctx, uuid.MustParse(userUUID), issuer, "subject-2")
+	assert.Nil(t, err)
+	err = db.LinkUserIdentity(ctx, uuid.MustParse(userUUID), issuer, "subject-3")
+	assert.ErrorIs(t, err, models.ErrOIDCEmailConflict)
+	linked, provisioned, err := db.GetUserByIdentity(ctx, issuer, "subject-2")
+	assert.Nil(t, err)
+	assert.False(t, provisioned)
+	assert.Equal(t, userEmail, linked.Email)
+
+	err = db.DeleteUser(ctx, id)
+	assert.Nil(t, err)
+	_, _, err = db.GetUserByIdentity(ctx, issuer, "subject-1")
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+}
+
+func TestDB_PasswordReset(t *testing.T) {
+	ctx := context.Background()
+	log := mocks.NewLogger(t)
+	log.On("DebugMsg", "connection to DB is OK").Return()
+	db, _ := NewClient(ctx, &testCfg, log)
+	defer db.dbConnect.Close(ctx)
+	now := time.Now().Truncate(time.Microsecond)
+	id := uuid.New()
+	err := db.CreateUser(ctx, id, models.User{Email: "resetuser@mail.com", Password: "old", Roles: []string{models.RoleUser}})
+	require.Nil(t, err)
+	defer db.DeleteUser(ctx, id)
+
+	token := models.PasswordResetToken{Hash: "reset hash 1", UserID: id, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	err = db.CreatePasswordResetToken(ctx, token, now.Add(-time.Minute))
+	assert.Nil(t, err)
+	//Повторный запрос раньше resend_interval
+	err = db.CreatePasswordResetToken(ctx, models.PasswordResetToken{Hash: "reset hash 2", UserID: id, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}, now.Add(-time.Minute))
+	assert.ErrorIs(t, err, models.ErrPasswordResetThrottled)
+	//Новый токен заменяет предыдущий
+	err = db.CreatePasswordResetToken(ctx, models.PasswordResetToken{Hash: "reset hash 2", UserID: id, CreatedAt: now.Add(time.Minute), ExpiresAt: now.Add(time.Hour)}, now.Add(time.Second))
+	assert.Nil(t, err)
+	_, _, err = db.ResetPasswordByToken(ctx, "reset hash 1", now, "new", models.PasswordAlgoPlain)
+	assert.ErrorIs(t, err, models.ErrInvalidResetToken)
+	_, _, err = db.ResetPasswordByToken(ctx, "reset hash 2", now.Add(2*time.Hour), "new", models.PasswordAlgoPlain)
+	assert.ErrorIs(t, err, models.ErrInvalidResetToken)
+
+	resetID, email, err := db.ResetPasswordByToken(ctx, "reset hash 2", now, "new", models.PasswordAlgoPlain)
+	assert.Nil(t, err)
+	assert.Equal(t, id, resetID)
+	assert.Equal(t, "resetuser@mail.com", email)
+	user, err := db.GetUserByUUID(ctx, id)
+	assert.Nil(t, err)
+	assert.True(t, user.CheckCreds(email, "new"))
+	revokedAt, err := db.GetUserTokensRevokedAt(ctx, id)
+	assert.Nil(t, err)
+	assert.NotNil(t, revokedAt)
+	//Токен одноразовый
+	_, _, err = db.ResetPasswordByToken(ctx, "reset hash 2", now, "newer", models.PasswordAlgoPlain)
+	assert.ErrorIs(t, err, models.ErrInvalidResetToken)
 }
 
 func TestDB_CreateMovie(t *testing.T) {
@@ -245,14 +802,23 @@ func TestDB_CreateMovie(t *testing.T) {
 
 	for _, test := range testTable {
 		if test.name == "positive" {
-			err := db.CreateActor(ctx, idActor, testActor)
+			_, err := db.CreateActor(ctx, idActor, testActor)
 			assert.Nil(t, err)
-			err = db.CreateMovie(ctx, test.id, test.testMovie)
+			result, err := db.CreateMovie(ctx, test.id, test.testMovie)
 			assert.Nil(t, err)
+			resetTimestamps(t, &result.CreatedAt, &result.UpdatedAt)
+			assert.Equal(t, &models.MovieDetails{
+				ID:          test.id,
+				Name:        "test_movie_1",
+				Description: "Description 1",
+				ReleaseDate: GetDate("2002-01-01"),
+				Rating:      6.2,
+				Cast:        []models.CastMember{{ID: &idActor, Name: "test_actor_1"}, {Name: "test_actor_2"}},
+			}, result)
 			err = db.DeleteActor(ctx, idActor)
 			assert.Nil(t, err)
 		} else {
-			err := db.CreateMovie(ctx, test.id, test.testMovie)
+			_, err := db.CreateMovie(ctx, test.id, test.testMovie)
 			assert.NotNil(t, err)
 		}
 	}
@@ -278,7 +844,7 @@ func TestDB_UpdateMovie(t *testing.T) {
 		Rating:      9.2,
 		ActorList:   []string{"test_actor_1", "test_actor_3"},
 	}
-	err := db.CreateActor(ctx, idActor, testActor)
+	_, err := db.CreateActor(ctx, idActor, testActor)
 	assert.Nil(t, err)
 	err = db.UpdateMovie(ctx, id, testMovie)
 	assert.Nil(t, err, "")
@@ -292,19 +858,25 @@ func TestDB_GetMovieByUUID(t *testing.T) {
 	db, _ := NewClient(ctx, &testCfg, log)
 	defer db.dbConnect.Close(ctx)
 	id := uuid.MustParse(newMovieUUID)
-	expected := &models.Movie{
+	idActor := uuid.MustParse(newActorUUID)
+	expected := &models.MovieDetails{
+		ID:          id,
 		Name:        "test_movie_1",
 		Description: "Description 2",
 		ReleaseDate: GetDate("2001-01-01"),
 		Rating:      9.2,
-		ActorList:   []string{"test_actor_1", "test_actor_3"},
+		Cast:        []models.CastMember{{ID: &idActor, Name: "test_actor_1"}, {Name: "test_actor_3"}},
 	}
 	result, err := db.GetMovieByUUID(ctx, id)
+	require.Nil(t, err)
+	assert.True(t, result.UpdatedAt.After(result.CreatedAt))
+	resetTimestamps(t, &result.CreatedAt, &result.UpdatedAt)
 	assert.Equal(t, expected, result)
-	assert.Nil(t, err)
-	idActor := uuid.MustParse(newActorUUID)
 	err = db.DeleteActor(ctx, idActor)
 	assert.Nil(t, err)
+	//not found
+	_, err = db.GetMovieByUUID(ctx, uuid.MustParse("f44d4a8f-7f16-4c1d-836b-02e0b8de4a01"))
+	assert.ErrorIs(t, err, models.ErrMovieNotFound)
 }
 
 func TestDB_DeleteMovie(t *testing.T) {
@@ -316,6 +888,8 @@ func TestDB_DeleteMovie(t *testing.T) {
 	id := uuid.MustParse(newMovieUUID)
 	err := db.DeleteMovie(ctx, id)
 	assert.Nil(t, err)
+	err = db.DeleteMovie(ctx, id)
+	assert.ErrorIs(t, err, models.ErrMovieNotFound)
 }
 
 func TestDB_GetMovieList(t *testing.T) {
@@ -324,10 +898,10 @@ func TestDB_GetMovieList(t *testing.T) {
 	log.On("DebugMsg", "connection to DB is OK").Return()
 	db, _ := NewClient(ctx, &testCfg, log)
 	defer db.dbConnect.Close(ctx)
-	testSort := "release_date"
-	testLine := "asc"
+	testSort := models.SortSpec{{Field: "release_date"}}
 	expected := []*models.Movie{
 		{
+			ID:          uuid.MustParse("43a4b2df-8ff8-4d32-9203-0367c2714431"),
 			Name:        "Pretty Woman",
 			Description: "Description 4",
 			ReleaseDate: time.Date(1990, 3, 23, 0, 0, 0, 0, time.UTC),
@@ -335,6 +909,7 @@ func TestDB_GetMovieList(t *testing.T) {
 			ActorList:   []string{"Cate Blanchett", "Tom Hanks"},
 		},
 		{
+			ID:          uuid.MustParse("20648636-b14e-4f88-a02a-8c4e3c2f534d"),
 			Name:        "Forrest Gump",
 			Description: "Description 1",
 			ReleaseDate: time.Date(1994, 7, 6, 0, 0, 0, 0, time.UTC),
@@ -342,6 +917,7 @@ func TestDB_GetMovieList(t *testing.T) {
 			ActorList:   []string{"Julia Roberts", "Tom Hanks"},
 		},
 		{
+			ID:          uuid.MustParse("a5e85bc9-5e75-4e5f-b890-0b156e15e40f"),
 			Name:        "Training Day",
 			Description: "Description 3",
 			ReleaseDate: time.Date(2001, 10, 5, 0, 0, 0, 0, time.UTC),
@@ -349,6 +925,7 @@ func TestDB_GetMovieList(t *testing.T) {
 			ActorList:   []string{"Leonardo DiCaprio", "Tom Hanks"},
 		},
 		{
+			ID:          uuid.MustParse("f44d4a8f-7f16-4c1d-836b-02e0b8de4a99"),
 			Name:        "The Devil Wears Prada",
 			Description: "Description 2",
 			ReleaseDate: time.Date(2006, 6, 30, 0, 0, 0, 0, time.UTC),
@@ -356,6 +933,7 @@ func TestDB_GetMovieList(t *testing.T) {
 			ActorList:   []string{"Brad Pitt", "Julia Roberts"},
 		},
 		{
+			ID:          uuid.MustParse("b19069b7-7296-4e67-a5f2-80c6e01a32d0"),
 			Name:        "Inception",
 			Description: "Description 5",
 			ReleaseDate: time.Date(2010, 7, 16, 0, 0, 0, 0, time.UTC),
@@ -364,9 +942,256 @@ func TestDB_GetMovieList(t *testing.T) {
 		},
 	}
 
-	result, err := db.GetMovieList(ctx, testSort, testLine)
+	result, total, err := db.GetMovieList(ctx, models.MovieFilter{}, testSort, nil, 10, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, total)
+	for _, val := range result {
+		resetTimestamps(t, &val.CreatedAt, &val.UpdatedAt)
+	}
+	assert.Equal(t, expected, result)
+
+	result, total, err = db.GetMovieList(ctx, models.MovieFilter{}, testSort, nil, 2, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, total)
+	for _, val := range result {
+		resetTimestamps(t, &val.CreatedAt, &val.UpdatedAt)
+	}
+	assert.Equal(t, expected[4:], result)
+
+	//без limit выдаются все фильмы
+	result, total, err = db.GetMovieList(ctx, models.MovieFilter{}, testSort, nil, 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, total)
+	for _, val := range result {
+		resetTimestamps(t, &val.CreatedAt, &val.UpdatedAt)
+	}
 	assert.Equal(t, expected, result)
+
+	after := &models.Cursor{Values: []string{"1994-07-06T00:00:00Z"}, ID: expected[1].ID}
+	result, _, err = db.GetMovieList(ctx, models.MovieFilter{}, testSort, after, 2, 0)
 	assert.Nil(t, err)
+	for _, val := range result {
+		resetTimestamps(t, &val.CreatedAt, &val.UpdatedAt)
+	}
+	assert.Equal(t, expected[2:4], result)
+
+	//у Inception и Forrest Gump одинаковый рейтинг, порядок задается UUID
+	after = &models.Cursor{Values: []string{"8.8"}, ID: expected[1].ID}
+	result, _, err = db.GetMovieList(ctx, models.MovieFilter{}, models.SortSpec{{Field: "rating", Desc: true}}, after, 1, 0)
+	assert.Nil(t, err)
+	for _, val := range result {
+		resetTimestamps(t, &val.CreatedAt, &val.UpdatedAt)
+	}
+	assert.Equal(t, expected[4:], result)
+
+	//поля с разными направлениями: рейтинг по убыванию, название по возрастанию
+	sort := models.SortSpec{{Field: "rating", Desc: true}, {Field: "name"}}
+	after = &models.Cursor{Values: []string{"8.8", "Forrest Gump"}, ID: expected[1].ID}
+	result, _, err = db.GetMovieList(ctx, models.MovieFilter{}, sort, after, 2, 0)
+	assert.Nil(t, err)
+	for _, val := range result {
+		resetTimestamps(t, &val.CreatedAt, &val.UpdatedAt)
+	}
+	assert.Equal(t, []*models.Movie{expected[4], expected[2]}, result)
+
+	_, _, err = db.GetMovieList(ctx, models.MovieFilter{}, models.SortSpec{{Field: "description"}}, nil, 10, 0)
+	assert.ErrorIs(t, err, models.ErrInvalidSort)
+	_, _, err = db.GetMovieList(ctx, models.MovieFilter{}, sort, &models.Cursor{Values: []string{"8.8"}, ID: expected[1].ID}, 10, 0)
+	assert.ErrorIs(t, err, models.ErrInvalidCursor)
+}
+
+func TestDB_GetMovieListFilter(t *testing.T) {
+	ctx := context.Background()
+	log := mocks.NewLogger(t)
+	log.On("DebugMsg", "connection to DB is OK").Return()
+	db, _ := NewClient(ctx, &testCfg, log)
+	defer db.dbConnect.Close(ctx)
+	date := func(s string) *time.Time {
+		result := GetDate(s)
+		return &result
+	}
+	rating := func(r float64) *float64 {
+		return &r
+	}
+	tomHanks := uuid.MustParse("b0482c7a-1a4c-4a3c-9463-35f0036a0d60")
+	juliaRoberts := uuid.MustParse("7cfd7e7f-3a27-46c5-9c3e-3b3d3b1c1416")
+	leonardoDiCaprio := uuid.MustParse("eb1b5f32-82c3-4dfb-aad2-9432908d12b7")
+	sort := models.SortSpec{{Field: "name"}}
+
+	testTable := []struct {
+		name     string
+		filter   models.MovieFilter
+		limit    int
+		expected []string
+		total    int
+	}{
+		{
+			name:     "release date range includes both ends",
+			filter:   models.MovieFilter{ReleasedFrom: date("1994-07-06"), ReleasedTo: date("2006-06-30")},
+			expected: []string{"Forrest Gump", "The Devil Wears Prada", "Training Day"},
+			total:    3,
+		},
+		{
+			name:     "rating range",
+			filter:   models.MovieFilter{RatingMin: rating(7.7), RatingMax: rating(8.8)},
+			expected: []string{"Forrest Gump", "Inception", "Training Day"},
+			total:    3,
+		},
+		{
+			name:     "any of actors",
+			filter:   models.MovieFilter{ActorIDs: []uuid.UUID{juliaRoberts, leonardoDiCaprio}, ActorMatch: models.ActorMatchAny},
+			expected: []string{"Forrest Gump", "The Devil Wears Prada", "Training Day"},
+			total:    3,
+		},
+		{
+			name:     "all of actors",
+			filter:   models.MovieFilter{ActorIDs: []uuid.UUID{tomHanks, juliaRoberts}, ActorMatch: models.ActorMatchAll},
+			expected: []string{"Forrest Gump"},
+			total:    1,
+		},
+		{
+			name:     "actor name contains ignoring case",
+			filter:   models.MovieFilter{ActorNameContains: "ROBERTS"},
+			expected: []string{"Forrest Gump", "The Devil Wears Prada"},
+			total:    2,
+		},
+		{
+			name:     "name contains ignoring case",
+			filter:   models.MovieFilter{NameContains: "IN"},
+			expected: []string{"Inception", "Training Day"},
+			total:    2,
+		},
+		{
+			name:     "like wildcards are matched as is",
+			filter:   models.MovieFilter{NameContains: "%"},
+			expected: []string{},
+			total:    0,
+		},
+		{
+			name: "combined with pagination",
+			filter: models.MovieFilter{RatingMin: rating(7), ReleasedTo: date("2000-12-31"),
+				ActorIDs: []uuid.UUID{tomHanks}, ActorMatch: models.ActorMatchAny},
+			limit:    1,
+			expected: []string{"Forrest Gump"},
+			total:    2,
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := tt.limit
+			if limit == 0 {
+				limit = 10
+			}
+			result, total, err := db.GetMovieList(ctx, tt.filter, sort, nil, limit, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.total, total)
+			names := make([]string, 0, len(result))
+			for _, val := range result {
+				names = append(names, val.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+
+	//курсор и условия отбора применяются вместе
+	filter := models.MovieFilter{ActorIDs: []uuid.UUID{tomHanks}, ActorMatch: models.ActorMatchAny}
+	after := &models.Cursor{Values: []string{"Forrest Gump"}, ID: uuid.MustParse("20648636-b14e-4f88-a02a-8c4e3c2f534d")}
+	result, total, err := db.GetMovieList(ctx, filter, sort, after, 10, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "Pretty Woman", result[0].Name)
+	assert.Equal(t, "Training Day", result[1].Name)
+}
+
+func TestQueryBuilder(t *testing.T) {
+	var q queryBuilder
+	assert.Equal(t, "", q.whereClause())
+
+	from := GetDate("1990-01-01")
+	movieFilter(&q, models.MovieFilter{ReleasedFrom: &from, ActorNameContains: "hanks", NameContains: `50%_off\`})
+	order, err := movieSortColumns.order(models.SortSpec{{Field: "rating", Desc: true}, {Field: "name"}})
+	assert.Nil(t, err)
+	id := uuid.MustParse("20648636-b14e-4f88-a02a-8c4e3c2f534d")
+	err = order.after(&q, &models.Cursor{Values: []string{"8.8", "Forrest Gump"}, ID: id})
+	assert.Nil(t, err)
+	limit := q.arg(10)
+
+	rating := "NULLIF($4::text, '')::float8"
+	assert.Equal(t, "WHERE (release_date >= $1) AND (uuid IN (SELECT movie_uuid FROM movie_actors WHERE actor_name ILIKE $2)) AND (name ILIKE $3) AND "+
+		"(((rating < "+rating+" OR rating IS NULL AND "+rating+" IS NOT NULL)) OR "+
+		"(rating IS NOT DISTINCT FROM "+rating+" AND name > $5::text::varchar) OR "+
+		"(rating IS NOT DISTINCT FROM "+rating+" AND name = $5::text::varchar AND uuid > $6))", q.whereClause())
+	assert.Equal(t, "$7", limit)
+	assert.Equal(t, []interface{}{from, "%hanks%", `%50\%\_off\\%`, "8.8", "Forrest Gump", id, 10}, q.args)
+	assert.Equal(t, "page.rating DESC NULLS LAST, page.name ASC, page.uuid ASC", order.orderBy("page."))
+
+	//фильм без даты выхода: после него идут только фильмы без даты с большим UUID
+	q = queryBuilder{}
+	order, err = movieSortColumns.order(models.SortSpec{{Field: "release_date"}})
+	assert.Nil(t, err)
+	err = order.after(&q, &models.Cursor{Values: []string{""}, ID: id})
+	assert.Nil(t, err)
+	date := "NULLIF($1::text, '')::timestamp"
+	assert.Equal(t, "WHERE (((release_date > "+date+" OR release_date IS NULL AND "+date+" IS NOT NULL)) OR "+
+		"(release_date IS NOT DISTINCT FROM "+date+" AND uuid > $2))", q.whereClause())
+	assert.Equal(t, "release_date ASC NULLS LAST, uuid ASC", order.orderBy(""))
+	assert.Equal(t, "", q.limit(0, 0))
+}
+
+func TestDB_GetMovieListQuery(t *testing.T) {
+	ctx := context.Background()
+	log := mocks.NewLogger(t)
+	log.On("DebugMsg", "connection to DB is OK").Return()
+	db, _ := NewClient(ctx, &testCfg, log)
+	defer db.dbConnect.Close(ctx)
+	rankSort := models.SortSpec{{Field: "rank", Desc: true}, {Field: "name"}}
+
+	//Слово находится в другой форме, фрагмент описания выделен
+	result, total, err := db.GetMovieList(ctx, models.MovieFilter{Query: "descriptions"}, rankSort, nil, 10, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, total)
+	require.Len(t, result, 5)
+	assert.Equal(t, "Forrest Gump", result[0].Name)
+	assert.Equal(t, "<mark>Description</mark> 1", result[0].Headline)
+	require.NotNil(t, result[0].Rank)
+	assert.Greater(t, *result[0].Rank, 0.0)
+
+	//Совпадение в названии весит больше, чем в описании, исключенное слово отбрасывает фильм
+	result, total, err = db.GetMovieList(ctx, models.MovieFilter{Query: "inception or description -prada"}, rankSort, nil, 10, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, total)
+	require.Len(t, result, 4)
+	assert.Equal(t, "Inception", result[0].Name)
+	assert.Greater(t, *result[0].Rank, *result[1].Rank)
+
+	//Страница по курсору с сортировкой по релевантности
+	after := &models.Cursor{Values: []string{strconv.FormatFloat(*result[0].Rank, 'g', -1, 64), result[0].Name}, ID: result[0].ID}
+	page, total, err := db.GetMovieList(ctx, models.MovieFilter{Query: "inception or description -prada"}, rankSort, after, 10, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, total)
+	assert.Equal(t, result[1:], page)
+
+	//Запрос сочетается с условиями отбора и другой сортировкой
+	rating := 8.0
+	result, total, err = db.GetMovieList(ctx, models.MovieFilter{Query: "description", RatingMin: &rating}, models.SortSpec{{Field: "name"}}, nil, 10, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, result, 2)
+	assert.Equal(t, "Forrest Gump", result[0].Name)
+	assert.Equal(t, "Inception", result[1].Name)
+}
+
+func TestMarkHeadline(t *testing.T) {
+	var q queryBuilder
+	textQuery := movieFilter(&q, models.MovieFilter{Query: "sad dog"})
+	assert.Equal(t, "(websearch_to_tsquery('russian', $1) || websearch_to_tsquery('english', $1))", textQuery)
+	assert.Equal(t, "WHERE (search_vector @@ "+textQuery+")", q.whereClause())
+	assert.Equal(t, []interface{}{"sad dog"}, q.args)
+	assert.Equal(t, "", movieFilter(&queryBuilder{}, models.MovieFilter{}))
+
+	assert.Equal(t, "a &lt;b&gt; <mark>sad</mark> &amp; <mark>dog</mark> ...",
+		markHeadline("a <b> "+headlineStart+"sad"+headlineStop+" & "+headlineStart+"dog"+headlineStop+" ..."))
 }
 
 func TestDB_GetMovie(t *testing.T) {
@@ -379,6 +1204,7 @@ func TestDB_GetMovie(t *testing.T) {
 	testMovie := "%%"
 	expected := []*models.Movie{
 		{
+			ID:          uuid.MustParse("20648636-b14e-4f88-a02a-8c4e3c2f534d"),
 			Name:        "Forrest Gump",
 			Description: "Description 1",
 			ReleaseDate: time.Date(1994, 7, 6, 0, 0, 0, 0, time.UTC),
@@ -386,6 +1212,7 @@ func TestDB_GetMovie(t *testing.T) {
 			ActorList:   []string{"Julia Roberts"},
 		},
 		{
+			ID:          uuid.MustParse("f44d4a8f-7f16-4c1d-836b-02e0b8de4a99"),
 			Name:        "The Devil Wears Prada",
 			Description: "Description 2",
 			ReleaseDate: time.Date(2006, 6, 30, 0, 0, 0, 0, time.UTC),
@@ -394,6 +1221,77 @@ func TestDB_GetMovie(t *testing.T) {
 		},
 	}
 	result, err := db.GetMovie(ctx, testActor, testMovie)
+	assert.Nil(t, err)
+	for _, val := range result {
+		resetTimestamps(t, &val.CreatedAt, &val.UpdatedAt)
+	}
 	assert.Equal(t, expected, result)
+}
+
+func TestDB_Search(t *testing.T) {
+	ctx := context.Background()
+	log := mocks.NewLogger(t)
+	log.On("DebugMsg", "connection to DB is OK").Return()
+	db, _ := NewClient(ctx, &testCfg, log)
+	defer db.dbConnect.Close(ctx)
+	allKinds := []string{models.SearchKindMovie, models.SearchKindActor}
+
+	//Опечатка в запросе
+	result, err := db.Search(ctx, "inceptoin", allKinds, 10)
+	assert.Nil(t, err)
+	require.NotEmpty(t, result)
+	assert.Equal(t, models.SearchKindMovie, result[0].Kind)
+	assert.Equal(t, uuid.MustParse("b19069b7-7296-4e67-a5f2-80c6e01a32d0"), result[0].ID)
+	assert.Equal(t, "Inception", result[0].Name)
+
+	//Регистр не учитывается, точное совпадение выше остальных
+	result, err = db.Search(ctx, "tom hanks", allKinds, 10)
+	assert.Nil(t, err)
+	require.NotEmpty(t, result)
+	assert.Equal(t, models.SearchKindActor, result[0].Kind)
+	assert.Equal(t, "Tom Hanks", result[0].Name)
+	assert.Equal(t, 1.0, result[0].Score)
+
+	//Начало слова в названии
+	result, err = db.Search(ctx, "prad", []string{models.SearchKindMovie}, 10)
+	assert.Nil(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, "The Devil Wears Prada", result[0].Name)
+
+	//Ищутся только указанные виды
+	result, err = db.Search(ctx, "tom hanks", []string{models.SearchKindMovie}, 10)
+	assert.Nil(t, err)
+	assert.Empty(t, result)
+	result, err = db.Search(ctx, "tom hanks", nil, 10)
+	assert.Nil(t, err)
+	assert.Empty(t, result)
+}
+
+func TestDB_Suggest(t *testing.T) {
+	ctx := context.Background()
+	log := mocks.NewLogger(t)
+	log.On("DebugMsg", "connection to DB is OK").Return()
+	db, _ := NewClient(ctx, &testCfg, log)
+	defer db.dbConnect.Close(ctx)
+
+	result, err := db.Suggest(ctx, models.SearchKindActor, "tom", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []*models.Suggestion{{ID: uuid.MustParse("b0482c7a-1a4c-4a3c-9463-35f0036a0d60"), Name: "Tom Hanks"}}, result)
+
+	//Совпадение только с начала названия, фильмы упорядочены по названию
+	result, err = db.Suggest(ctx, models.SearchKindMovie, "t", 10)
+	assert.Nil(t, err)
+	names := make([]string, 0, len(result))
+	for _, val := range result {
+		names = append(names, val.Name)
+	}
+	assert.Equal(t, []string{"The Devil Wears Prada", "Training Day"}, names)
+
+	result, err = db.Suggest(ctx, models.SearchKindMovie, "t", 1)
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+
+	result, err = db.Suggest(ctx, models.SearchKindMovie, "hanks", 10)
 	assert.Nil(t, err)
+	assert.Empty(t, result)
 }
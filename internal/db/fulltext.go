@@ -0,0 +1,28 @@
+package db
+
+import (
+	"html"
+	"strings"
+)
+
+// Границы найденных слов во фрагментах ts_headline. Вместо тегов используются управляющие символы, чтобы экранировать
+// фрагмент для HTML и только после этого выделить слова тегом <mark>
+const (
+	headlineStart = "\x02"
+	headlineStop  = "\x03"
+)
+
+// headlineOptions - параметры ts_headline: до двух фрагментов описания по 10-30 слов
+var headlineOptions = "StartSel=" + headlineStart + ", StopSel=" + headlineStop + ", MaxFragments=2, MinWords=10, MaxWords=30"
+
+// movieTextQuery возвращает выражение tsquery для запроса в параметре ref. Запрос разбирается в конфигурациях russian
+// и english, как и search_vector, поэтому слово находится в любой из своих форм
+func movieTextQuery(ref string) string {
+	return "(websearch_to_tsquery('russian', " + ref + ") || websearch_to_tsquery('english', " + ref + "))"
+}
+
+// markHeadline экранирует фрагмент ts_headline для HTML и выделяет найденные слова тегом <mark>
+func markHeadline(s string) string {
+	s = html.EscapeString(s)
+	return strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>").Replace(s)
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// RegisterLoginFailure увеличивает счетчик неудачных попыток входа и возвращает его новое значение.
+// Если с предыдущей неудачной попытки прошло больше window, счетчик начинается заново
+func (db *DB) RegisterLoginFailure(ctx context.Context, kind, value string, now time.Time, window time.Duration) (int, error) {
+	var failures int
+	registerOrder := `
+	INSERT INTO login_attempts (kind, value, failures, last_failure_at)
+	VALUES ($1, $2, 1, $3)
+	ON CONFLICT (kind, value) DO UPDATE
+	SET failures = CASE
+	                   WHEN login_attempts.last_failure_at < $4 THEN 1
+	                   ELSE login_attempts.failures + 1
+	    END,
+	    last_failure_at = $3
+	RETURNING failures
+	`
+	err := db.dbConnect.QueryRow(ctx, registerOrder, kind, value, now, now.Add(-window)).Scan(&failures)
+	if err != nil {
+		return 0, err
+	}
+	return failures, nil
+}
+
+func (db *DB) LockLogin(ctx context.Context, kind, value string, until time.Time) error {
+	lockOrder := `
+	UPDATE login_attempts
+	SET locked_until = $3
+	WHERE kind = $1 AND value = $2
+	`
+	_, err := db.dbConnect.Exec(ctx, lockOrder, kind, value, until)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetLoginLockedUntil возвращает наиболее позднее время блокировки входа по email или IP, nil если блокировки нет
+func (db *DB) GetLoginLockedUntil(ctx context.Context, email, ip string, now time.Time) (*time.Time, error) {
+	var lockedUntil *time.Time
+	queryOrder := `
+	SELECT max(locked_until)
+	FROM login_attempts
+	WHERE ((kind = 'email' AND value = $1) OR (kind = 'ip' AND value = $2)) AND locked_until > $3
+	`
+	err := db.dbConnect.QueryRow(ctx, queryOrder, email, ip, now).Scan(&lockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	return lockedUntil, nil
+}
+
+func (db *DB) ResetLoginFailures(ctx context.Context, kind, value string) error {
+	deleteOrder := `
+	DELETE FROM login_attempts WHERE kind = $1 AND value = $2
+	`
+	_, err := db.dbConnect.Exec(ctx, deleteOrder, kind, value)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// GetTOTP возвращает секрет TOTP пользователя, nil если TOTP не настраивался
+func (db *DB) GetTOTP(ctx context.Context, userID uuid.UUID) (*models.TOTP, error) {
+	result := models.TOTP{}
+	queryOrder := `
+	SELECT user_uuid, secret, enabled, last_step
+	FROM user_totp WHERE user_uuid = $1
+	`
+	err := db.dbConnect.QueryRow(ctx, queryOrder, userID).Scan(&result.UserID, &result.Secret, &result.Enabled, &result.LastStep)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SaveTOTPSecret сохраняет новый неподтвержденный секрет, заменяя предыдущий неподтвержденный.
+// Подключенный TOTP не перезаписывается, в этом случае возвращается models.ErrMFAAlreadyEnabled
+func (db *DB) SaveTOTPSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	saveOrder := `
+	INSERT INTO user_totp (user_uuid, secret)
+	VALUES ($1, $2)
+	ON CONFLICT (user_uuid) DO UPDATE
+	SET secret = $2, last_step = 0, created_at = now()
+	WHERE NOT user_totp.enabled
+	`
+	tag, err := db.dbConnect.Exec(ctx, saveOrder, userID, secret)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableTOTP подтверждает секрет и заменяет коды восстановления пользователя
+func (db *DB) EnableTOTP(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	tx, err := db.dbConnect.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	enableOrder := `
+	UPDATE user_totp
+	SET enabled = true, last_step = $2
+	WHERE user_uuid = $1 AND NOT enabled
+	`
+	tag, err := tx.Exec(ctx, enableOrder, userID, step)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrMFAAlreadyEnabled
+	}
+	deleteOrder := `
+	DELETE FROM recovery_codes WHERE user_uuid = $1
+	`
+	_, err = tx.Exec(ctx, deleteOrder, userID)
+	if err != nil {
+		return err
+	}
+	codeOrder := `
+	INSERT INTO recovery_codes (user_uuid, code_hash)
+	VALUES ($1, $2)
+	`
+	for _, hash := range recoveryCodeHashes {
+		_, err = tx.Exec(ctx, codeOrder, userID, hash)
+		if err != nil {
+			return err
+		}
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// UseTOTPStep запоминает период принятого кода. Если код этого или более позднего периода уже
+// был принят, возвращается models.ErrInvalidMFACode
+func (db *DB) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	updateOrder := `
+	UPDATE user_totp
+	SET last_step = $2
+	WHERE user_uuid = $1 AND enabled AND last_step < $2
+	`
+	tag, err := db.dbConnect.Exec(ctx, updateOrder, userID, step)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrInvalidMFACode
+	}
+	return nil
+}
+
+// UseRecoveryCode помечает код восстановления использованным, неизвестный или уже
+// использованный код возвращает models.ErrInvalidMFACode
+func (db *DB) UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) error {
+	updateOrder := `
+	UPDATE recovery_codes
+	SET used_at = now()
+	WHERE user_uuid = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+	tag, err := db.dbConnect.Exec(ctx, updateOrder, userID, hash)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrInvalidMFACode
+	}
+	return nil
+}
@@ -6,22 +6,37 @@ import (
 	"errors"
 	"github.com/ast3am/VKintern-movies/internal/models"
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
+	"time"
 )
 
-func (db *DB) CreateMovie(ctx context.Context, id uuid.UUID, movie models.Movie) error {
+// releaseDate - значение release_date для записи, фильм без даты выхода хранится с NULL
+func releaseDate(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// CreateMovie возвращает сохраненный фильм, прочитанный в той же транзакции
+func (db *DB) CreateMovie(ctx context.Context, id uuid.UUID, movie models.Movie) (*models.MovieDetails, error) {
 	tx, err := db.dbConnect.Begin(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer tx.Rollback(ctx)
 
 	createOrder := `
-	INSERT INTO movies values ($1, $2, $3, $4, $5)
+	INSERT INTO movies (uuid, name, description, release_date, rating) values ($1, $2, $3, $4, $5)
 	`
-	_, err = tx.Exec(ctx, createOrder, id, movie.Name, movie.Description, movie.ReleaseDate, movie.Rating)
+	_, err = tx.Exec(ctx, createOrder, id, movie.Name, movie.Description, releaseDate(movie.ReleaseDate), movie.Rating)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return nil, models.ErrMovieExists
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 	for _, val := range movie.ActorList {
 		updateFilmOrder := `
@@ -29,7 +44,7 @@ func (db *DB) CreateMovie(ctx context.Context, id uuid.UUID, movie models.Movie)
 		VALUES ($1, $2)`
 		_, err = tx.Exec(ctx, updateFilmOrder, id, val)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		var actorID string
 		selectActorOrder := `
@@ -38,7 +53,7 @@ func (db *DB) CreateMovie(ctx context.Context, id uuid.UUID, movie models.Movie)
 		if errors.Is(err, pgx.ErrNoRows) {
 			continue
 		} else if err != nil {
-			return err
+			return nil, err
 		}
 		actorUID, _ := uuid.Parse(actorID)
 		updateOrder := `
@@ -47,39 +62,63 @@ func (db *DB) CreateMovie(ctx context.Context, id uuid.UUID, movie models.Movie)
 		WHERE movie_uuid = $2 AND actor_name = $3`
 		_, err = tx.Exec(ctx, updateOrder, actorUID, id, val)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
+	result, err := getMovieByUUID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
 	err = tx.Commit(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return result, nil
+}
+
+// GetMovieByUUID возвращает фильм с составом актеров, LEFT JOIN нужен, чтобы найти фильм без актеров
+func (db *DB) GetMovieByUUID(ctx context.Context, id uuid.UUID) (*models.MovieDetails, error) {
+	return getMovieByUUID(ctx, db.dbConnect, id)
 }
 
-func (db *DB) GetMovieByUUID(ctx context.Context, id uuid.UUID) (*models.Movie, error) {
-	result := &models.Movie{}
+func getMovieByUUID(ctx context.Context, q querier, id uuid.UUID) (*models.MovieDetails, error) {
+	var result *models.MovieDetails
 	getMovieOrder := `
-	SELECT name, description, release_date, rating, actor_name FROM movies
-	JOIN movie_actors ma ON ma.movie_uuid = movies.uuid
+	SELECT movies.uuid, name, description, release_date, rating, created_at, updated_at, ma.actor_uuid, ma.actor_name
+	FROM movies
+	LEFT JOIN movie_actors ma ON ma.movie_uuid = movies.uuid
 	WHERE movies.uuid = $1
-	order by name`
-	rows, err := db.dbConnect.Query(ctx, getMovieOrder, id)
+	order by ma.actor_name`
+	rows, err := q.Query(ctx, getMovieOrder, id)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var timestamp sql.NullTime
 	for rows.Next() {
-		actorName := ""
-		err = rows.Scan(&result.Name, &result.Description, &timestamp, &result.Rating, &actorName)
+		movie := models.MovieDetails{Cast: make([]models.CastMember, 0)}
+		var timestamp sql.NullTime
+		var actorID *uuid.UUID
+		var actorName sql.NullString
+		err = rows.Scan(&movie.ID, &movie.Name, &movie.Description, &timestamp, &movie.Rating, &movie.CreatedAt, &movie.UpdatedAt,
+			&actorID, &actorName)
 		if err != nil {
 			return nil, err
 		}
-		if timestamp.Valid {
-			result.ReleaseDate = timestamp.Time
+		if result == nil {
+			if timestamp.Valid {
+				movie.ReleaseDate = timestamp.Time
+			}
+			result = &movie
 		}
-		result.ActorList = append(result.ActorList, actorName)
+		if actorName.Valid {
+			result.Cast = append(result.Cast, models.CastMember{ID: actorID, Name: actorName.String})
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, models.ErrMovieNotFound
 	}
 	return result, nil
 }
@@ -93,48 +132,54 @@ func (db *DB) UpdateMovie(ctx context.Context, id uuid.UUID, movie models.Movie)
 
 	updateMoviesOrder := `
 	UPDATE movies
-	SET name = $2, description = $3, release_date = $4, rating = $5
+	SET name = $2, description = $3, release_date = $4, rating = $5, updated_at = now()
 	WHERE uuid = $1
 	`
-	_, err = tx.Exec(ctx, updateMoviesOrder, id, movie.Name, movie.Description, movie.ReleaseDate, movie.Rating)
+	tag, err := tx.Exec(ctx, updateMoviesOrder, id, movie.Name, movie.Description, releaseDate(movie.ReleaseDate), movie.Rating)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return models.ErrMovieExists
+	}
 	if err != nil {
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrMovieNotFound
+	}
 
-	if len(movie.ActorList) != 0 {
-		deleteFilmOrder := `
-		DELETE FROM movie_actors WHERE movie_uuid = $1`
-		_, err = tx.Exec(ctx, deleteFilmOrder, id)
+	// список актеров заменяется целиком, при частичном изменении сервис передает текущий список
+	deleteFilmOrder := `
+	DELETE FROM movie_actors WHERE movie_uuid = $1`
+	_, err = tx.Exec(ctx, deleteFilmOrder, id)
+	if err != nil {
+		return err
+	}
+
+	for _, val := range movie.ActorList {
+		updateFilmOrder := `
+		INSERT INTO movie_actors (movie_uuid, actor_name)
+		VALUES ($1, $2)`
+		_, err = tx.Exec(ctx, updateFilmOrder, id, val)
 		if err != nil {
 			return err
 		}
-
-		for _, val := range movie.ActorList {
-			updateFilmOrder := `
-			INSERT INTO movie_actors (movie_uuid, actor_name)
-			VALUES ($1, $2)`
-			_, err = tx.Exec(ctx, updateFilmOrder, id, val)
-			if err != nil {
-				return err
-			}
-			var actorID string
-			selectActorOrder := `
-			SELECT uuid FROM actors WHERE actors.name = $1`
-			err = tx.QueryRow(ctx, selectActorOrder, val).Scan(&actorID)
-			if errors.Is(err, pgx.ErrNoRows) {
-				continue
-			} else if err != nil {
-				return err
-			}
-			actorUID, _ := uuid.Parse(actorID)
-			updateOrder := `
-			UPDATE movie_actors
-			SET actor_uuid = $1
-			WHERE movie_uuid = $2 AND actor_name = $3`
-			_, err = tx.Exec(ctx, updateOrder, actorUID, id, val)
-			if err != nil {
-				return err
-			}
+		var actorID string
+		selectActorOrder := `
+		SELECT uuid FROM actors WHERE actors.name = $1`
+		err = tx.QueryRow(ctx, selectActorOrder, val).Scan(&actorID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		actorUID, _ := uuid.Parse(actorID)
+		updateOrder := `
+		UPDATE movie_actors
+		SET actor_uuid = $1
+		WHERE movie_uuid = $2 AND actor_name = $3`
+		_, err = tx.Exec(ctx, updateOrder, actorUID, id, val)
+		if err != nil {
+			return err
 		}
 	}
 	err = tx.Commit(ctx)
@@ -153,10 +198,13 @@ func (db *DB) DeleteMovie(ctx context.Context, uid uuid.UUID) error {
 	deleteOrder := `
 	DELETE FROM movies WHERE uuid = $1
 	`
-	_, err = tx.Exec(ctx, deleteOrder, uid)
+	tag, err := tx.Exec(ctx, deleteOrder, uid)
 	if err != nil {
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrMovieNotFound
+	}
 	err = tx.Commit(ctx)
 	if err != nil {
 		return err
@@ -164,48 +212,141 @@ func (db *DB) DeleteMovie(ctx context.Context, uid uuid.UUID) error {
 	return nil
 }
 
-func (db *DB) GetMovieList(ctx context.Context, sortby, list string) ([]*models.Movie, error) {
-	result := make([]*models.Movie, 0)
-	getMovieOrder := `SELECT name, description, release_date, rating, actor_name FROM movies
-	JOIN movie_actors ma ON ma.movie_uuid = movies.uuid`
-	getMovieOrder += " order by " + sortby + " " + list + ", actor_name"
-	rows, err := db.dbConnect.Query(ctx, getMovieOrder)
+var movieSortColumns = sortColumns{
+	"name":         {"name", "varchar", false},
+	"rating":       {"rating", "float8", true},
+	"release_date": {"release_date", "timestamp", true},
+	"rank":         {"rank", "float8", false},
+}
+
+// movieFilter добавляет в q условия отбора фильмов и возвращает выражение tsquery полнотекстового запроса
+// или пустую строку, если запрос не задан
+func movieFilter(q *queryBuilder, filter models.MovieFilter) string {
+	if filter.ReleasedFrom != nil {
+		q.where("release_date >= ?", *filter.ReleasedFrom)
+	}
+	if filter.ReleasedTo != nil {
+		q.where("release_date < ?", filter.ReleasedTo.AddDate(0, 0, 1))
+	}
+	if filter.RatingMin != nil {
+		q.where("rating >= ?", *filter.RatingMin)
+	}
+	if filter.RatingMax != nil {
+		q.where("rating <= ?", *filter.RatingMax)
+	}
+	if len(filter.ActorIDs) > 0 {
+		ids := make([]string, 0, len(filter.ActorIDs))
+		for _, val := range filter.ActorIDs {
+			ids = append(ids, val.String())
+		}
+		if filter.ActorMatch == models.ActorMatchAll {
+			q.where(`uuid IN (SELECT movie_uuid FROM movie_actors WHERE actor_uuid = ANY(?::uuid[])
+				GROUP BY movie_uuid HAVING count(DISTINCT actor_uuid) = ?)`, ids, len(ids))
+		} else {
+			q.where("uuid IN (SELECT movie_uuid FROM movie_actors WHERE actor_uuid = ANY(?::uuid[]))", ids)
+		}
+	}
+	if filter.ActorNameContains != "" {
+		q.where("uuid IN (SELECT movie_uuid FROM movie_actors WHERE actor_name ILIKE ?)", containsPattern(filter.ActorNameContains))
+	}
+	if filter.NameContains != "" {
+		q.where("name ILIKE ?", containsPattern(filter.NameContains))
+	}
+	if filter.Query == "" {
+		return ""
+	}
+	textQuery := movieTextQuery(q.arg(filter.Query))
+	q.where("search_vector @@ " + textQuery)
+	return textQuery
+}
+
+// GetMovieList возвращает страницу фильмов, подходящих под filter, и общее количество таких фильмов. Страница
+// выбирается из movies до соединения с movie_actors, поэтому актеры фильма не делятся между страницами. При заданном
+// after выдаются фильмы после курсора, фильмы с равными значениями всех полей сортировки упорядочиваются по UUID.
+// С полнотекстовым запросом у фильмов заполняются Rank и Headline, фрагменты описания строятся только для страницы.
+// При limit 0 выдаются все подходящие фильмы
+func (db *DB) GetMovieList(ctx context.Context, filter models.MovieFilter, sort models.SortSpec, after *models.Cursor, limit, offset int) ([]*models.Movie, int, error) {
+	order, err := movieSortColumns.order(sort)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	var q queryBuilder
+	textQuery := movieFilter(&q, filter)
+	var total int
+	err = db.dbConnect.QueryRow(ctx, `SELECT count(*) FROM movies `+q.whereClause(), q.args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if after != nil {
+		err = order.after(&q, after)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	source, rank, headline := "movies", "", ""
+	if textQuery != "" {
+		source = "(SELECT *, ts_rank(search_vector, " + textQuery + ")::float8 AS rank FROM movies) AS movies"
+		rank = ", rank"
+		headline = ", page.rank, ts_headline('russian', coalesce(page.description, ''), " + textQuery + ", " + q.arg(headlineOptions) + ")"
+	}
+	getMovieOrder := `
+	WITH page AS (
+		SELECT uuid, name, description, release_date, rating, created_at, updated_at` + rank + ` FROM ` + source + `
+		` + q.whereClause() + `
+		ORDER BY ` + order.orderBy("") + `
+		` + q.limit(limit, offset) + `
+	)
+	SELECT page.uuid, page.name, page.description, page.release_date, page.rating, page.created_at, page.updated_at` + headline + `, ma.actor_name
+	FROM page
+	LEFT JOIN movie_actors ma ON ma.movie_uuid = page.uuid
+	ORDER BY ` + order.orderBy("page.") + `, ma.actor_name`
+	rows, err := db.dbConnect.Query(ctx, getMovieOrder, q.args...)
+	if err != nil {
+		return nil, 0, err
 	}
 	defer rows.Close()
-	var timestamp sql.NullTime
-	movieName := ""
-	i := 0
+	result := make([]*models.Movie, 0)
 	for rows.Next() {
-		actorName := ""
-		movieStruct := models.Movie{}
-		err = rows.Scan(&movieStruct.Name, &movieStruct.Description, &timestamp, &movieStruct.Rating, &actorName)
+		var timestamp sql.NullTime
+		var actorName sql.NullString
+		var movieHeadline string
+		movie := models.Movie{ActorList: make([]string, 0)}
+		dest := []interface{}{&movie.ID, &movie.Name, &movie.Description, &timestamp, &movie.Rating, &movie.CreatedAt, &movie.UpdatedAt}
+		if textQuery != "" {
+			dest = append(dest, &movie.Rank, &movieHeadline)
+		}
+		err = rows.Scan(append(dest, &actorName)...)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		if timestamp.Valid {
-			movieStruct.ReleaseDate = timestamp.Time
+			movie.ReleaseDate = timestamp.Time
 		}
-		if movieStruct.Name != movieName {
-			movieName = movieStruct.Name
-			movieStruct.ActorList = append(movieStruct.ActorList, actorName)
-			result = append(result, &movieStruct)
-			i++
-			continue
-		} else {
-			result[i-1].ActorList = append(result[i-1].ActorList, actorName)
+		if textQuery != "" {
+			movie.Headline = markHeadline(movieHeadline)
+		}
+		if len(result) == 0 || result[len(result)-1].ID != movie.ID {
+			result = append(result, &movie)
+		}
+		if actorName.Valid {
+			last := result[len(result)-1]
+			last.ActorList = append(last.ActorList, actorName.String)
 		}
 	}
-	return result, nil
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return result, total, nil
 }
 
 func (db *DB) GetMovie(ctx context.Context, actor, movie string) ([]*models.Movie, error) {
 	result := make([]*models.Movie, 0)
 	getMovieOrder := `
-	SELECT name, description, release_date, rating, actor_name FROM movies
+	SELECT movies.uuid, name, description, release_date, rating, created_at, updated_at, actor_name FROM movies
 	JOIN movie_actors ma ON ma.movie_uuid = movies.uuid
-	WHERE movies.name LIKE $1 AND ma.actor_name LIKE $2
+	WHERE movies.name ILIKE $1 AND ma.actor_name ILIKE $2
 	order by name`
 	rows, err := db.dbConnect.Query(ctx, getMovieOrder, movie, actor)
 	if err != nil {
@@ -218,7 +359,8 @@ func (db *DB) GetMovie(ctx context.Context, actor, movie string) ([]*models.Movi
 	for rows.Next() {
 		actorName := ""
 		movieStruct := models.Movie{}
-		err = rows.Scan(&movieStruct.Name, &movieStruct.Description, &timestamp, &movieStruct.Rating, &actorName)
+		err = rows.Scan(&movieStruct.ID, &movieStruct.Name, &movieStruct.Description, &timestamp, &movieStruct.Rating,
+			&movieStruct.CreatedAt, &movieStruct.UpdatedAt, &actorName)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+func (db *DB) CreateOIDCState(ctx context.Context, state models.OIDCState) error {
+	createOrder := `
+	INSERT INTO oidc_states (state_hash, nonce, code_verifier, expires_at)
+	VALUES ($1, $2, $3, $4)
+	`
+	_, err := db.dbConnect.Exec(ctx, createOrder, state.Hash, state.Nonce, state.CodeVerifier, state.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ConsumeOIDCState удаляет state и возвращает его параметры, поэтому каждый state принимается только один раз.
+// Для неизвестного или истекшего state возвращается models.ErrInvalidOIDCState, истекшие state удаляются
+func (db *DB) ConsumeOIDCState(ctx context.Context, hash string, now time.Time) (*models.OIDCState, error) {
+	cleanupOrder := `
+	DELETE FROM oidc_states WHERE expires_at <= $1
+	`
+	_, err := db.dbConnect.Exec(ctx, cleanupOrder, now)
+	if err != nil {
+		return nil, err
+	}
+
+	result := models.OIDCState{}
+	deleteOrder := `
+	DELETE FROM oidc_states
+	WHERE state_hash = $1 AND expires_at > $2
+	RETURNING state_hash, nonce, code_verifier, expires_at
+	`
+	err = db.dbConnect.QueryRow(ctx, deleteOrder, hash, now).Scan(&result.Hash, &result.Nonce, &result.CodeVerifier, &result.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrInvalidOIDCState
+	} else if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetUserByIdentity возвращает пользователя, связанного с учетной записью провайдера. provisioned сообщает,
+// что пользователь был создан при входе через провайдер, а не связан с существующим по email
+func (db *DB) GetUserByIdentity(ctx context.Context, issuer, subject string) (*models.User, bool, error) {
+	result := models.User{}
+	var provisioned bool
+	queryOrder := `
+	SELECT u.uuid, u.email, u.password, u.password_algo, u.disabled,
+	       COALESCE(array_agg(ur.role ORDER BY ur.role) FILTER (WHERE ur.role IS NOT NULL), '{}'), ui.provisioned
+	FROM users u
+	LEFT JOIN user_roles ur ON ur.user_uuid = u.uuid
+	JOIN user_identities ui ON ui.user_uuid = u.uuid
+	WHERE ui.issuer = $1 AND ui.subject = $2
+	GROUP BY u.uuid, ui.provisioned
+	`
+	err := db.dbConnect.QueryRow(ctx, queryOrder, issuer, subject).Scan(&result.ID, &result.Email, &result.Password, &result.PasswordAlgo, &result.Disabled, &result.Roles, &provisioned)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, models.ErrUserNotFound
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	return &result, provisioned, nil
+}
+
+// LinkUserIdentity связывает существующего пользователя с учетной записью провайдера. Пользователь может быть
+// связан только с одной учетной записью каждого провайдера, иначе возвращается models.ErrOIDCEmailConflict
+func (db *DB) LinkUserIdentity(ctx context.Context, userID uuid.UUID, issuer, subject string) error {
+	linkOrder := `
+	INSERT INTO user_identities (issuer, subject, user_uuid)
+	VALUES ($1, $2, $3)
+	`
+	_, err := db.dbConnect.Exec(ctx, linkOrder, issuer, subject, userID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return models.ErrOIDCEmailConflict
+	}
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode {
+		return models.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// CreateOIDCUser создает пользователя вместе со связью с учетной записью провайдера в одной транзакции
+func (db *DB) CreateOIDCUser(ctx context.Context, id uuid.UUID, user models.User, issuer, subject string) error {
+	tx, err := db.dbConnect.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = insertUser(ctx, tx, id, user)
+	if err != nil {
+		return err
+	}
+	linkOrder := `
+	INSERT INTO user_identities (issuer, subject, user_uuid, provisioned)
+	VALUES ($1, $2, $3, true)
+	`
+	_, err = tx.Exec(ctx, linkOrder, issuer, subject, id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return models.ErrUserExists
+	}
+	if err != nil {
+		return err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
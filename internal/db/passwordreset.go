@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+// CreatePasswordResetToken сохраняет токен восстановления пароля, неиспользованные токены пользователя
+// удаляются. Если предыдущий токен создан позже resendAfter, возвращается models.ErrPasswordResetThrottled
+func (db *DB) CreatePasswordResetToken(ctx context.Context, token models.PasswordResetToken, resendAfter time.Time) error {
+	tx, err := db.dbConnect.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// блокировка строки пользователя исключает параллельное создание токенов
+	lockOrder := `
+	SELECT uuid FROM users WHERE uuid = $1 FOR UPDATE
+	`
+	err = tx.QueryRow(ctx, lockOrder, token.UserID).Scan(&token.UserID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.ErrUserNotFound
+	} else if err != nil {
+		return err
+	}
+	var recent bool
+	recentOrder := `
+	SELECT EXISTS (
+		SELECT 1 FROM password_reset_tokens
+		WHERE user_uuid = $1 AND created_at > $2
+	)
+	`
+	err = tx.QueryRow(ctx, recentOrder, token.UserID, resendAfter).Scan(&recent)
+	if err != nil {
+		return err
+	}
+	if recent {
+		return models.ErrPasswordResetThrottled
+	}
+	deleteOrder := `
+	DELETE FROM password_reset_tokens WHERE user_uuid = $1
+	`
+	_, err = tx.Exec(ctx, deleteOrder, token.UserID)
+	if err != nil {
+		return err
+	}
+	createOrder := `
+	INSERT INTO password_reset_tokens (token_hash, user_uuid, created_at, expires_at)
+	VALUES ($1, $2, $3, $4)
+	`
+	_, err = tx.Exec(ctx, createOrder, token.Hash, token.UserID, token.CreatedAt, token.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ResetPasswordByToken в одной транзакции помечает токен использованным, заменяет пароль и отзывает
+// все токены пользователя. Возвращает UUID и email пользователя, для неизвестного, использованного
+// или истекшего токена возвращается models.ErrInvalidResetToken
+func (db *DB) ResetPasswordByToken(ctx context.Context, hash string, now time.Time, password, algo string) (uuid.UUID, string, error) {
+	tx, err := db.dbConnect.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var id uuid.UUID
+	useOrder := `
+	UPDATE password_reset_tokens
+	SET used_at = $2
+	WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+	RETURNING user_uuid
+	`
+	err = tx.QueryRow(ctx, useOrder, hash, now).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, "", models.ErrInvalidResetToken
+	} else if err != nil {
+		return uuid.Nil, "", err
+	}
+	var email string
+	updateOrder := `
+	UPDATE users
+	SET password = $2, password_algo = $3, tokens_revoked_at = now()
+	WHERE uuid = $1
+	RETURNING email
+	`
+	err = tx.QueryRow(ctx, updateOrder, id, password, algo).Scan(&email)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	refreshOrder := `
+	UPDATE refresh_tokens
+	SET revoked_at = now()
+	WHERE user_uuid = $1 AND revoked_at IS NULL
+	`
+	_, err = tx.Exec(ctx, refreshOrder, id)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	return id, email, nil
+}
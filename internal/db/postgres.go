@@ -7,12 +7,24 @@ import (
 	"time"
 )
 
+// Коды ошибок PostgreSQL при нарушении ограничений unique и foreign key
+const (
+	uniqueViolationCode     = "23505"
+	foreignKeyViolationCode = "23503"
+)
+
 //go:generate mockery --name logger
 type logger interface {
 	DebugMsg(msg string)
 	ErrorMsg(msg string, err error)
 }
 
+// querier - общие методы pgx.Conn и pgx.Tx, чтобы читать записи как из соединения, так и внутри транзакции
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
 type DB struct {
 	dbConnect *pgx.Conn
 	log       logger
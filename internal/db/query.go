@@ -0,0 +1,54 @@
+package db
+
+import (
+	"strconv"
+	"strings"
+)
+
+// queryBuilder собирает условия WHERE и параметры запроса. В тексте условия вместо номера параметра пишется ?,
+// номера $n назначаются по порядку добавления, поэтому условия можно добавлять в любом сочетании
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg добавляет параметр и возвращает ссылку на него вида $n, ссылку можно использовать в запросе несколько раз
+func (q *queryBuilder) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+// where добавляет условие, каждый ? в тексте условия заменяется ссылкой на очередной параметр из args
+func (q *queryBuilder) where(condition string, args ...interface{}) {
+	var sb strings.Builder
+	for _, val := range args {
+		i := strings.IndexByte(condition, '?')
+		sb.WriteString(condition[:i])
+		sb.WriteString(q.arg(val))
+		condition = condition[i+1:]
+	}
+	sb.WriteString(condition)
+	q.conditions = append(q.conditions, sb.String())
+}
+
+// whereClause возвращает WHERE со всеми условиями через AND или пустую строку, если условий нет
+func (q *queryBuilder) whereClause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return "WHERE (" + strings.Join(q.conditions, ") AND (") + ")"
+}
+
+// limit возвращает LIMIT и OFFSET с параметрами, при limit 0 записи не ограничиваются
+func (q *queryBuilder) limit(limit, offset int) string {
+	if limit == 0 {
+		return ""
+	}
+	return "LIMIT " + q.arg(limit) + " OFFSET " + q.arg(offset)
+}
+
+// containsPattern возвращает шаблон LIKE для поиска подстроки, символы %, _ и \ в s ищутся как есть
+func containsPattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
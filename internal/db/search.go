@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"strings"
+)
+
+// searchTables - таблицы, в которых ищутся объекты каждого вида
+var searchTables = map[string]string{
+	models.SearchKindMovie: "movies",
+	models.SearchKindActor: "actors",
+}
+
+// Search ищет фильмы и актеров видов kinds, название которых похоже на query по триграммам pg_trgm: целиком
+// (оператор %) или как слово внутри названия (оператор <%), поэтому запрос находит названия с опечатками
+// и по началу слова. Оба оператора используют GIN индексы по name. Результаты упорядочены по убыванию оценки
+func (db *DB) Search(ctx context.Context, query string, kinds []string, limit int) ([]*models.SearchResult, error) {
+	var q queryBuilder
+	text := q.arg(query)
+	selects := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		table, ok := searchTables[kind]
+		if !ok {
+			continue
+		}
+		selects = append(selects, `
+		SELECT `+q.arg(kind)+`::text AS kind, uuid, name,
+			greatest(similarity(name, `+text+`), word_similarity(`+text+`, name))::float8 AS score
+		FROM `+table+`
+		WHERE name % `+text+` OR `+text+` <% name`)
+	}
+	result := make([]*models.SearchResult, 0)
+	if len(selects) == 0 {
+		return result, nil
+	}
+	searchOrder := strings.Join(selects, "\n\tUNION ALL") + `
+	ORDER BY score DESC, name, uuid
+	LIMIT ` + q.arg(limit)
+	rows, err := db.dbConnect.Query(ctx, searchOrder, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var val models.SearchResult
+		err = rows.Scan(&val.Kind, &val.ID, &val.Name, &val.Score)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &val)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Suggest возвращает до limit фильмов или актеров вида kind, название которых начинается с prefix без учета регистра,
+// prefix передается в нижнем регистре. Оператор ^@ использует SP-GiST индекс по lower(name) и с параметром запроса
+func (db *DB) Suggest(ctx context.Context, kind, prefix string, limit int) ([]*models.Suggestion, error) {
+	result := make([]*models.Suggestion, 0)
+	table, ok := searchTables[kind]
+	if !ok {
+		return result, nil
+	}
+	suggestOrder := `
+	SELECT uuid, name FROM ` + table + `
+	WHERE lower(name) ^@ $1
+	ORDER BY lower(name), uuid
+	LIMIT $2`
+	rows, err := db.dbConnect.Query(ctx, suggestOrder, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var val models.Suggestion
+		err = rows.Scan(&val.ID, &val.Name)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &val)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
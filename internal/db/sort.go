@@ -0,0 +1,99 @@
+package db
+
+import (
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"strings"
+)
+
+// sortColumn - столбец сортировки и тип, к которому приводится текстовое значение курсора. Записи со значением NULL
+// в столбце nullable выдаются в конце при любом направлении сортировки, в курсоре NULL передается пустой строкой
+type sortColumn struct {
+	name     string
+	cast     string
+	nullable bool
+}
+
+// sortColumns - допустимые поля сортировки и их столбцы. Имена столбцов берутся только отсюда,
+// поэтому значения из запроса в текст SQL не попадают
+type sortColumns map[string]sortColumn
+
+// sortOrder - сортировка, переведенная в столбцы, последним столбцом добавляется uuid по возрастанию,
+// чтобы порядок записей с равными значениями был однозначным
+type sortOrder struct {
+	columns []sortColumn
+	desc    []bool
+}
+
+func (c sortColumns) order(sort models.SortSpec) (*sortOrder, error) {
+	result := &sortOrder{}
+	for _, val := range sort {
+		column, ok := c[val.Field]
+		if !ok {
+			return nil, models.ErrInvalidSort
+		}
+		result.columns = append(result.columns, column)
+		result.desc = append(result.desc, val.Desc)
+	}
+	result.columns = append(result.columns, sortColumn{name: "uuid"})
+	result.desc = append(result.desc, false)
+	return result, nil
+}
+
+// orderBy возвращает список ORDER BY, prefix добавляется к именам столбцов, например "page."
+func (o *sortOrder) orderBy(prefix string) string {
+	result := make([]string, 0, len(o.columns))
+	for i, column := range o.columns {
+		direction := "ASC"
+		if o.desc[i] {
+			direction = "DESC"
+		}
+		if column.nullable {
+			direction += " NULLS LAST"
+		}
+		result = append(result, prefix+column.name+" "+direction)
+	}
+	return strings.Join(result, ", ")
+}
+
+// after добавляет в q условие "запись после курсора" для сортировки со смешанными направлениями:
+// (a > $1) OR (a = $1 AND b < $2) OR (a = $1 AND b = $2 AND uuid > $3). Значения курсора передаются
+// параметрами и приводятся к типу столбца в запросе. Столбцы nullable сравниваются через IS NOT DISTINCT FROM,
+// а после непустого значения идут и записи с NULL, так как они выдаются в конце
+func (o *sortOrder) after(q *queryBuilder, cursor *models.Cursor) error {
+	if len(cursor.Values) != len(o.columns)-1 {
+		return models.ErrInvalidCursor
+	}
+	params := make([]string, 0, len(o.columns))
+	for i, value := range cursor.Values {
+		param := q.arg(value) + "::text"
+		if o.columns[i].nullable {
+			param = "NULLIF(" + param + ", '')"
+		}
+		params = append(params, param+"::"+o.columns[i].cast)
+	}
+	params = append(params, q.arg(cursor.ID))
+
+	conditions := make([]string, 0, len(o.columns))
+	for i, column := range o.columns {
+		terms := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			equal := " = "
+			if o.columns[j].nullable {
+				equal = " IS NOT DISTINCT FROM "
+			}
+			terms = append(terms, o.columns[j].name+equal+params[j])
+		}
+		compare := " > "
+		if o.desc[i] {
+			compare = " < "
+		}
+		term := column.name + compare + params[i]
+		if column.nullable {
+			term = "(" + term + " OR " + column.name + " IS NULL AND " + params[i] + " IS NOT NULL)"
+		}
+		terms = append(terms, term)
+		conditions = append(conditions, "("+strings.Join(terms, " AND ")+")")
+	}
+	q.where(strings.Join(conditions, " OR "))
+	return nil
+}
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+func (db *DB) CreateRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	createOrder := `
+	INSERT INTO refresh_tokens (token_hash, family_id, user_uuid, expires_at)
+	VALUES ($1, $2, $3, $4)
+	`
+	_, err := db.dbConnect.Exec(ctx, createOrder, token.Hash, token.FamilyID, token.UserID, token.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (db *DB) GetRefreshToken(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	result := models.RefreshToken{}
+	queryOrder := `
+	SELECT token_hash, family_id, user_uuid, expires_at, rotated_at, revoked_at
+	FROM refresh_tokens WHERE token_hash = $1
+	`
+	err := db.dbConnect.QueryRow(ctx, queryOrder, hash).Scan(&result.Hash, &result.FamilyID, &result.UserID,
+		&result.ExpiresAt, &result.RotatedAt, &result.RevokedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrInvalidRefreshToken
+	} else if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RotateRefreshToken помечает старый токен использованным и сохраняет новый токен того же семейства.
+// Если старый токен уже был использован или отозван, возвращается models.ErrRefreshTokenReused
+func (db *DB) RotateRefreshToken(ctx context.Context, oldHash string, token models.RefreshToken) error {
+	tx, err := db.dbConnect.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	rotateOrder := `
+	UPDATE refresh_tokens
+	SET rotated_at = now()
+	WHERE token_hash = $1 AND rotated_at IS NULL AND revoked_at IS NULL
+	`
+	tag, err := tx.Exec(ctx, rotateOrder, oldHash)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrRefreshTokenReused
+	}
+	createOrder := `
+	INSERT INTO refresh_tokens (token_hash, family_id, user_uuid, expires_at)
+	VALUES ($1, $2, $3, $4)
+	`
+	_, err = tx.Exec(ctx, createOrder, token.Hash, token.FamilyID, token.UserID, token.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (db *DB) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	revokeOrder := `
+	UPDATE refresh_tokens
+	SET revoked_at = now()
+	WHERE family_id = $1 AND revoked_at IS NULL
+	`
+	_, err := db.dbConnect.Exec(ctx, revokeOrder, familyID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RevokeToken добавляет access токен в список отозванных до окончания его действия,
+// заодно удаляются записи о токенах, срок действия которых уже истек
+func (db *DB) RevokeToken(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error {
+	revokeOrder := `
+	INSERT INTO revoked_tokens (jti, user_uuid, expires_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (jti) DO NOTHING
+	`
+	_, err := db.dbConnect.Exec(ctx, revokeOrder, jti, userID, expiresAt)
+	if err != nil {
+		return err
+	}
+	deleteOrder := `
+	DELETE FROM revoked_tokens WHERE expires_at < now()
+	`
+	_, err = db.dbConnect.Exec(ctx, deleteOrder)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (db *DB) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	queryOrder := `
+	SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)
+	`
+	err := db.dbConnect.QueryRow(ctx, queryOrder, jti).Scan(&revoked)
+	if err != nil {
+		return false, err
+	}
+	return revoked, nil
+}
+
+// RevokeUserTokens делает недействительными все выпущенные пользователю токены:
+// access токены, выпущенные до tokens_revoked_at, и все refresh токены
+func (db *DB) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	tx, err := db.dbConnect.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	userOrder := `
+	UPDATE users
+	SET tokens_revoked_at = now()
+	WHERE uuid = $1
+	`
+	tag, err := tx.Exec(ctx, userOrder, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrUserNotFound
+	}
+	refreshOrder := `
+	UPDATE refresh_tokens
+	SET revoked_at = now()
+	WHERE user_uuid = $1 AND revoked_at IS NULL
+	`
+	_, err = tx.Exec(ctx, refreshOrder, userID)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (db *DB) GetUserTokensRevokedAt(ctx context.Context, userID uuid.UUID) (*time.Time, error) {
+	var revokedAt *time.Time
+	queryOrder := `
+	SELECT tokens_revoked_at FROM users WHERE uuid = $1
+	`
+	err := db.dbConnect.QueryRow(ctx, queryOrder, userID).Scan(&revokedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return revokedAt, nil
+}
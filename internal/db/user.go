@@ -2,18 +2,243 @@ package db
 
 import (
 	"context"
+	"errors"
 	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 )
 
+// userQuery выбирает пользователя вместе со списком его ролей, условие WHERE добавляется вызывающим
+const userQuery = `
+	SELECT u.uuid, u.email, u.password, u.password_algo, u.disabled,
+	       COALESCE(array_agg(ur.role ORDER BY ur.role) FILTER (WHERE ur.role IS NOT NULL), '{}')
+	FROM users u
+	LEFT JOIN user_roles ur ON ur.user_uuid = u.uuid
+	`
+
+// GetUserByEmail ищет пользователя без учета регистра email, условие использует уникальный индекс по lower(email)
 func (db *DB) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	result := models.User{}
+	queryOrder := userQuery + `WHERE lower(u.email) = lower($1) GROUP BY u.uuid`
+	err := db.dbConnect.QueryRow(ctx, queryOrder, email).Scan(&result.ID, &result.Email, &result.Password, &result.PasswordAlgo, &result.Disabled, &result.Roles)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (db *DB) GetUserByUUID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	result := models.User{}
+	queryOrder := userQuery + `WHERE u.uuid = $1 GROUP BY u.uuid`
+	err := db.dbConnect.QueryRow(ctx, queryOrder, id).Scan(&result.ID, &result.Email, &result.Password, &result.PasswordAlgo, &result.Disabled, &result.Roles)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetUserList возвращает страницу пользователей, отсортированных по email, и общее количество пользователей
+func (db *DB) GetUserList(ctx context.Context, limit, offset int) ([]*models.User, int, error) {
+	var total int
+	countOrder := `
+	SELECT count(*) FROM users
+	`
+	err := db.dbConnect.QueryRow(ctx, countOrder).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]*models.User, 0)
+	queryOrder := userQuery + `GROUP BY u.uuid ORDER BY u.email LIMIT $1 OFFSET $2`
+	rows, err := db.dbConnect.Query(ctx, queryOrder, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		user := models.User{}
+		err = rows.Scan(&user.ID, &user.Email, &user.Password, &user.PasswordAlgo, &user.Disabled, &user.Roles)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, &user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return result, total, nil
+}
+
+// GetUserPermissions возвращает объединение прав всех ролей пользователя
+func (db *DB) GetUserPermissions(ctx context.Context, id uuid.UUID) ([]models.Permission, error) {
+	result := make([]models.Permission, 0)
 	queryOrder := `
-	SELECT email, password, role FROM users WHERE email = $1
+	SELECT DISTINCT rp.permission
+	FROM user_roles ur
+	JOIN role_permissions rp ON rp.role = ur.role
+	WHERE ur.user_uuid = $1
+	ORDER BY rp.permission
 	`
-	err := db.dbConnect.QueryRow(ctx, queryOrder, email).Scan(&result.Email, &result.Password, &result.Role)
+	rows, err := db.dbConnect.Query(ctx, queryOrder, id)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+	for rows.Next() {
+		var permission string
+		err = rows.Scan(&permission)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, models.Permission(permission))
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
-	return &result, nil
+func (db *DB) UpdateUserPassword(ctx context.Context, email, password, algo string) error {
+	updateOrder := `
+	UPDATE users
+	SET password = $2, password_algo = $3
+	WHERE lower(email) = lower($1)
+	`
+	_, err := db.dbConnect.Exec(ctx, updateOrder, email, password, algo)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (db *DB) CreateUser(ctx context.Context, id uuid.UUID, user models.User) error {
+	tx, err := db.dbConnect.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = insertUser(ctx, tx, id, user)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// insertUser добавляет пользователя и его роли в рамках транзакции tx
+func insertUser(ctx context.Context, tx pgx.Tx, id uuid.UUID, user models.User) error {
+	createOrder := `
+	INSERT INTO users (uuid, email, password, password_algo)
+	VALUES ($1, $2, $3, $4)
+	`
+	_, err := tx.Exec(ctx, createOrder, id, user.Email, user.Password, user.PasswordAlgo)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return models.ErrUserExists
+	}
+	if err != nil {
+		return err
+	}
+	roleOrder := `
+	INSERT INTO user_roles (user_uuid, role)
+	VALUES ($1, $2)
+	`
+	for _, role := range user.Roles {
+		_, err = tx.Exec(ctx, roleOrder, id, role)
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode {
+			return models.ErrUnknownRole
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetUserRoles заменяет роли пользователя переданным списком
+func (db *DB) SetUserRoles(ctx context.Context, id uuid.UUID, roles []string) error {
+	tx, err := db.dbConnect.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// блокировка строки пользователя исключает параллельное изменение ролей
+	lockOrder := `
+	SELECT uuid FROM users WHERE uuid = $1 FOR UPDATE
+	`
+	err = tx.QueryRow(ctx, lockOrder, id).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.ErrUserNotFound
+	} else if err != nil {
+		return err
+	}
+	deleteOrder := `
+	DELETE FROM user_roles WHERE user_uuid = $1
+	`
+	_, err = tx.Exec(ctx, deleteOrder, id)
+	if err != nil {
+		return err
+	}
+	roleOrder := `
+	INSERT INTO user_roles (user_uuid, role)
+	VALUES ($1, $2)
+	ON CONFLICT DO NOTHING
+	`
+	for _, role := range roles {
+		_, err = tx.Exec(ctx, roleOrder, id, role)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode {
+			return models.ErrUnknownRole
+		}
+		if err != nil {
+			return err
+		}
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (db *DB) SetUserDisabled(ctx context.Context, id uuid.UUID, disabled bool) error {
+	updateOrder := `
+	UPDATE users
+	SET disabled = $2
+	WHERE uuid = $1
+	`
+	tag, err := db.dbConnect.Exec(ctx, updateOrder, id, disabled)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrUserNotFound
+	}
+	return nil
+}
+
+func (db *DB) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	deleteOrder := `
+	DELETE FROM users WHERE uuid = $1
+	`
+	tag, err := db.dbConnect.Exec(ctx, deleteOrder, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrUserNotFound
+	}
+	return nil
 }
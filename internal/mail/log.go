@@ -0,0 +1,43 @@
+package mail
+
+import (
+	"context"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer не отправляет письма, а дописывает их в файл или в w, если файл не задан. Нужен для локальной разработки
+type LogMailer struct {
+	from string
+	path string
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewLogMailer(from, path string, w io.Writer) *LogMailer {
+	return &LogMailer{from: from, path: path, w: w}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg models.MailMessage) error {
+	data, err := buildMessage(m.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w := m.w
+	if m.path != "" {
+		file, err := os.OpenFile(m.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	_, err = w.Write(append(append([]byte("-----BEGIN MAIL-----\r\n"), data...), "\r\n-----END MAIL-----\r\n"...))
+	return err
+}
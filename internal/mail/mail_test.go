@@ -0,0 +1,140 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testFrom = "Фильмотека <noreply@example.com>"
+
+var testMessage = models.MailMessage{
+	To:      "user@example.com",
+	Subject: "Восстановление пароля",
+	Body:    "Ссылка для смены пароля:\nhttp://localhost:8080/reset?token=abc",
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(models.MailConfig{Driver: DriverLog, From: testFrom})
+	assert.NoError(t, err)
+	_, err = New(models.MailConfig{Driver: "pigeon", From: testFrom})
+	assert.Error(t, err)
+	_, err = New(models.MailConfig{Driver: DriverLog, From: "not an address"})
+	assert.Error(t, err)
+	_, err = New(models.MailConfig{Driver: DriverSMTP, From: testFrom, SMTP: models.SMTPConfig{Port: "25", Timeout: time.Second}})
+	assert.Error(t, err)
+}
+
+func TestBuildMessage(t *testing.T) {
+	data, err := buildMessage(testFrom, testMessage, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	parsed, err := netmail.ReadMessage(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "<user@example.com>", parsed.Header.Get("To"))
+	assert.Equal(t, "Fri, 01 Mar 2024 12:00:00 +0000", parsed.Header.Get("Date"))
+	assert.True(t, strings.HasSuffix(parsed.Header.Get("Message-ID"), "@example.com>"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, testMessage.Subject, subject)
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	require.NoError(t, err)
+	assert.Equal(t, strings.ReplaceAll(testMessage.Body, "\n", "\r\n"), string(body))
+
+	//Перевод строки в заголовке позволил бы добавить получателей
+	_, err = buildMessage(testFrom, models.MailMessage{To: "user@example.com\r\nBcc: other@example.com", Subject: "test"}, time.Now())
+	assert.ErrorIs(t, err, errHeaderInjection)
+	_, err = buildMessage(testFrom, models.MailMessage{To: "user@example.com", Subject: "test\nBcc: other@example.com"}, time.Now())
+	assert.ErrorIs(t, err, errHeaderInjection)
+}
+
+func TestLogMailer(t *testing.T) {
+	var buf bytes.Buffer
+	err := NewLogMailer(testFrom, "", &buf).Send(context.TODO(), testMessage)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "To: <user@example.com>")
+
+	path := filepath.Join(t.TempDir(), "mail.log")
+	mailer := NewLogMailer(testFrom, path, &buf)
+	require.NoError(t, mailer.Send(context.TODO(), testMessage))
+	require.NoError(t, mailer.Send(context.TODO(), testMessage))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "-----BEGIN MAIL-----"))
+}
+
+func TestSMTPMailer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	result := make(chan []string, 1)
+	go serveSMTP(listener, result)
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	mailer, err := NewSMTPMailer(models.MailConfig{
+		From: testFrom,
+		SMTP: models.SMTPConfig{Host: host, Port: port, Timeout: 5 * time.Second},
+	})
+	require.NoError(t, err)
+	err = mailer.Send(context.TODO(), testMessage)
+	require.NoError(t, err)
+
+	envelope := <-result
+	require.Len(t, envelope, 3)
+	assert.Equal(t, "MAIL FROM:<noreply@example.com> BODY=8BITMIME", envelope[0])
+	assert.Equal(t, "RCPT TO:<user@example.com>", envelope[1])
+	assert.Contains(t, envelope[2], "To: <user@example.com>")
+}
+
+// serveSMTP - минимальный SMTP сервер без STARTTLS и AUTH, возвращает конверт и текст принятого письма
+func serveSMTP(listener net.Listener, result chan<- []string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	var envelope []string
+	text.PrintfLine("220 localhost ESMTP test")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO", "HELO":
+			text.PrintfLine("250-localhost\r\n250 8BITMIME")
+		case "MAIL", "RCPT":
+			envelope = append(envelope, line)
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 go ahead")
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			envelope = append(envelope, string(data))
+			text.PrintfLine("250 queued")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			result <- envelope
+			return
+		default:
+			text.PrintfLine("502 not implemented")
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// SMTPMailer отправляет письма через SMTP сервер, каждое письмо - в отдельном соединении
+type SMTPMailer struct {
+	from   string
+	sender string
+	cfg    models.SMTPConfig
+}
+
+func NewSMTPMailer(cfg models.MailConfig) (*SMTPMailer, error) {
+	if cfg.SMTP.Host == "" || cfg.SMTP.Port == "" {
+		return nil, errors.New("smtp host and port must be set")
+	}
+	if cfg.SMTP.Timeout <= 0 {
+		return nil, errors.New("smtp timeout must be positive")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, err
+	}
+	return &SMTPMailer{from: cfg.From, sender: from.Address, cfg: cfg.SMTP}, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg models.MailMessage) error {
+	now := time.Now()
+	data, err := buildMessage(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(m.cfg.Host, m.cfg.Port))
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: m.cfg.Host, MinVersion: tls.VersionTLS12})
+		if err != nil {
+			return err
+		}
+	}
+	if m.cfg.Username != "" {
+		// smtp.PlainAuth отказывается передавать пароль без TLS, если сервер не localhost
+		err = client.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host))
+		if err != nil {
+			return err
+		}
+	}
+	err = client.Mail(m.sender)
+	if err != nil {
+		return err
+	}
+	err = client.Rcpt(to.Address)
+	if err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
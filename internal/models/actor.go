@@ -2,15 +2,50 @@ package models
 
 import (
 	"encoding/json"
+	"github.com/google/uuid"
 	"time"
 )
 
+var (
+	ErrActorNotFound = NewError(ErrNotFound, "actor_not_found", "actor not found")
+	ErrActorExists   = NewError(ErrConflict, "actor_exists", "actor with this name already exists")
+)
+
+// ActorListSort - порядок списка актеров
+var ActorListSort = SortSpec{{Field: "name"}}
+
 type Actor struct {
 	Name      string    `json:"name"`
 	Gender    string    `json:"gender"`
 	BirthDate time.Time `json:"birth_date"`
 }
 
+// ActorDetails - актер с идентификатором и списком фильмов
+type ActorDetails struct {
+	ID          uuid.UUID    `json:"id"`
+	Name        string       `json:"name"`
+	Gender      string       `json:"gender"`
+	BirthDate   time.Time    `json:"birth_date"`
+	Filmography []ActorMovie `json:"filmography"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// ActorMovie - фильм в списке фильмов актера
+type ActorMovie struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	ReleaseDate time.Time `json:"release_date"`
+}
+
+func (a *Actor) Validate() error {
+	var verr ValidationError
+	if a.Name == "" {
+		verr.Add("name", "must not be empty")
+	}
+	return verr.Err()
+}
+
 func (a *Actor) UnmarshalJSON(data []byte) error {
 	type Alias Actor
 	aux := &struct {
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+var (
+	ErrAPIKeyNotFound     = NewError(ErrNotFound, "api_key_not_found", "api key not found")
+	ErrInvalidAPIKey      = NewError(ErrUnauthorized, "invalid_api_key", "invalid api key")
+	ErrEmptyAPIKeyName    = NewError(ErrValidation, "api_key_name_empty", "api key name is empty")
+	ErrEmptyPermissions   = NewError(ErrValidation, "permissions_empty", "permissions list is empty")
+	ErrUnknownPermission  = NewError(ErrValidation, "unknown_permission", "unknown permission")
+	ErrPermissionNotOwned = NewError(ErrForbidden, "permission_not_owned", "permission is not granted to key creator")
+	ErrInvalidExpiry      = NewError(ErrValidation, "invalid_expiry", "expiry must be in the future")
+	ErrAPIKeyByAPIKey     = NewError(ErrForbidden, "api_key_by_api_key", "api key can't be created with an api key")
+)
+
+// APIKey - долгоживущий ключ для сервисных клиентов. Сам ключ хранится только в виде хеша,
+// Prefix - видимая часть ключа, по которой его можно узнать в списке. После удаления создателя CreatedBy пуст.
+// CreatorPermissions - текущие права ролей создателя, по ним ограничиваются права ключа
+type APIKey struct {
+	ID                 uuid.UUID    `json:"id"`
+	Name               string       `json:"name"`
+	Prefix             string       `json:"prefix"`
+	Hash               string       `json:"-"`
+	Permissions        []Permission `json:"permissions"`
+	CreatedBy          *uuid.UUID   `json:"created_by"`
+	CreatorDisabled    bool         `json:"creator_disabled"`
+	CreatorPermissions []Permission `json:"-"`
+	CreatedAt          time.Time    `json:"created_at"`
+	ExpiresAt          *time.Time   `json:"expires_at"`
+	LastUsedAt         *time.Time   `json:"last_used_at"`
+	RevokedAt          *time.Time   `json:"revoked_at"`
+}
+
+// Active сообщает, принимается ли ключ: он не отозван, не просрочен, а его создатель существует и не заблокирован.
+// Ключ заблокированного пользователя снова начинает приниматься после разблокировки
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt)) && k.CreatedBy != nil && !k.CreatorDisabled
+}
+
+// EffectivePermissions возвращает права ключа, которые есть у создателя сейчас. Если создателя понизили,
+// ключ теряет права, которых у создателя больше нет
+func (k *APIKey) EffectivePermissions() []Permission {
+	result := make([]Permission, 0, len(k.Permissions))
+	for _, permission := range k.Permissions {
+		for _, owned := range k.CreatorPermissions {
+			if owned == permission {
+				result = append(result, permission)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func (k *APIKey) HasPermission(permission Permission) bool {
+	for _, p := range k.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatedAPIKey возвращается при создании ключа, полный ключ показывается только один раз
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -1,9 +1,20 @@
 package models
 
+import "time"
+
 type Config struct {
-	ListenPort string    `yaml:"listen_port"`
-	SqlConfig  SqlConfig `yaml:"sql_config"`
-	LogLevel   string    `yaml:"log_level"`
+	ListenPort     string              `yaml:"listen_port"`
+	SqlConfig      SqlConfig           `yaml:"sql_config"`
+	JWT            JWTConfig           `yaml:"jwt"`
+	Login          LoginConfig         `yaml:"login"`
+	MFA            MFAConfig           `yaml:"mfa"`
+	OIDC           OIDCConfig          `yaml:"oidc"`
+	PasswordReset  PasswordResetConfig `yaml:"password_reset"`
+	Mail           MailConfig          `yaml:"mail"`
+	Pagination     PaginationConfig    `yaml:"pagination"`
+	Suggest        SuggestConfig       `yaml:"suggest"`
+	TrustedProxies []string            `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+	LogLevel       string              `yaml:"log_level"`
 }
 
 type SqlConfig struct {
@@ -14,3 +25,104 @@ type SqlConfig struct {
 	DBName     string `yaml:"db_name"`
 	DelayTime  int    `yaml:"delay_time"`
 }
+
+type JWTConfig struct {
+	Secret       string        `yaml:"secret" env:"JWT_SECRET"`
+	SecretFile   string        `yaml:"secret_file" env:"JWT_SECRET_FILE"`
+	Keys         []JWTKey      `yaml:"keys"`
+	SigningKeyID string        `yaml:"signing_key_id" env:"JWT_SIGNING_KEY_ID"`
+	Issuer       string        `yaml:"issuer" env-default:"vkintern-movies"`
+	Audience     string        `yaml:"audience" env-default:"vkintern-movies"`
+	AccessTTL    time.Duration `yaml:"access_ttl" env-default:"1h"`
+	RefreshTTL   time.Duration `yaml:"refresh_ttl" env-default:"720h"`
+	MFATTL       time.Duration `yaml:"mfa_ttl" env-default:"5m"`
+	ClockSkew    time.Duration `yaml:"clock_skew" env-default:"30s"`
+}
+
+// LoginConfig - защита от перебора паролей. После max_*_failures неудачных попыток в пределах failure_window
+// вход блокируется на base_lockout, каждая следующая неудачная попытка удваивает блокировку, но не больше max_lockout.
+// Нулевое значение max_*_failures отключает соответствующее ограничение
+type LoginConfig struct {
+	MaxEmailFailures int           `yaml:"max_email_failures" env-default:"5"`
+	MaxIPFailures    int           `yaml:"max_ip_failures" env-default:"20"`
+	FailureWindow    time.Duration `yaml:"failure_window" env-default:"15m"`
+	BaseLockout      time.Duration `yaml:"base_lockout" env-default:"1m"`
+	MaxLockout       time.Duration `yaml:"max_lockout" env-default:"1h"`
+}
+
+// MFAConfig - двухфакторная аутентификация. Пользователь с любой из required_roles не получит access токен,
+// пока не подключит TOTP. issuer отображается в приложении-аутентификаторе
+type MFAConfig struct {
+	RequiredRoles []string `yaml:"required_roles"`
+	Issuer        string   `yaml:"issuer" env-default:"VKintern-movies"`
+}
+
+// OIDCConfig - вход через внешний OpenID Connect провайдер, пустой issuer отключает вход.
+// Адреса провайдера берутся из {issuer}/.well-known/openid-configuration. Роли пользователя, созданного при входе
+// через провайдер, при каждом входе заменяются ролями его групп из group_roles, если ни одна группа не подошла -
+// default_roles. Роли локальных пользователей, связанных по email, заменяются, только если включен sync_linked_roles
+type OIDCConfig struct {
+	Issuer          string              `yaml:"issuer" env:"OIDC_ISSUER"`
+	ClientID        string              `yaml:"client_id" env:"OIDC_CLIENT_ID"`
+	ClientSecret    string              `yaml:"client_secret" env:"OIDC_CLIENT_SECRET"`
+	RedirectURL     string              `yaml:"redirect_url" env:"OIDC_REDIRECT_URL"`
+	Scopes          []string            `yaml:"scopes" env-default:"openid,email,profile"`
+	GroupsClaim     string              `yaml:"groups_claim" env-default:"groups"`
+	GroupRoles      map[string][]string `yaml:"group_roles"`
+	DefaultRoles    []string            `yaml:"default_roles"`
+	SyncLinkedRoles bool                `yaml:"sync_linked_roles"`
+	StateTTL        time.Duration       `yaml:"state_ttl" env-default:"10m"`
+	ClockSkew       time.Duration       `yaml:"clock_skew" env-default:"30s"`
+	RequestTimeout  time.Duration       `yaml:"request_timeout" env-default:"10s"`
+}
+
+// PasswordResetConfig - восстановление пароля по ссылке из письма. url - адрес страницы установки нового пароля,
+// токен добавляется к нему параметром token. Новое письмо тому же пользователю отправляется не чаще resend_interval
+type PasswordResetConfig struct {
+	URL            string        `yaml:"url" env:"PASSWORD_RESET_URL" env-default:"http://localhost:3000/reset-password"`
+	TokenTTL       time.Duration `yaml:"token_ttl" env-default:"1h"`
+	ResendInterval time.Duration `yaml:"resend_interval" env-default:"1m"`
+}
+
+// MailConfig - отправка писем. Драйвер smtp отправляет письма через SMTP сервер, драйвер log для локальной
+// разработки записывает их в log_file или в стандартный вывод, если файл не задан
+type MailConfig struct {
+	Driver  string     `yaml:"driver" env:"MAIL_DRIVER" env-default:"log"`
+	From    string     `yaml:"from" env:"MAIL_FROM" env-default:"noreply@vkintern-movies.local"`
+	LogFile string     `yaml:"log_file"`
+	SMTP    SMTPConfig `yaml:"smtp"`
+}
+
+// SMTPConfig - SMTP сервер. Если сервер поддерживает STARTTLS, соединение шифруется, без шифрования
+// логин и пароль передаются только на localhost
+type SMTPConfig struct {
+	Host     string        `yaml:"host" env:"SMTP_HOST"`
+	Port     string        `yaml:"port" env:"SMTP_PORT" env-default:"587"`
+	Username string        `yaml:"username" env:"SMTP_USERNAME"`
+	Password string        `yaml:"password" env:"SMTP_PASSWORD"`
+	Timeout  time.Duration `yaml:"timeout" env-default:"10s"`
+}
+
+// PaginationConfig - размер страницы списков фильмов и актеров, если он не задан в запросе, и наибольший допустимый размер
+type PaginationConfig struct {
+	DefaultPageSize int `yaml:"default_page_size" env-default:"20"`
+	MaxPageSize     int `yaml:"max_page_size" env-default:"100"`
+}
+
+// SuggestConfig - количество подсказок GET /suggest и кеш подсказок в памяти. CacheSize - наибольшее количество
+// кешируемых префиксов, 0 отключает кеш, CacheTTL ограничивает время, за которое становятся видны изменения,
+// сделанные другими экземплярами сервиса
+type SuggestConfig struct {
+	Limit     int           `yaml:"limit" env-default:"10"`
+	CacheSize int           `yaml:"cache_size" env-default:"1000"`
+	CacheTTL  time.Duration `yaml:"cache_ttl" env-default:"1m"`
+}
+
+// JWTKey - ключ подписи в PEM файлах, тип ключа (RSA или Ed25519) определяет алгоритм подписи.
+// Если ключи заданы, секрет не используется. Ключ только с публичной частью используется
+// для проверки токенов, выпущенных до смены ключа
+type JWTKey struct {
+	ID             string `yaml:"id"`
+	PrivateKeyFile string `yaml:"private_key_file"`
+	PublicKeyFile  string `yaml:"public_key_file"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+// Категории доменных ошибок, по категории обработчики выбирают код ответа:
+// errors.Is(err, ErrNotFound) верно для любой ошибки "не найдено"
+var (
+	ErrBadRequest      = errors.New("bad request")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrValidation      = errors.New("validation failed")
+	ErrTooManyRequests = errors.New("too many requests")
+	ErrUpstream        = errors.New("upstream service error")
+)
+
+// Error - доменная ошибка с категорией и стабильным кодом, код передается клиенту и не меняется
+// вместе с текстом сообщения
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+}
+
+func NewError(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// FieldError - ошибка проверки одного поля входных данных
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError возвращается, если входные данные не прошли проверку, Fields перечисляет неверные поля
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// CodeValidation - код ошибки проверки входных данных
+const CodeValidation = "validation_failed"
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		fields = append(fields, field.Field+": "+field.Message)
+	}
+	return ErrValidation.Error() + ": " + strings.Join(fields, ", ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Add добавляет ошибку поля
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err возвращает ошибку, если хотя бы одно поле не прошло проверку, иначе nil
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
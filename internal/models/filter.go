@@ -0,0 +1,114 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ActorMatchAny = "any"
+	ActorMatchAll = "all"
+)
+
+// MovieFilterParams - условия отбора списка фильмов из строки запроса. actor_id можно повторять
+// или перечислять через запятую
+type MovieFilterParams struct {
+	ReleasedFrom      string
+	ReleasedTo        string
+	RatingMin         string
+	RatingMax         string
+	ActorIDs          []string
+	ActorMatch        string
+	ActorNameContains string
+	NameContains      string
+	Query             string
+}
+
+// MovieFilter - условия отбора списка фильмов, незаданные условия не применяются. Даты выхода включают
+// released_from и released_to, ActorMatch задает, должен ли в фильме сниматься любой из ActorIDs или все.
+// ActorNameContains - фрагмент имени любого из актеров фильма.
+// Query - полнотекстовый запрос в синтаксисе websearch_to_tsquery по названию и описанию
+type MovieFilter struct {
+	ReleasedFrom      *time.Time
+	ReleasedTo        *time.Time
+	RatingMin         *float64
+	RatingMax         *float64
+	ActorIDs          []uuid.UUID
+	ActorMatch        string
+	ActorNameContains string
+	NameContains      string
+	Query             string
+}
+
+// Parse проверяет условия отбора, все неверные параметры перечисляются в ValidationError
+func (p MovieFilterParams) Parse() (MovieFilter, error) {
+	var verr ValidationError
+	result := MovieFilter{ActorMatch: ActorMatchAny, ActorNameContains: p.ActorNameContains, NameContains: p.NameContains,
+		Query: strings.TrimSpace(p.Query)}
+	result.ReleasedFrom = parseFilterDate(&verr, "released_from", p.ReleasedFrom)
+	result.ReleasedTo = parseFilterDate(&verr, "released_to", p.ReleasedTo)
+	if result.ReleasedFrom != nil && result.ReleasedTo != nil && result.ReleasedTo.Before(*result.ReleasedFrom) {
+		verr.Add("released_to", "must not be before released_from")
+	}
+	result.RatingMin = parseFilterRating(&verr, "rating_min", p.RatingMin)
+	result.RatingMax = parseFilterRating(&verr, "rating_max", p.RatingMax)
+	if result.RatingMin != nil && result.RatingMax != nil && *result.RatingMax < *result.RatingMin {
+		verr.Add("rating_max", "must not be less than rating_min")
+	}
+	if len(p.ActorIDs) > 0 {
+		for _, id := range strings.Split(strings.Join(p.ActorIDs, ","), ",") {
+			parsed, err := uuid.Parse(id)
+			if err != nil {
+				verr.Add("actor_id", "must be a list of UUIDs")
+				break
+			}
+			if !slices.Contains(result.ActorIDs, parsed) {
+				result.ActorIDs = append(result.ActorIDs, parsed)
+			}
+		}
+	}
+	switch p.ActorMatch {
+	case "", ActorMatchAny:
+	case ActorMatchAll:
+		result.ActorMatch = ActorMatchAll
+	default:
+		verr.Add("actor_match", "must be any or all")
+	}
+	if len(p.ActorNameContains) > 150 {
+		verr.Add("actor_name_contains", "must be at most 150 characters long")
+	}
+	if len(p.NameContains) > 150 {
+		verr.Add("name_contains", "must be at most 150 characters long")
+	}
+	if len(result.Query) > 150 {
+		verr.Add("q", "must be at most 150 characters long")
+	}
+	return result, verr.Err()
+}
+
+func parseFilterDate(verr *ValidationError, field, value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	result, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		verr.Add(field, "must be a date in YYYY-MM-DD format")
+		return nil
+	}
+	return &result
+}
+
+func parseFilterRating(verr *ValidationError, field, value string) *float64 {
+	if value == "" {
+		return nil
+	}
+	result, err := strconv.ParseFloat(value, 64)
+	if err != nil || result < 0 || result > 10 {
+		verr.Add(field, "must be a number between 0 and 10")
+		return nil
+	}
+	return &result
+}
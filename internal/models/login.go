@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Счетчики неудачных попыток входа ведутся отдельно по email и по IP адресу клиента
+const (
+	LoginKeyEmail = "email"
+	LoginKeyIP    = "ip"
+)
+
+var (
+	ErrWrongCredentials     = NewError(ErrBadRequest, "wrong_credentials", "wrong email or password")
+	ErrTooManyLoginAttempts = NewError(ErrTooManyRequests, "too_many_login_attempts", "too many login attempts, try again later")
+)
+
+// LoginLockedError возвращается, пока вход заблокирован после серии неудачных попыток
+type LoginLockedError struct {
+	Until time.Time
+}
+
+func (e *LoginLockedError) Error() string {
+	return ErrTooManyLoginAttempts.Error()
+}
+
+func (e *LoginLockedError) Unwrap() error {
+	return ErrTooManyLoginAttempts
+}
@@ -0,0 +1,43 @@
+package models
+
+import "github.com/google/uuid"
+
+var (
+	ErrInvalidToken       = NewError(ErrUnauthorized, "invalid_token", "invalid token")
+	ErrInvalidMFACode     = NewError(ErrBadRequest, "invalid_mfa_code", "invalid two-factor code")
+	ErrMFAAlreadyEnabled  = NewError(ErrConflict, "mfa_already_enabled", "two-factor authentication already enabled")
+	ErrMFASetupNotStarted = NewError(ErrConflict, "mfa_setup_not_started", "two-factor setup is not started")
+	ErrMFANotEnabled      = NewError(ErrConflict, "mfa_not_enabled", "two-factor authentication is not enabled")
+	ErrMFASetupRequired   = NewError(ErrForbidden, "mfa_setup_required", "two-factor authentication setup required")
+)
+
+// RecoveryCodesCount - количество одноразовых кодов восстановления, выдаваемых при подключении TOTP
+const RecoveryCodesCount = 10
+
+// TOTP - секрет TOTP пользователя. До подтверждения первым кодом Enabled = false и вход без второго
+// фактора продолжает работать. LastStep - номер периода последнего принятого кода
+type TOTP struct {
+	UserID   uuid.UUID
+	Secret   string
+	Enabled  bool
+	LastStep int64
+}
+
+// MFAChallenge возвращается на /auth вместо токенов, если нужен второй фактор.
+// SetupRequired означает, что роль пользователя требует TOTP, который еще не подключен
+type MFAChallenge struct {
+	MFAToken      string `json:"mfa_token"`
+	SetupRequired bool   `json:"setup_required"`
+}
+
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// MFAEnrollment - результат подключения TOTP. Коды восстановления показываются только один раз.
+// Если подключение выполнено с токеном второго фактора, сразу выдаются токены
+type MFAEnrollment struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	*TokenPair
+}
@@ -2,23 +2,114 @@ package models
 
 import (
 	"encoding/json"
-	"errors"
+	"github.com/google/uuid"
+	"strconv"
 	"time"
 )
 
+var (
+	ErrMovieNotFound = NewError(ErrNotFound, "movie_not_found", "movie not found")
+	ErrMovieExists   = NewError(ErrConflict, "movie_exists", "movie with this name already exists")
+	// ErrInvalidID - идентификатор в пути запроса не является UUID
+	ErrInvalidID = NewError(ErrBadRequest, "invalid_id", "invalid id")
+	// ErrRankWithoutQuery - сортировка по релевантности без полнотекстового запроса q
+	ErrRankWithoutQuery = NewError(ErrBadRequest, "invalid_sort", "sort by rank requires q")
+)
+
+// Movie - фильм в списках и в теле запроса, id, created_at и updated_at в запросе не учитываются.
+// Rank и Headline заполняются только в списке с полнотекстовым запросом q: Rank - релевантность фильма,
+// в Headline фрагменты описания с найденными словами выделены тегом <mark>, остальной текст экранирован для HTML
 type Movie struct {
+	ID          uuid.UUID `json:"id" readonly:"true"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
 	ReleaseDate time.Time `json:"release_date"`
 	Rating      float64   `json:"rating"`
 	ActorList   []string  `json:"actor_list"`
+	Rank        *float64  `json:"rank,omitempty" readonly:"true" example:"0.6"`
+	Headline    string    `json:"headline,omitempty" readonly:"true" example:"Мальчик <mark>волшебник</mark> ..."`
+	CreatedAt   time.Time `json:"created_at" readonly:"true"`
+	UpdatedAt   time.Time `json:"updated_at" readonly:"true"`
+}
+
+// MovieDetails - фильм с идентификатором и составом актеров
+type MovieDetails struct {
+	ID          uuid.UUID    `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	ReleaseDate time.Time    `json:"release_date"`
+	Rating      float64      `json:"rating"`
+	Cast        []CastMember `json:"cast"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// CastMember - актер в составе фильма, ID пустой, если актера нет в списке актеров
+type CastMember struct {
+	ID   *uuid.UUID `json:"id"`
+	Name string     `json:"name"`
+}
+
+// ActorNames возвращает имена актеров в том виде, в котором их принимает Movie.ActorList
+func (m *MovieDetails) ActorNames() []string {
+	result := make([]string, 0, len(m.Cast))
+	for _, val := range m.Cast {
+		result = append(result, val.Name)
+	}
+	return result
+}
+
+// movieSortFields - поля, по которым можно сортировать список фильмов, rank - только с полнотекстовым запросом
+var movieSortFields = []string{"name", "rating", "release_date", "rank"}
+
+// DefaultMovieSort - сортировка списка фильмов, если она не задана в запросе
+var DefaultMovieSort = SortSpec{{Field: "rating", Desc: true}}
+
+// RankMovieSort - сортировка списка фильмов с полнотекстовым запросом, если она не задана в запросе
+var RankMovieSort = SortSpec{{Field: "rank", Desc: true}}
+
+// ParseMovieSort разбирает сортировку списка фильмов, например -rating,release_date,name
+func ParseMovieSort(s string) (SortSpec, error) {
+	return ParseSort(s, movieSortFields)
+}
+
+// SortValues возвращает значения полей сортировки для курсора, формат значений понимает PostgreSQL.
+// Фильм без даты выхода хранится с NULL, для него вместо release_date выдается пустая строка
+func (m *Movie) SortValues(sort SortSpec) []string {
+	result := make([]string, 0, len(sort))
+	for _, val := range sort {
+		switch val.Field {
+		case "name":
+			result = append(result, m.Name)
+		case "rating":
+			result = append(result, strconv.FormatFloat(m.Rating, 'g', -1, 64))
+		case "release_date":
+			if m.ReleaseDate.IsZero() {
+				result = append(result, "")
+			} else {
+				result = append(result, m.ReleaseDate.Format(time.RFC3339Nano))
+			}
+		case "rank":
+			if m.Rank != nil {
+				result = append(result, strconv.FormatFloat(*m.Rank, 'g', -1, 64))
+			}
+		}
+	}
+	return result
 }
 
 func (m *Movie) Validate() error {
-	if len(m.Name) > 150 || len(m.Name) < 1 || len(m.Description) > 1000 || m.Rating < 0 || m.Rating > 10 {
-		return errors.New("no valid data")
+	var verr ValidationError
+	if len(m.Name) < 1 || len(m.Name) > 150 {
+		verr.Add("name", "must be 1-150 characters long")
 	}
-	return nil
+	if len(m.Description) > 1000 {
+		verr.Add("description", "must be at most 1000 characters long")
+	}
+	if m.Rating < 0 || m.Rating > 10 {
+		verr.Add("rating", "must be between 0 and 10")
+	}
+	return verr.Err()
 }
 
 func (m *Movie) UnmarshalJSON(data []byte) error {
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+var (
+	ErrOIDCDisabled      = NewError(ErrNotFound, "oidc_disabled", "oidc login is not configured")
+	ErrInvalidOIDCState  = NewError(ErrBadRequest, "invalid_oidc_state", "invalid or expired oidc state")
+	ErrOIDCProvider      = NewError(ErrUpstream, "oidc_provider_error", "oidc provider error")
+	ErrInvalidIDToken    = NewError(ErrUnauthorized, "invalid_id_token", "invalid id token")
+	ErrOIDCNoRoles       = NewError(ErrForbidden, "oidc_no_roles", "no roles are mapped for user groups")
+	ErrOIDCEmailConflict = NewError(ErrConflict, "oidc_email_conflict", "user with this email already exists and email is not verified by provider")
+)
+
+// OIDCState - параметры начатого входа через провайдер. Хранится до возврата пользователя на callback,
+// сам state хранится в виде хеша
+type OIDCState struct {
+	Hash         string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
+
+// OIDCClaims - проверенные данные пользователя из ID токена провайдера
+type OIDCClaims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Groups        []string
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidPagination = NewError(ErrValidation, "invalid_pagination", "invalid pagination parameters")
+	ErrInvalidCursor     = NewError(ErrBadRequest, "invalid_cursor", "invalid cursor")
+)
+
+// PageParams - параметры страницы из строки запроса. limit и cursor задают постраничный вывод по курсору,
+// page и per_page - по номеру страницы, одновременно использовать оба способа нельзя
+type PageParams struct {
+	Limit   string
+	Cursor  string
+	Page    string
+	PerPage string
+}
+
+// PageInfo - сведения о странице в ответе. Для вывода по курсору заполняются limit и next_cursor,
+// для вывода по номеру страницы - page и per_page
+type PageInfo struct {
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Page       int    `json:"page,omitempty"`
+	PerPage    int    `json:"per_page,omitempty"`
+}
+
+// Cursor - позиция после последней записи страницы: значения полей сортировки и UUID записи.
+// Sort - сортировка, для которой выдан курсор, с другой сортировкой курсор не принимается
+type Cursor struct {
+	Sort   string    `json:"s"`
+	Values []string  `json:"v"`
+	ID     uuid.UUID `json:"id"`
+}
+
+// Encode возвращает непрозрачное для клиента представление курсора
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor разбирает курсор, выданный Encode, для сортировки sort
+func DecodeCursor(s string, sort SortSpec) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var result Cursor
+	err = json.Unmarshal(data, &result)
+	if err != nil || result.Sort != sort.String() || len(result.Values) != len(sort) {
+		return nil, ErrInvalidCursor
+	}
+	return &result, nil
+}
+
+// MovieList - страница списка фильмов
+type MovieList struct {
+	Movies []*Movie `json:"movies"`
+	PageInfo
+}
+
+// ActorList - страница списка актеров
+type ActorList struct {
+	Actors []*ActorDetails `json:"actors"`
+	PageInfo
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+var (
+	ErrInvalidResetToken      = NewError(ErrBadRequest, "invalid_reset_token", "invalid or expired password reset token")
+	ErrPasswordResetThrottled = NewError(ErrTooManyRequests, "password_reset_throttled", "password reset was requested recently")
+)
+
+// PasswordResetToken - одноразовый токен восстановления пароля, хранится в виде хеша
+type PasswordResetToken struct {
+	Hash      string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// MailMessage - текстовое письмо одному получателю
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
@@ -0,0 +1,37 @@
+package models
+
+var ErrPermissionDenied = NewError(ErrForbidden, "permission_denied", "permission denied")
+
+// Permission - именованное право доступа. Роли хранятся в БД как наборы прав,
+// пользователю может быть назначено несколько ролей
+type Permission string
+
+const (
+	PermMovieRead   Permission = "movie:read"
+	PermMovieWrite  Permission = "movie:write"
+	PermMovieDelete Permission = "movie:delete"
+	PermActorRead   Permission = "actor:read"
+	PermActorWrite  Permission = "actor:write"
+	PermActorDelete Permission = "actor:delete"
+	PermUserManage  Permission = "user:manage"
+)
+
+// Permissions - все известные права, используется для проверки прав, запрошенных для API ключа
+var Permissions = []Permission{
+	PermMovieRead,
+	PermMovieWrite,
+	PermMovieDelete,
+	PermActorRead,
+	PermActorWrite,
+	PermActorDelete,
+	PermUserManage,
+}
+
+func (p Permission) Valid() bool {
+	for _, known := range Permissions {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+// Principal - тот, от чьего имени выполняется запрос. Для API ключа UserID - создатель ключа,
+// права - права ключа, которые есть у создателя
+type Principal struct {
+	UserID      uuid.UUID
+	Email       string
+	Roles       []string
+	Permissions []Permission
+	APIKeyID    *uuid.UUID
+}
+
+func (p *Principal) HasPermission(permission Permission) bool {
+	for _, owned := range p.Permissions {
+		if owned == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal возвращает контекст запроса с проверенным Principal
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext возвращает Principal, сохраненный middleware аутентификации
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	SearchKindMovie = "movie"
+	SearchKindActor = "actor"
+)
+
+// SearchKinds - виды объектов, по которым ведется поиск, в порядке вывода при равной оценке
+var SearchKinds = []string{SearchKindMovie, SearchKindActor}
+
+// SearchParams - параметры поиска из строки запроса, kind ограничивает поиск одним видом объектов
+type SearchParams struct {
+	Query string
+	Kind  string
+	Limit string
+}
+
+// SearchResult - найденный фильм или актер. Score - похожесть названия на запрос от 0 до 1,
+// в Highlight слова названия, похожие на слова запроса, выделены тегом <mark>, остальной текст экранирован для HTML
+type SearchResult struct {
+	Kind      string    `json:"kind" example:"movie"`
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name" example:"Inception"`
+	Score     float64   `json:"score" example:"0.6"`
+	Highlight string    `json:"highlight" example:"<mark>Inception</mark>"`
+}
+
+// SearchResults - результаты поиска, упорядоченные по убыванию Score
+type SearchResults struct {
+	Query   string          `json:"query"`
+	Results []*SearchResult `json:"results"`
+}
+
+// SuggestParams - параметры подсказок из строки запроса
+type SuggestParams struct {
+	Prefix string
+	Kind   string
+}
+
+// Suggestion - фильм или актер, название которого начинается с префикса
+type Suggestion struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name" example:"Tom Hanks"`
+}
+
+// Suggestions - подсказки по префиксу, упорядоченные по названию
+type Suggestions struct {
+	Kind        string        `json:"kind" example:"actor"`
+	Prefix      string        `json:"prefix" example:"tom"`
+	Suggestions []*Suggestion `json:"suggestions"`
+}
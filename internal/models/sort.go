@@ -0,0 +1,58 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrInvalidSort = NewError(ErrBadRequest, "invalid_sort", "invalid sort parameter")
+
+// SortField - поле сортировки и направление
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// SortSpec - сортировка по нескольким полям в порядке приоритета. В запросе задается как sort=-rating,release_date,name,
+// минус перед полем означает сортировку по убыванию
+type SortSpec []SortField
+
+// ParseSort разбирает сортировку из запроса, поля проверяются по списку allowed. Пустая строка дает пустую
+// сортировку, для неизвестного поля возвращается ошибка со списком допустимых полей
+func ParseSort(s string, allowed []string) (SortSpec, error) {
+	if s == "" {
+		return nil, nil
+	}
+	result := make(SortSpec, 0)
+	for _, val := range strings.Split(s, ",") {
+		field := SortField{Field: strings.TrimPrefix(val, "-"), Desc: strings.HasPrefix(val, "-")}
+		if field.Field == "" || slices.ContainsFunc(result, func(f SortField) bool { return f.Field == field.Field }) {
+			return nil, ErrInvalidSort
+		}
+		if !slices.Contains(allowed, field.Field) {
+			return nil, NewError(ErrBadRequest, ErrInvalidSort.Code,
+				fmt.Sprintf("unknown sort field %q, allowed fields: %s", field.Field, strings.Join(allowed, ", ")))
+		}
+		result = append(result, field)
+	}
+	return result, nil
+}
+
+// String возвращает сортировку в том виде, в котором она задается в запросе
+func (s SortSpec) String() string {
+	fields := make([]string, 0, len(s))
+	for _, val := range s {
+		if val.Desc {
+			fields = append(fields, "-"+val.Field)
+		} else {
+			fields = append(fields, val.Field)
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// HasField сообщает, что сортировка использует поле field
+func (s SortSpec) HasField(field string) bool {
+	return slices.ContainsFunc(s, func(f SortField) bool { return f.Field == field })
+}
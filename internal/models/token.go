@@ -0,0 +1,28 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+var (
+	ErrInvalidRefreshToken = NewError(ErrUnauthorized, "invalid_refresh_token", "invalid refresh token")
+	ErrRefreshTokenReused  = NewError(ErrUnauthorized, "refresh_token_reused", "refresh token reuse detected")
+	ErrTokenRevoked        = NewError(ErrUnauthorized, "token_revoked", "token revoked")
+)
+
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken - запись о refresh токене в БД, сам токен хранится только в виде хеша.
+// Токены, полученные последовательной ротацией, относятся к одному семейству FamilyID
+type RefreshToken struct {
+	Hash      string
+	FamilyID  uuid.UUID
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+}
@@ -1,14 +1,132 @@
 package models
 
+import (
+	"crypto/subtle"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"net/mail"
+	"strings"
+	"unicode"
+)
+
+// Базовые роли, набор прав каждой роли задается в таблице role_permissions
+const (
+	RoleUser   = "user"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// PasswordAlgoNone - пароль не задан, пользователь входит только через внешний провайдер
+const (
+	PasswordAlgoPlain  = "plain"
+	PasswordAlgoBcrypt = "bcrypt"
+	PasswordAlgoNone   = "none"
+)
+
+// PasswordCost - стоимость bcrypt, хеши с меньшей стоимостью пересчитываются при входе
+const PasswordCost = bcrypt.DefaultCost
+
+const (
+	passwordMinLength = 8
+	passwordMaxLength = 72
+)
+
+var (
+	ErrUserExists   = NewError(ErrConflict, "user_exists", "user with this email already exists")
+	ErrUserNotFound = NewError(ErrNotFound, "user_not_found", "user not found")
+	ErrInvalidEmail = NewError(ErrValidation, "invalid_email", "invalid email")
+	ErrWeakPassword = NewError(ErrValidation, "weak_password", "password must be 8-72 characters long and contain letters and digits")
+	ErrUserDisabled = NewError(ErrForbidden, "user_disabled", "user is disabled")
+	ErrUnknownRole  = NewError(ErrValidation, "unknown_role", "unknown role")
+	ErrEmptyRoles   = NewError(ErrValidation, "empty_roles", "user must have at least one role")
+)
+
 type User struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Role     string `json:"role"`
+	ID           uuid.UUID `json:"id"`
+	Email        string    `json:"email"`
+	Password     string    `json:"-"`
+	PasswordAlgo string    `json:"-"`
+	Roles        []string  `json:"roles"`
+	Disabled     bool      `json:"disabled"`
+}
+
+// UserList - страница списка пользователей, Total - общее количество пользователей
+type UserList struct {
+	Users  []*User `json:"users"`
+	Total  int     `json:"total"`
+	Limit  int     `json:"limit"`
+	Offset int     `json:"offset"`
+}
+
+// NormalizeEmail приводит email к виду, в котором он хранится в БД
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func ValidateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return ErrInvalidEmail
+	}
+	domain := email[strings.LastIndex(email, "@")+1:]
+	if !strings.Contains(domain, ".") {
+		return ErrInvalidEmail
+	}
+	return nil
+}
+
+func ValidatePassword(password string) error {
+	if len(password) < passwordMinLength || len(password) > passwordMaxLength {
+		return ErrWeakPassword
+	}
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return ErrWeakPassword
+	}
+	return nil
 }
 
 func (u *User) CheckCreds(email, password string) bool {
-	if u.Email != email || u.Password != password {
+	if u.Email != email {
+		return false
+	}
+	switch u.PasswordAlgo {
+	case PasswordAlgoBcrypt:
+		return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) == nil
+	case PasswordAlgoPlain, "":
+		return subtle.ConstantTimeCompare([]byte(u.Password), []byte(password)) == 1
+	default:
 		return false
 	}
-	return true
+}
+
+// NeedsRehash сообщает, что пароль хранится в открытом виде или с устаревшей стоимостью хеширования
+func (u *User) NeedsRehash() bool {
+	if u.PasswordAlgo != PasswordAlgoBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(u.Password))
+	if err != nil {
+		return true
+	}
+	return cost < PasswordCost
+}
+
+// SetPassword заменяет пароль пользователя на bcrypt хеш
+func (u *User) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), PasswordCost)
+	if err != nil {
+		return err
+	}
+	u.Password = string(hash)
+	u.PasswordAlgo = PasswordAlgoBcrypt
+	return nil
 }
@@ -0,0 +1,132 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/dgrijalva/jwt-go"
+	"time"
+)
+
+// verifyIDToken проверяет подпись ID токена ключом провайдера и claims по OpenID Connect Core 3.1.3.7
+func (p *Provider) verifyIDToken(ctx context.Context, raw, nonce string) (*models.OIDCClaims, error) {
+	_, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{}
+	// проверка времени выполняется ниже с допуском clock_skew
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	_, err = parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := p.key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		// alg из заголовка должен совпадать с алгоритмом ключа, иначе возможна подмена алгоритма
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, errors.New("unexpected signing method from token")
+		}
+		return key.key, nil
+	})
+	if errors.Is(err, models.ErrOIDCProvider) {
+		return nil, err
+	}
+	var validationErr *jwt.ValidationError
+	if errors.As(err, &validationErr) && errors.Is(validationErr.Inner, models.ErrOIDCProvider) {
+		return nil, validationErr.Inner
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidIDToken, err)
+	}
+
+	err = p.validateClaims(claims, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidIDToken, err)
+	}
+	email, _ := claims["email"].(string)
+	return &models.OIDCClaims{
+		Issuer:        p.cfg.Issuer,
+		Subject:       claims["sub"].(string),
+		Email:         email,
+		EmailVerified: boolClaim(claims["email_verified"]),
+		Groups:        stringsClaim(claims[p.cfg.GroupsClaim]),
+	}, nil
+}
+
+func (p *Provider) validateClaims(claims jwt.MapClaims, nonce string) error {
+	now := p.now()
+	skew := p.cfg.ClockSkew
+	if iss, _ := claims["iss"].(string); iss != p.cfg.Issuer {
+		return errors.New("unexpected issuer")
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return errors.New("subject is empty")
+	}
+	audience := stringsClaim(claims["aud"])
+	if !contains(audience, p.cfg.ClientID) {
+		return errors.New("unexpected audience")
+	}
+	// при нескольких получателях токен должен быть выдан именно этому клиенту
+	if azp, ok := claims["azp"].(string); (ok || len(audience) > 1) && azp != p.cfg.ClientID {
+		return errors.New("unexpected authorized party")
+	}
+	exp, ok := timeClaim(claims["exp"])
+	if !ok || now.After(exp.Add(skew)) {
+		return errors.New("token is expired")
+	}
+	iat, ok := timeClaim(claims["iat"])
+	if !ok || now.Before(iat.Add(-skew)) {
+		return errors.New("token is issued in the future")
+	}
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce == "" || tokenNonce != nonce {
+		return errors.New("unexpected nonce")
+	}
+	return nil
+}
+
+func timeClaim(value interface{}) (time.Time, bool) {
+	seconds, ok := value.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// boolClaim учитывает провайдеров, которые передают email_verified строкой
+func boolClaim(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// stringsClaim возвращает claim, который может быть строкой или массивом строк, например aud или groups
+func stringsClaim(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/dgrijalva/jwt-go"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// keysRefreshInterval - не чаще этого ключи провайдера загружаются повторно из-за неизвестного kid,
+// иначе токены с произвольным kid превращали бы каждый запрос в обращение к провайдеру
+const keysRefreshInterval = time.Minute
+
+type publicKey struct {
+	method jwt.SigningMethod
+	key    interface{}
+}
+
+type keySet struct {
+	keys      map[string]*publicKey
+	fetchedAt time.Time
+}
+
+// key возвращает ключ провайдера по kid, загружая ключи при первом обращении и при неизвестном kid.
+// Если kid не передан, используется единственный ключ провайдера
+func (p *Provider) key(ctx context.Context, kid string) (*publicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.keys != nil {
+		if key, ok := p.keys.lookup(kid); ok {
+			return key, nil
+		}
+		if p.now().Sub(p.keys.fetchedAt) < keysRefreshInterval {
+			return nil, errors.New("unknown key id")
+		}
+	}
+
+	keys, err := p.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	if key, ok := p.keys.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, errors.New("unknown key id")
+}
+
+func (s *keySet) lookup(kid string) (*publicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// fetchKeys загружает ключи по jwks_uri, вызывается под блокировкой. Ключи неподдерживаемых типов пропускаются
+func (p *Provider) fetchKeys(ctx context.Context) (*keySet, error) {
+	if p.discovery == nil {
+		return nil, fmt.Errorf("%w: discovery document is not loaded", models.ErrOIDCProvider)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.discovery.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set models.JWKSet
+	err = p.doJSON(req, &set)
+	if err != nil {
+		return nil, err
+	}
+	result := &keySet{keys: make(map[string]*publicKey, len(set.Keys)), fetchedAt: p.now()}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(jwk)
+		if err != nil {
+			continue
+		}
+		result.keys[jwk.Kid] = key
+	}
+	return result, nil
+}
+
+func parseJWK(jwk models.JWK) (*publicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if n.BitLen() < 2048 || !e.IsInt64() {
+			return nil, errors.New("unsupported rsa key")
+		}
+		method := jwt.SigningMethod(jwt.SigningMethodRS256)
+		switch jwk.Alg {
+		case "", "RS256":
+		case "RS384":
+			method = jwt.SigningMethodRS384
+		case "RS512":
+			method = jwt.SigningMethodRS512
+		default:
+			return nil, fmt.Errorf("unsupported algorithm %s", jwk.Alg)
+		}
+		return &publicKey{method: method, key: &rsa.PublicKey{N: n, E: int(e.Int64())}}, nil
+	case "EC":
+		var curve elliptic.Curve
+		var method jwt.SigningMethod
+		switch jwk.Crv {
+		case "P-256":
+			curve, method = elliptic.P256(), jwt.SigningMethodES256
+		case "P-384":
+			curve, method = elliptic.P384(), jwt.SigningMethodES384
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", jwk.Crv)
+		}
+		if jwk.Alg != "" && jwk.Alg != method.Alg() {
+			return nil, fmt.Errorf("unsupported algorithm %s", jwk.Alg)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("ec point is not on curve")
+		}
+		return &publicKey{method: method, key: &ecdsa.PublicKey{Curve: curve, X: x, Y: y}}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", jwk.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) == 0 {
+		return nil, errors.New("empty key parameter")
+	}
+	return new(big.Int).SetBytes(buf), nil
+}
@@ -0,0 +1,389 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID     = "movies-client"
+	testClientSecret = "client-secret"
+	testRedirectURL  = "http://localhost:8080/auth/oidc/callback"
+	testCode         = "test-code"
+	testVerifier     = "test-code-verifier"
+	testNonce        = "test-nonce"
+)
+
+// stubIdP - локальный OpenID Connect провайдер: discovery, JWKS и token endpoint,
+// token endpoint проверяет code_verifier по code_challenge так же, как настоящий провайдер
+type stubIdP struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu         sync.Mutex
+	key        *rsa.PrivateKey
+	kid        string
+	issuer     string
+	challenge  string
+	claims     jwt.MapClaims
+	jwksCalls  int
+	tokenCalls int
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	idp := &stubIdP{t: t, key: newRSAKey(t), kid: "key-1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc(discoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		writeJSON(w, discovery{
+			Issuer:                idp.issuer,
+			AuthorizationEndpoint: idp.server.URL + "/authorize",
+			TokenEndpoint:         idp.server.URL + "/token",
+			JWKSURI:               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		idp.jwksCalls++
+		writeJSON(w, models.JWKSet{Keys: []models.JWK{{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			Kid: idp.kid,
+			N:   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	idp.issuer = idp.server.URL
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *stubIdP) token(w http.ResponseWriter, r *http.Request) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.tokenCalls++
+	clientID, secret, ok := r.BasicAuth()
+	if r.Method != http.MethodPost || !ok || clientID != testClientID || secret != testClientSecret {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+	if r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("code") != testCode ||
+		r.PostFormValue("redirect_uri") != testRedirectURL || codeChallenge(r.PostFormValue("code_verifier")) != idp.challenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.claims)
+	token.Header["kid"] = idp.kid
+	signed, err := token.SignedString(idp.key)
+	require.NoError(idp.t, err)
+	writeJSON(w, tokenResponse{IDToken: signed, TokenType: "Bearer"})
+}
+
+// defaultClaims возвращает claims корректного ID токена
+func (idp *stubIdP) defaultClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            idp.issuer,
+		"sub":            "user-1",
+		"aud":            testClientID,
+		"exp":            now.Add(time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          testNonce,
+		"email":          "user@example.com",
+		"email_verified": true,
+		"groups":         []string{"movies-admins", "staff"},
+	}
+}
+
+func (idp *stubIdP) config() models.OIDCConfig {
+	return models.OIDCConfig{
+		Issuer:         idp.issuer,
+		ClientID:       testClientID,
+		ClientSecret:   testClientSecret,
+		RedirectURL:    testRedirectURL,
+		Scopes:         []string{"email", "profile"},
+		GroupsClaim:    "groups",
+		ClockSkew:      30 * time.Second,
+		RequestTimeout: 5 * time.Second,
+	}
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func newTestProvider(t *testing.T, idp *stubIdP) *Provider {
+	provider, err := NewProvider(idp.config(), idp.server.Client())
+	require.NoError(t, err)
+	return provider
+}
+
+func TestNewProvider(t *testing.T) {
+	_, err := NewProvider(models.OIDCConfig{ClientID: testClientID, RedirectURL: testRedirectURL}, nil)
+	assert.Error(t, err)
+}
+
+func TestProvider_AuthCodeURL(t *testing.T) {
+	idp := newStubIdP(t)
+	provider := newTestProvider(t, idp)
+
+	authURL, err := provider.AuthCodeURL(context.TODO(), "test-state", testNonce, testVerifier)
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, idp.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	query := parsed.Query()
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, testClientID, query.Get("client_id"))
+	assert.Equal(t, testRedirectURL, query.Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+	assert.Equal(t, "test-state", query.Get("state"))
+	assert.Equal(t, testNonce, query.Get("nonce"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, codeChallenge(testVerifier), query.Get("code_challenge"))
+	assert.NotContains(t, authURL, testVerifier)
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	testTable := []struct {
+		name     string
+		claims   func(idp *stubIdP) jwt.MapClaims
+		verifier string
+		nonce    string
+		expected *models.OIDCClaims
+		err      error
+	}{
+		{
+			name:     "OK",
+			claims:   func(idp *stubIdP) jwt.MapClaims { return idp.defaultClaims() },
+			verifier: testVerifier,
+			nonce:    testNonce,
+			expected: &models.OIDCClaims{
+				Subject:       "user-1",
+				Email:         "user@example.com",
+				EmailVerified: true,
+				Groups:        []string{"movies-admins", "staff"},
+			},
+		},
+		{
+			name: "audience list with azp",
+			claims: func(idp *stubIdP) jwt.MapClaims {
+				claims := idp.defaultClaims()
+				claims["aud"] = []string{testClientID, "other-client"}
+				claims["azp"] = testClientID
+				claims["email_verified"] = "false"
+				claims["groups"] = "staff"
+				return claims
+			},
+			verifier: testVerifier,
+			nonce:    testNonce,
+			expected: &models.OIDCClaims{
+				Subject: "user-1",
+				Email:   "user@example.com",
+				Groups:  []string{"staff"},
+			},
+		},
+		{
+			name:     "wrong code verifier",
+			claims:   func(idp *stubIdP) jwt.MapClaims { return idp.defaultClaims() },
+			verifier: "other-verifier",
+			nonce:    testNonce,
+			err:      models.ErrOIDCProvider,
+		},
+		{
+			name:     "wrong nonce",
+			claims:   func(idp *stubIdP) jwt.MapClaims { return idp.defaultClaims() },
+			verifier: testVerifier,
+			nonce:    "other-nonce",
+			err:      models.ErrInvalidIDToken,
+		},
+		{
+			name: "wrong audience",
+			claims: func(idp *stubIdP) jwt.MapClaims {
+				claims := idp.defaultClaims()
+				claims["aud"] = "other-client"
+				return claims
+			},
+			verifier: testVerifier,
+			nonce:    testNonce,
+			err:      models.ErrInvalidIDToken,
+		},
+		{
+			name: "audience list without azp",
+			claims: func(idp *stubIdP) jwt.MapClaims {
+				claims := idp.defaultClaims()
+				claims["aud"] = []string{testClientID, "other-client"}
+				return claims
+			},
+			verifier: testVerifier,
+			nonce:    testNonce,
+			err:      models.ErrInvalidIDToken,
+		},
+		{
+			name: "wrong issuer",
+			claims: func(idp *stubIdP) jwt.MapClaims {
+				claims := idp.defaultClaims()
+				claims["iss"] = "https://evil.example.com"
+				return claims
+			},
+			verifier: testVerifier,
+			nonce:    testNonce,
+			err:      models.ErrInvalidIDToken,
+		},
+		{
+			name: "expired",
+			claims: func(idp *stubIdP) jwt.MapClaims {
+				claims := idp.defaultClaims()
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+				return claims
+			},
+			verifier: testVerifier,
+			nonce:    testNonce,
+			err:      models.ErrInvalidIDToken,
+		},
+		{
+			name: "expired within clock skew",
+			claims: func(idp *stubIdP) jwt.MapClaims {
+				claims := idp.defaultClaims()
+				claims["exp"] = time.Now().Add(-10 * time.Second).Unix()
+				delete(claims, "email")
+				delete(claims, "groups")
+				return claims
+			},
+			verifier: testVerifier,
+			nonce:    testNonce,
+			expected: &models.OIDCClaims{
+				Subject:       "user-1",
+				EmailVerified: true,
+			},
+		},
+		{
+			name: "issued in the future",
+			claims: func(idp *stubIdP) jwt.MapClaims {
+				claims := idp.defaultClaims()
+				claims["iat"] = time.Now().Add(time.Hour).Unix()
+				return claims
+			},
+			verifier: testVerifier,
+			nonce:    testNonce,
+			err:      models.ErrInvalidIDToken,
+		},
+		{
+			name: "empty subject",
+			claims: func(idp *stubIdP) jwt.MapClaims {
+				claims := idp.defaultClaims()
+				claims["sub"] = ""
+				return claims
+			},
+			verifier: testVerifier,
+			nonce:    testNonce,
+			err:      models.ErrInvalidIDToken,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.name, func(t *testing.T) {
+			idp := newStubIdP(t)
+			idp.challenge = codeChallenge(testVerifier)
+			idp.claims = tc.claims(idp)
+			provider := newTestProvider(t, idp)
+
+			result, err := provider.Exchange(context.TODO(), testCode, tc.verifier, tc.nonce)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Nil(t, result)
+				return
+			}
+			require.NoError(t, err)
+			tc.expected.Issuer = idp.issuer
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestProvider_ExchangeForgedToken(t *testing.T) {
+	idp := newStubIdP(t)
+	idp.challenge = codeChallenge(testVerifier)
+	idp.claims = idp.defaultClaims()
+	provider := newTestProvider(t, idp)
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.defaultClaims())
+	forged.Header["kid"] = idp.kid
+	raw, err := forged.SignedString(newRSAKey(t))
+	require.NoError(t, err)
+	_, err = provider.verifyIDToken(context.TODO(), raw, testNonce)
+	assert.ErrorIs(t, err, models.ErrInvalidIDToken)
+
+	// HS256 с публичным ключом в качестве секрета - классическая подмена алгоритма
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, idp.defaultClaims())
+	hmacToken.Header["kid"] = idp.kid
+	raw, err = hmacToken.SignedString([]byte("secret"))
+	require.NoError(t, err)
+	_, err = provider.verifyIDToken(context.TODO(), raw, testNonce)
+	assert.ErrorIs(t, err, models.ErrInvalidIDToken)
+}
+
+func TestProvider_KeyRotation(t *testing.T) {
+	idp := newStubIdP(t)
+	idp.challenge = codeChallenge(testVerifier)
+	idp.claims = idp.defaultClaims()
+	provider := newTestProvider(t, idp)
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+
+	_, err := provider.Exchange(context.TODO(), testCode, testVerifier, testNonce)
+	require.NoError(t, err)
+	assert.Equal(t, 1, idp.jwksCalls)
+
+	// провайдер сменил ключ: неизвестный kid не загружает ключи повторно чаще раза в keysRefreshInterval
+	idp.mu.Lock()
+	idp.key, idp.kid = newRSAKey(t), "key-2"
+	idp.mu.Unlock()
+	_, err = provider.Exchange(context.TODO(), testCode, testVerifier, testNonce)
+	assert.ErrorIs(t, err, models.ErrInvalidIDToken)
+	assert.Equal(t, 1, idp.jwksCalls)
+
+	now = now.Add(keysRefreshInterval)
+	result, err := provider.Exchange(context.TODO(), testCode, testVerifier, testNonce)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", result.Subject)
+	assert.Equal(t, 2, idp.jwksCalls)
+}
+
+func TestProvider_DiscoveryIssuerMismatch(t *testing.T) {
+	idp := newStubIdP(t)
+	cfg := idp.config()
+	idp.issuer = "https://evil.example.com"
+	provider, err := NewProvider(cfg, idp.server.Client())
+	require.NoError(t, err)
+
+	_, err = provider.AuthCodeURL(context.TODO(), "test-state", testNonce, testVerifier)
+	assert.ErrorIs(t, err, models.ErrOIDCProvider)
+	assert.Equal(t, 0, idp.tokenCalls)
+}
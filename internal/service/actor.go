@@ -2,22 +2,26 @@ package service
 
 import (
 	"context"
-	"errors"
 	"github.com/ast3am/VKintern-movies/internal/models"
 	"github.com/google/uuid"
 )
 
-func (s *Service) CreateActor(ctx context.Context, actor models.Actor) error {
-	if actor.Name == "" {
-		return errors.New("empty name")
+func (s *Service) CreateActor(ctx context.Context, actor models.Actor) (*models.ActorDetails, error) {
+	err := actor.Validate()
+	if err != nil {
+		return nil, err
 	}
 	id, _ := uuid.NewUUID()
-	err := s.db.CreateActor(ctx, id, actor)
-	return err
+	result, err := s.db.CreateActor(ctx, id, actor)
+	if err != nil {
+		return nil, err
+	}
+	s.suggestions.invalidate(models.SearchKindActor)
+	return result, nil
 }
 
 func (s *Service) DeleteActor(ctx context.Context, id string) error {
-	uid, err := uuid.Parse(id)
+	uid, err := parseID(id)
 	if err != nil {
 		return err
 	}
@@ -25,11 +29,12 @@ func (s *Service) DeleteActor(ctx context.Context, id string) error {
 	if err != nil {
 		return err
 	}
+	s.suggestions.invalidate(models.SearchKindActor)
 	return nil
 }
 
 func (s *Service) UpdateActor(ctx context.Context, id string, actor models.Actor) error {
-	uid, err := uuid.Parse(id)
+	uid, err := parseID(id)
 	if err != nil {
 		return err
 	}
@@ -46,17 +51,59 @@ func (s *Service) UpdateActor(ctx context.Context, id string, actor models.Actor
 	if actor.BirthDate.IsZero() {
 		actor.BirthDate = nowActor.BirthDate
 	}
+	err = actor.Validate()
+	if err != nil {
+		return err
+	}
+	err = s.db.UpdateActor(ctx, uid, actor)
+	if err != nil {
+		return err
+	}
+	s.suggestions.invalidate(models.SearchKindActor)
+	return nil
+}
+
+func (s *Service) GetActorByID(ctx context.Context, id string) (*models.ActorDetails, error) {
+	uid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.db.GetActorByUUID(ctx, uid)
+}
+
+// ReplaceActor полностью заменяет информацию об актере, в отличие от UpdateActor пустые поля не берутся из БД
+func (s *Service) ReplaceActor(ctx context.Context, id string, actor models.Actor) error {
+	uid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	err = actor.Validate()
+	if err != nil {
+		return err
+	}
 	err = s.db.UpdateActor(ctx, uid, actor)
 	if err != nil {
 		return err
 	}
+	s.suggestions.invalidate(models.SearchKindActor)
 	return nil
 }
 
-func (s *Service) GetActorList(ctx context.Context) (map[string][]string, error) {
-	result, err := s.db.GetActorList(ctx)
+// GetActorList возвращает страницу актеров, отсортированных по имени
+func (s *Service) GetActorList(ctx context.Context, params models.PageParams) (*models.ActorList, error) {
+	page, err := s.parsePage(params, models.ActorListSort)
 	if err != nil {
 		return nil, err
 	}
+	actors, total, err := s.db.GetActorList(ctx, page.after, page.fetch(), page.offset())
+	if err != nil {
+		return nil, err
+	}
+	result := &models.ActorList{Actors: actors, PageInfo: page.info(total)}
+	if page.hasMore(len(actors)) {
+		result.Actors = actors[:page.limit]
+		last := result.Actors[page.limit-1]
+		result.NextCursor = page.cursor([]string{last.Name}, last.ID)
+	}
 	return result, nil
 }
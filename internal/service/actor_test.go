@@ -21,8 +21,9 @@ func GetDate(date string) time.Time {
 func TestService_CreateActor(t *testing.T) {
 	mockDB := mocks.NewDb(t)
 	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
 	ctx := context.TODO()
-	s := NewService(mockDB, log)
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
 	actorTrue := models.Actor{
 		Name:      "Tom Hanks",
 		Gender:    "Male",
@@ -34,20 +35,29 @@ func TestService_CreateActor(t *testing.T) {
 		BirthDate: GetDate("1956-07-09"),
 	}
 
-	mockDB.On("CreateActor", ctx, mock.AnythingOfType("uuid.UUID"), actorTrue).Return(nil)
+	created := &models.ActorDetails{Name: "Tom Hanks", Gender: "Male", BirthDate: GetDate("1956-07-09")}
+	mockDB.On("CreateActor", ctx, mock.AnythingOfType("uuid.UUID"), actorTrue).Return(func(ctx context.Context, id uuid.UUID, actor models.Actor) (*models.ActorDetails, error) {
+		created.ID = id
+		return created, nil
+	})
 	//true
-	err := s.CreateActor(ctx, actorTrue)
+	result, err := s.CreateActor(ctx, actorTrue)
 	assert.Nil(t, err)
+	assert.Equal(t, created, result)
+	assert.NotEqual(t, uuid.Nil, result.ID)
 	//false
-	err = s.CreateActor(ctx, actorFalse)
-	assert.EqualError(t, err, "empty name")
+	_, err = s.CreateActor(ctx, actorFalse)
+	var verr *models.ValidationError
+	assert.ErrorAs(t, err, &verr)
+	assert.Equal(t, []models.FieldError{{Field: "name", Message: "must not be empty"}}, verr.Fields)
 }
 
 func TestService_DeleteActor(t *testing.T) {
 	mockDB := mocks.NewDb(t)
 	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
 	ctx := context.TODO()
-	s := NewService(mockDB, log)
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
 	validID := "b0482c7a-1a4c-4a3c-9463-35f0036a0d60"
 	validIDnoUser := "7cfd7e7f-3a27-46c5-9c3e-3b3d3b1c1416"
 	noValidID := "b0482c7a-1a4c-4a3"
@@ -71,12 +81,13 @@ func TestService_DeleteActor(t *testing.T) {
 func TestService_UpdateActor(t *testing.T) {
 	mockDB := mocks.NewDb(t)
 	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
 	ctx := context.TODO()
-	s := NewService(mockDB, log)
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
 	validID := "b0482c7a-1a4c-4a3c-9463-35f0036a0d60"
 	validIDnoUser := "7cfd7e7f-3a27-46c5-9c3e-3b3d3b1c1416"
 	noValidID := "b0482c7a-1a4c-4a3"
-	actor := &models.Actor{
+	actor := &models.ActorDetails{
 		Name:      "Tom Hanks",
 		Gender:    "Male",
 		BirthDate: GetDate("1956-07-09"),
@@ -109,25 +120,102 @@ func TestService_UpdateActor(t *testing.T) {
 	//noUser
 	err = s.UpdateActor(ctx, validIDnoUser, testData[0])
 	assert.NotNil(t, err)
+
+	//после слияния с текущими значениями актер проверяется так же, как в ReplaceActor
+	mockDB = mocks.NewDb(t)
+	s = NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	mockDB.On("GetActorByUUID", ctx, uuid.MustParse(validID)).Return(&models.ActorDetails{Gender: "Male"}, nil).Once()
+	err = s.UpdateActor(ctx, validID, models.Actor{Gender: "Female"})
+	var verr *models.ValidationError
+	assert.ErrorAs(t, err, &verr)
+	assert.Equal(t, []models.FieldError{{Field: "name", Message: "must not be empty"}}, verr.Fields)
+	mockDB.AssertNotCalled(t, "UpdateActor", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestService_GetActorByID(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	validID := "b0482c7a-1a4c-4a3c-9463-35f0036a0d60"
+	actor := &models.ActorDetails{ID: uuid.MustParse(validID), Name: "Tom Hanks", Filmography: []models.ActorMovie{}}
+	mockDB.On("GetActorByUUID", ctx, uuid.MustParse(validID)).Return(actor, nil).Once()
+	result, err := s.GetActorByID(ctx, validID)
+	assert.Nil(t, err)
+	assert.Equal(t, actor, result)
+	//not found
+	mockDB.On("GetActorByUUID", ctx, uuid.MustParse(validID)).Return(nil, models.ErrActorNotFound).Once()
+	_, err = s.GetActorByID(ctx, validID)
+	assert.ErrorIs(t, err, models.ErrActorNotFound)
+	//novalid UUID
+	_, err = s.GetActorByID(ctx, "b0482c7a-1a4c-4a3")
+	assert.ErrorIs(t, err, models.ErrInvalidID)
+}
+
+func TestService_ReplaceActor(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	validID := "b0482c7a-1a4c-4a3c-9463-35f0036a0d60"
+	//незаполненные поля не берутся из БД
+	actor := models.Actor{Name: "Tom Hanks"}
+	mockDB.On("UpdateActor", ctx, uuid.MustParse(validID), actor).Return(nil).Once()
+	err := s.ReplaceActor(ctx, validID, actor)
+	assert.Nil(t, err)
+	//empty name
+	err = s.ReplaceActor(ctx, validID, models.Actor{Gender: "male"})
+	assert.NotNil(t, err)
+	//novalid UUID
+	err = s.ReplaceActor(ctx, "b0482c7a-1a4c-4a3", actor)
+	assert.NotNil(t, err)
 }
 
 func TestService_GetActorList(t *testing.T) {
 	mockDB := mocks.NewDb(t)
 	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
 	ctx := context.TODO()
-	s := NewService(mockDB, log)
-	mockDB.On("GetActorList", ctx).Return(map[string][]string{
-		"Tom Hanks": []string{
-			"Forrest Gump",
-			"Training Day",
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	actors := []*models.ActorDetails{
+		{ID: uuid.MustParse("b0482c7a-1a4c-4a3c-9463-35f0036a0d60"), Name: "Brad Pitt", Filmography: []models.ActorMovie{}},
+		{
+			ID:   uuid.MustParse("6f2f5bd6-7ed1-4d1c-8f5a-1d8f7a1c1f0b"),
+			Name: "Tom Hanks",
+			Filmography: []models.ActorMovie{
+				{ID: uuid.MustParse("20648636-b14e-4f88-a02a-8c4e3c2f534d"), Name: "Forrest Gump"},
+			},
 		},
-	}, nil).Once()
-	result, err := s.GetActorList(ctx)
-	assert.NotNil(t, result)
+		{ID: uuid.MustParse("7a1c5e2d-3b4f-4c6a-9d8e-0f1a2b3c4d5e"), Name: "Will Smith", Filmography: []models.ActorMovie{}},
+	}
+	//первая страница по курсору, из БД запрашивается на одну запись больше
+	mockDB.On("GetActorList", ctx, (*models.Cursor)(nil), 3, 0).Return(actors, 3, nil).Once()
+	result, err := s.GetActorList(ctx, models.PageParams{})
+	assert.Nil(t, err)
+	assert.Equal(t, actors[:2], result.Actors)
+	assert.Equal(t, 3, result.Total)
+	assert.Equal(t, 2, result.Limit)
+	next, err := models.DecodeCursor(result.NextCursor, models.ActorListSort)
+	assert.Nil(t, err)
+	assert.Equal(t, &models.Cursor{Sort: "name", Values: []string{"Tom Hanks"}, ID: actors[1].ID}, next)
+
+	//следующая страница по курсору
+	mockDB.On("GetActorList", ctx, next, 3, 0).Return(actors[2:], 3, nil).Once()
+	result, err = s.GetActorList(ctx, models.PageParams{Cursor: result.NextCursor})
+	assert.Nil(t, err)
+	assert.Equal(t, actors[2:], result.Actors)
+	assert.Empty(t, result.NextCursor)
+
+	//страница по номеру
+	mockDB.On("GetActorList", ctx, (*models.Cursor)(nil), 2, 2).Return(actors[2:], 3, nil).Once()
+	result, err = s.GetActorList(ctx, models.PageParams{Page: "2"})
 	assert.Nil(t, err)
+	assert.Equal(t, models.PageInfo{Total: 3, Page: 2, PerPage: 2}, result.PageInfo)
 
-	mockDB.On("GetActorList", ctx).Return(nil, errors.New("some error")).Once()
-	result, err = s.GetActorList(ctx)
+	mockDB.On("GetActorList", ctx, (*models.Cursor)(nil), 3, 0).Return(nil, 0, errors.New("some error")).Once()
+	result, err = s.GetActorList(ctx, models.PageParams{})
 	assert.Nil(t, result)
 	assert.NotNil(t, err)
 }
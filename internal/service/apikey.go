@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/ast3am/VKintern-movies/internal/utils"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// apiKeyScheme - схема заголовка Authorization для API ключей
+const apiKeyScheme = "ApiKey "
+
+// apiKeyTouchInterval - точность времени последнего использования ключа, чаще оно в БД не записывается
+const apiKeyTouchInterval = time.Minute
+
+// CreateAPIKey выпускает API ключ. Права ключа не могут превышать права токена, которым он создается. Ключом новый
+// ключ создать нельзя, иначе ключ с ограниченным сроком мог бы выпустить себе бессрочную замену
+func (s *Service) CreateAPIKey(ctx context.Context, name string, permissions []models.Permission, expiresAt *time.Time) (*models.CreatedAPIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, models.ErrEmptyAPIKeyName
+	}
+	if len(permissions) == 0 {
+		return nil, models.ErrEmptyPermissions
+	}
+	now := time.Now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, models.ErrInvalidExpiry
+	}
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, models.ErrInvalidToken
+	}
+	if principal.APIKeyID != nil {
+		return nil, models.ErrAPIKeyByAPIKey
+	}
+	var createdBy *uuid.UUID
+	if principal.UserID != uuid.Nil {
+		createdBy = &principal.UserID
+	}
+	keyPermissions := make([]models.Permission, 0, len(permissions))
+	seen := make(map[models.Permission]bool, len(permissions))
+	for _, permission := range permissions {
+		if !permission.Valid() {
+			return nil, models.ErrUnknownPermission
+		}
+		if !principal.HasPermission(permission) {
+			return nil, models.ErrPermissionNotOwned
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			keyPermissions = append(keyPermissions, permission)
+		}
+	}
+
+	key, prefix, err := utils.NewAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	apiKey := models.APIKey{
+		ID:          uuid.New(),
+		Name:        name,
+		Prefix:      prefix,
+		Hash:        utils.HashToken(key),
+		Permissions: keyPermissions,
+		CreatedBy:   createdBy,
+		CreatedAt:   now,
+		ExpiresAt:   expiresAt,
+	}
+	err = s.db.CreateAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func (s *Service) GetAPIKeyList(ctx context.Context) ([]*models.APIKey, error) {
+	return s.db.GetAPIKeyList(ctx)
+}
+
+func (s *Service) RevokeAPIKey(ctx context.Context, id string) error {
+	uid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return s.db.RevokeAPIKey(ctx, uid)
+}
+
+// apiKeyPrincipal проверяет, что ключ существует и действует, и возвращает Principal от имени создателя ключа.
+// Права Principal - права ключа, которые есть у создателя на момент запроса
+func (s *Service) apiKeyPrincipal(ctx context.Context, key string) (*models.Principal, error) {
+	apiKey, err := s.db.GetAPIKeyByHash(ctx, utils.HashToken(key))
+	if errors.Is(err, models.ErrAPIKeyNotFound) {
+		return nil, models.ErrInvalidAPIKey
+	} else if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !apiKey.Active(now) {
+		return nil, models.ErrInvalidAPIKey
+	}
+	err = s.db.TouchAPIKey(ctx, apiKey.ID, now, now.Add(-apiKeyTouchInterval))
+	if err != nil {
+		s.log.ErrorMsg("can't update api key last use", err)
+	}
+	return &models.Principal{
+		UserID:      *apiKey.CreatedBy,
+		Permissions: apiKey.EffectivePermissions(),
+		APIKeyID:    &apiKey.ID,
+	}, nil
+}
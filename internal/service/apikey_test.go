@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/ast3am/VKintern-movies/internal/service/mocks"
+	"github.com/ast3am/VKintern-movies/internal/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestService_CreateAPIKey(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	adminID := uuid.New()
+	expiresAt := time.Now().Add(24 * time.Hour)
+	past := time.Now().Add(-time.Minute)
+	ctx := models.ContextWithPrincipal(context.TODO(), &models.Principal{
+		UserID:      adminID,
+		Permissions: []models.Permission{models.PermMovieRead, models.PermMovieWrite, models.PermUserManage},
+	})
+
+	//Без Principal в контексте ключ не создается
+	_, err := s.CreateAPIKey(context.TODO(), "importer", []models.Permission{models.PermMovieRead}, nil)
+	assert.ErrorIs(t, err, models.ErrInvalidToken)
+
+	testTable := []struct {
+		name        string
+		keyName     string
+		permissions []models.Permission
+		expiresAt   *time.Time
+		wantErr     error
+	}{
+		{"empty name", " ", []models.Permission{models.PermMovieRead}, nil, models.ErrEmptyAPIKeyName},
+		{"no permissions", "importer", nil, nil, models.ErrEmptyPermissions},
+		{"expired", "importer", []models.Permission{models.PermMovieRead}, &past, models.ErrInvalidExpiry},
+		{"unknown permission", "importer", []models.Permission{"movie:everything"}, nil, models.ErrUnknownPermission},
+		{"not owned", "importer", []models.Permission{models.PermMovieDelete}, nil, models.ErrPermissionNotOwned},
+	}
+	for _, test := range testTable {
+		result, err := s.CreateAPIKey(ctx, test.keyName, test.permissions, test.expiresAt)
+		assert.ErrorIs(t, err, test.wantErr, test.name)
+		assert.Nil(t, result, test.name)
+	}
+
+	//positive, повторяющиеся права схлопываются
+	var stored models.APIKey
+	mockDB.On("CreateAPIKey", ctx, mock.AnythingOfType("models.APIKey")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(models.APIKey)
+	}).Return(nil).Once()
+	result, err := s.CreateAPIKey(ctx, " importer ", []models.Permission{models.PermMovieRead, models.PermMovieWrite, models.PermMovieRead}, &expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, "importer", result.Name)
+	assert.Equal(t, []models.Permission{models.PermMovieRead, models.PermMovieWrite}, result.Permissions)
+	assert.Equal(t, &adminID, result.CreatedBy)
+	assert.True(t, strings.HasPrefix(result.Key, result.Prefix+"_"), result.Key)
+	assert.Equal(t, utils.HashToken(result.Key), stored.Hash)
+	assert.Equal(t, result.APIKey, stored)
+
+	//Ключом новый ключ не создается, даже с правами и сроком самого ключа
+	parentID := uuid.New()
+	keyCtx := models.ContextWithPrincipal(context.TODO(), &models.Principal{
+		UserID: adminID, APIKeyID: &parentID, Permissions: []models.Permission{models.PermMovieRead, models.PermUserManage},
+	})
+	_, err = s.CreateAPIKey(keyCtx, "child", []models.Permission{models.PermMovieRead}, nil)
+	assert.ErrorIs(t, err, models.ErrAPIKeyByAPIKey)
+	_, err = s.CreateAPIKey(keyCtx, "child", []models.Permission{models.PermMovieRead}, &expiresAt)
+	assert.ErrorIs(t, err, models.ErrAPIKeyByAPIKey)
+}
+
+func TestService_AuthenticateAPIKey(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	ownerID := uuid.New()
+	ownerPermissions := []models.Permission{models.PermMovieRead, models.PermMovieWrite, models.PermUserManage}
+	active := &models.APIKey{ID: uuid.New(), CreatedBy: &ownerID, Permissions: []models.Permission{models.PermMovieRead}, CreatorPermissions: ownerPermissions, ExpiresAt: &future}
+	mockDB.On("GetAPIKeyByHash", ctx, utils.HashToken("active")).Return(active, nil)
+	mockDB.On("GetAPIKeyByHash", ctx, utils.HashToken("expired")).Return(&models.APIKey{ID: uuid.New(), CreatedBy: &ownerID, Permissions: active.Permissions, ExpiresAt: &past}, nil)
+	mockDB.On("GetAPIKeyByHash", ctx, utils.HashToken("revoked")).Return(&models.APIKey{ID: uuid.New(), CreatedBy: &ownerID, Permissions: active.Permissions, RevokedAt: &past}, nil)
+	mockDB.On("GetAPIKeyByHash", ctx, utils.HashToken("unknown")).Return(nil, models.ErrAPIKeyNotFound)
+	//Создатель ключа удален или заблокирован
+	mockDB.On("GetAPIKeyByHash", ctx, utils.HashToken("orphaned")).Return(&models.APIKey{ID: uuid.New(), Permissions: active.Permissions, ExpiresAt: &future}, nil)
+	mockDB.On("GetAPIKeyByHash", ctx, utils.HashToken("disabled creator")).Return(&models.APIKey{ID: uuid.New(), CreatedBy: &ownerID, CreatorDisabled: true, Permissions: active.Permissions}, nil)
+
+	//Время использования записывается с ограничением частоты
+	mockDB.On("TouchAPIKey", ctx, active.ID, mock.AnythingOfType("time.Time"), mock.MatchedBy(func(notAfter time.Time) bool {
+		return time.Since(notAfter) >= apiKeyTouchInterval
+	})).Return(nil).Once()
+	principal, err := s.Authenticate(ctx, "ApiKey active")
+	require.NoError(t, err)
+	assert.Equal(t, &models.Principal{UserID: ownerID, Permissions: active.Permissions, APIKeyID: &active.ID}, principal)
+	assert.False(t, principal.HasPermission(models.PermMovieWrite))
+	for _, key := range []string{"expired", "revoked", "unknown", "orphaned", "disabled creator"} {
+		principal, err = s.Authenticate(ctx, "ApiKey "+key)
+		assert.ErrorIs(t, err, models.ErrInvalidAPIKey, key)
+		assert.Nil(t, principal, key)
+	}
+
+	//Создателя понизили: ключ теряет права, которых у создателя больше нет
+	demoted := &models.APIKey{ID: uuid.New(), CreatedBy: &ownerID, Permissions: []models.Permission{models.PermMovieRead, models.PermUserManage},
+		CreatorPermissions: []models.Permission{models.PermMovieRead}}
+	mockDB.On("GetAPIKeyByHash", ctx, utils.HashToken("demoted creator")).Return(demoted, nil).Once()
+	mockDB.On("TouchAPIKey", ctx, demoted.ID, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(nil).Once()
+	principal, err = s.Authenticate(ctx, "ApiKey demoted creator")
+	require.NoError(t, err)
+	assert.Equal(t, []models.Permission{models.PermMovieRead}, principal.Permissions)
+	assert.False(t, principal.HasPermission(models.PermUserManage))
+
+	//Ошибка записи времени использования не мешает запросу
+	mockDB.On("TouchAPIKey", ctx, active.ID, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(errors.New("db error")).Once()
+	log.On("ErrorMsg", "can't update api key last use", errors.New("db error")).Return().Once()
+	_, err = s.Authenticate(ctx, "ApiKey active")
+	assert.NoError(t, err)
+}
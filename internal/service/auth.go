@@ -3,31 +3,318 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"github.com/ast3am/VKintern-movies/internal/models"
 	"github.com/ast3am/VKintern-movies/internal/utils"
+	"github.com/google/uuid"
+	"strings"
+	"time"
 )
 
-func (s *Service) Auth(ctx context.Context, email, password string) (token string, err error) {
-	user, err := s.db.GetUserByEmail(ctx, email)
+// Auth проверяет email и пароль и выпускает пару токенов. Для несуществующего пользователя и неверного
+// пароля возвращается одна и та же ошибка, неудачные попытки учитываются по email и IP адресу клиента.
+// Если у пользователя подключен TOTP или его роль требует TOTP, вместо токенов возвращается MFAChallenge
+func (s *Service) Auth(ctx context.Context, email, password, ip string) (*models.TokenPair, *models.MFAChallenge, error) {
+	email = models.NormalizeEmail(email)
+	err := s.checkLoginLocked(ctx, email, ip)
 	if err != nil {
-		return "", err
+		return nil, nil, err
+	}
+
+	user, err := s.db.GetUserByEmail(ctx, email)
+	if errors.Is(err, models.ErrUserNotFound) {
+		checkDummyCreds(password)
+		s.registerLoginFailure(ctx, email, ip)
+		return nil, nil, models.ErrWrongCredentials
+	} else if err != nil {
+		return nil, nil, err
 	}
 
+	// у пользователей, входящих только через провайдер, пароля нет, проверка занимает то же время
+	if user.PasswordAlgo == models.PasswordAlgoNone {
+		checkDummyCreds(password)
+	}
 	credsCorrect := user.CheckCreds(email, password)
 
 	if !credsCorrect {
-		return "", errors.New("wrong email or password")
+		s.registerLoginFailure(ctx, email, ip)
+		return nil, nil, models.ErrWrongCredentials
+	}
+	if user.Disabled {
+		return nil, nil, models.ErrUserDisabled
 	}
 
-	token, err = utils.GetToken(user.Email, user.Role)
+	if user.NeedsRehash() {
+		s.rehashPassword(ctx, user.Email, password)
+	}
 
+	// при включенном втором факторе счетчик сбрасывается только после проверки кода,
+	// иначе повторный ввод пароля позволял бы перебирать коды без блокировки
+	challenge, err := s.mfaChallenge(ctx, user)
+	if err != nil || challenge != nil {
+		return nil, challenge, err
+	}
+	s.resetLoginFailures(ctx, email)
+	pair, err := s.issueTokens(ctx, user, nil)
+	return pair, nil, err
+}
+
+// Refresh обменивает refresh токен на новую пару токенов. Повторное использование уже
+// замененного токена означает его утечку, в этом случае отзывается все семейство токенов
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	stored, err := s.db.GetRefreshToken(ctx, utils.HashToken(refreshToken))
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if stored.RevokedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return nil, models.ErrInvalidRefreshToken
+	}
+	if stored.RotatedAt != nil {
+		s.revokeTokenFamily(ctx, stored.FamilyID)
+		return nil, models.ErrRefreshTokenReused
 	}
 
-	return token, nil
+	user, err := s.db.GetUserByUUID(ctx, stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, models.ErrUserDisabled
+	}
+
+	pair, err := s.issueTokens(ctx, user, stored)
+	if errors.Is(err, models.ErrRefreshTokenReused) {
+		s.revokeTokenFamily(ctx, stored.FamilyID)
+	}
+	return pair, err
 }
 
-func (s *Service) CheckToken(token, permissionLevel string) error {
-	err := utils.CheckPermissionByToken(token, permissionLevel)
+func (s *Service) Register(ctx context.Context, email, password string) error {
+	email = models.NormalizeEmail(email)
+	err := models.ValidateEmail(email)
+	if err != nil {
+		return err
+	}
+	err = models.ValidatePassword(password)
+	if err != nil {
+		return err
+	}
+	user := models.User{
+		Email: email,
+		Roles: []string{models.RoleUser},
+	}
+	err = user.SetPassword(password)
+	if err != nil {
+		return err
+	}
+	id, _ := uuid.NewUUID()
+	err = s.db.CreateUser(ctx, id, user)
 	return err
 }
+
+// issueTokens выпускает access и refresh токены. Если передан предыдущий refresh токен,
+// новый токен продолжает его семейство, иначе начинается новое семейство
+func (s *Service) issueTokens(ctx context.Context, user *models.User, previous *models.RefreshToken) (*models.TokenPair, error) {
+	permissions, err := s.db.GetUserPermissions(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := s.tokens.GetToken(user.ID, user.Email, user.Roles, permissions)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, expiresAt, err := s.tokens.GetRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	stored := models.RefreshToken{
+		Hash:      utils.HashToken(refreshToken),
+		UserID:    user.ID,
+		ExpiresAt: expiresAt,
+	}
+	if previous == nil {
+		stored.FamilyID = uuid.New()
+		err = s.db.CreateRefreshToken(ctx, stored)
+	} else {
+		stored.FamilyID = previous.FamilyID
+		err = s.db.RotateRefreshToken(ctx, previous.Hash, stored)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (s *Service) revokeTokenFamily(ctx context.Context, familyID uuid.UUID) {
+	err := s.db.RevokeRefreshTokenFamily(ctx, familyID)
+	if err != nil {
+		s.log.ErrorMsg("can't revoke refresh token family", err)
+		return
+	}
+	s.log.DebugMsg("refresh token reuse, token family revoked")
+}
+
+// rehashPassword переводит пароль на актуальный хеш, ошибка не должна мешать входу пользователя
+func (s *Service) rehashPassword(ctx context.Context, email, password string) {
+	user := models.User{Email: email}
+	err := user.SetPassword(password)
+	if err != nil {
+		s.log.ErrorMsg("can't hash password", err)
+		return
+	}
+	err = s.db.UpdateUserPassword(ctx, user.Email, user.Password, user.PasswordAlgo)
+	if err != nil {
+		s.log.ErrorMsg("can't update password hash", err)
+		return
+	}
+	s.log.DebugMsg("password hash upgraded")
+}
+
+// Logout отзывает access токен до окончания его действия. Если передан refresh токен того же
+// пользователя, отзывается и все его семейство
+func (s *Service) Logout(ctx context.Context, token, refreshToken string) error {
+	claims, err := s.tokens.ParseToken(accessToken(token))
+	if err != nil {
+		return err
+	}
+	err = s.checkRevoked(ctx, claims)
+	if err != nil {
+		return err
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return err
+	}
+	err = s.revokeToken(ctx, claims, userID)
+	if err != nil {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+	stored, err := s.db.GetRefreshToken(ctx, utils.HashToken(refreshToken))
+	if errors.Is(err, models.ErrInvalidRefreshToken) || (err == nil && stored.UserID != userID) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return s.db.RevokeRefreshTokenFamily(ctx, stored.FamilyID)
+}
+
+func (s *Service) revokeToken(ctx context.Context, claims *utils.Claims, userID uuid.UUID) error {
+	expiresAt := time.Unix(claims.ExpiresAt, 0).Add(revocationGracePeriod)
+	err := s.db.RevokeToken(ctx, claims.Id, userID, expiresAt)
+	if err != nil {
+		return err
+	}
+	s.revoked.setToken(claims.Id, true, expiresAt)
+	return nil
+}
+
+// RevokeUserTokens отзывает все access и refresh токены, выпущенные пользователю на текущий момент
+func (s *Service) RevokeUserTokens(ctx context.Context, id string) error {
+	uid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return s.revokeUserTokens(ctx, uid)
+}
+
+func (s *Service) revokeUserTokens(ctx context.Context, id uuid.UUID) error {
+	err := s.db.RevokeUserTokens(ctx, id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	s.revoked.setUser(id, &now)
+	return nil
+}
+
+// GetJWKS возвращает публичные ключи, которыми другие сервисы могут проверять выпущенные токены
+func (s *Service) GetJWKS() models.JWKSet {
+	return s.tokens.JWKS()
+}
+
+// bearerScheme - стандартная схема заголовка Authorization для access токенов (RFC 6750)
+const bearerScheme = "Bearer "
+
+// Authenticate проверяет значение заголовка Authorization и возвращает Principal запроса. Принимаются
+// "Bearer <access token>", "ApiKey <key>" и, для совместимости, access токен без схемы
+func (s *Service) Authenticate(ctx context.Context, authorization string) (*models.Principal, error) {
+	if key, ok := cutScheme(authorization, apiKeyScheme); ok {
+		return s.apiKeyPrincipal(ctx, key)
+	}
+	claims, err := s.tokens.ParseToken(accessToken(authorization))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidToken, err)
+	}
+	err = s.checkRevoked(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidToken, err)
+	}
+	return &models.Principal{
+		UserID:      userID,
+		Email:       claims.Email,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+	}, nil
+}
+
+// cutScheme отрезает схему авторизации, схема сравнивается без учета регистра (RFC 7235)
+func cutScheme(authorization, scheme string) (string, bool) {
+	if len(authorization) < len(scheme) || !strings.EqualFold(authorization[:len(scheme)], scheme) {
+		return authorization, false
+	}
+	return strings.TrimSpace(authorization[len(scheme):]), true
+}
+
+// accessToken возвращает токен из заголовка Authorization со схемой Bearer или без схемы
+func accessToken(authorization string) string {
+	token, _ := cutScheme(authorization, bearerScheme)
+	return token
+}
+
+// checkRevoked проверяет, что токен не отозван ни по jti, ни вместе со всеми токенами пользователя.
+// Токен удаленного пользователя считается недействительным
+func (s *Service) checkRevoked(ctx context.Context, claims *utils.Claims) error {
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return err
+	}
+	revokedAt, ok := s.revoked.getUser(userID)
+	if !ok {
+		revokedAt, err = s.db.GetUserTokensRevokedAt(ctx, userID)
+		if errors.Is(err, models.ErrUserNotFound) {
+			return models.ErrInvalidToken
+		} else if err != nil {
+			return err
+		}
+		s.revoked.setUser(userID, revokedAt)
+	}
+	if revokedAt != nil && claims.IssuedBefore(*revokedAt) {
+		return models.ErrTokenRevoked
+	}
+
+	revoked, ok := s.revoked.getToken(claims.Id)
+	if !ok {
+		revoked, err = s.db.IsTokenRevoked(ctx, claims.Id)
+		if err != nil {
+			return err
+		}
+		s.revoked.setToken(claims.Id, revoked, time.Unix(claims.ExpiresAt, 0).Add(revocationGracePeriod))
+	}
+	if revoked {
+		return models.ErrTokenRevoked
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"sync"
+	"time"
+)
+
+var (
+	dummyUser     models.User
+	dummyUserOnce sync.Once
+)
+
+// checkDummyCreds проверяет пароль по заранее вычисленному хешу, чтобы ответ для несуществующего
+// пользователя занимал столько же времени, сколько для существующего
+func checkDummyCreds(password string) {
+	dummyUserOnce.Do(func() {
+		_ = dummyUser.SetPassword("dummyPassword1")
+	})
+	dummyUser.CheckCreds(dummyUser.Email, password)
+}
+
+// checkLoginLocked возвращает ошибку, если вход по email или с IP адреса временно заблокирован
+func (s *Service) checkLoginLocked(ctx context.Context, email, ip string) error {
+	lockedUntil, err := s.db.GetLoginLockedUntil(ctx, email, ip, time.Now())
+	if err != nil {
+		return err
+	}
+	if lockedUntil != nil {
+		return &models.LoginLockedError{Until: *lockedUntil}
+	}
+	return nil
+}
+
+// registerLoginFailure учитывает неудачную попытку входа по email и по IP адресу.
+// Ошибки БД только логируются, клиент в любом случае получает ответ о неверных данных
+func (s *Service) registerLoginFailure(ctx context.Context, email, ip string) {
+	s.registerFailure(ctx, models.LoginKeyEmail, email, s.cfg.Login.MaxEmailFailures)
+	if ip != "" {
+		s.registerFailure(ctx, models.LoginKeyIP, ip, s.cfg.Login.MaxIPFailures)
+	}
+}
+
+func (s *Service) registerFailure(ctx context.Context, kind, value string, maxFailures int) {
+	if maxFailures <= 0 {
+		return
+	}
+	now := time.Now()
+	failures, err := s.db.RegisterLoginFailure(ctx, kind, value, now, s.cfg.Login.FailureWindow)
+	if err != nil {
+		s.log.ErrorMsg("can't register login failure", err)
+		return
+	}
+	if failures < maxFailures {
+		return
+	}
+	err = s.db.LockLogin(ctx, kind, value, now.Add(s.lockoutDuration(failures-maxFailures)))
+	if err != nil {
+		s.log.ErrorMsg("can't lock login", err)
+		return
+	}
+	s.log.DebugMsg("login locked after failed attempts: " + kind)
+}
+
+// lockoutDuration удваивает базовую блокировку за каждую неудачную попытку сверх лимита
+func (s *Service) lockoutDuration(extraFailures int) time.Duration {
+	lockout := s.cfg.Login.BaseLockout
+	for i := 0; i < extraFailures && lockout < s.cfg.Login.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > s.cfg.Login.MaxLockout {
+		lockout = s.cfg.Login.MaxLockout
+	}
+	return lockout
+}
+
+// resetLoginFailures сбрасывает счетчик по email после успешного входа. Счетчик по IP не сбрасывается,
+// иначе перебор по многим аккаунтам с одного адреса обходился бы входом в свой аккаунт
+func (s *Service) resetLoginFailures(ctx context.Context, email string) {
+	err := s.db.ResetLoginFailures(ctx, models.LoginKeyEmail, email)
+	if err != nil {
+		s.log.ErrorMsg("can't reset login failures", err)
+	}
+}
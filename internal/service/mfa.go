@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/ast3am/VKintern-movies/internal/utils"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// mfaChallenge возвращает MFAChallenge, если для входа пользователя нужен второй фактор, иначе nil
+func (s *Service) mfaChallenge(ctx context.Context, user *models.User) (*models.MFAChallenge, error) {
+	totp, err := s.db.GetTOTP(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	enabled := totp != nil && totp.Enabled
+	if !enabled && !s.mfaRequired(user) {
+		return nil, nil
+	}
+	token, err := s.tokens.GetMFAToken(user.ID, user.Email)
+	if err != nil {
+		return nil, err
+	}
+	return &models.MFAChallenge{MFAToken: token, SetupRequired: !enabled}, nil
+}
+
+func (s *Service) mfaRequired(user *models.User) bool {
+	for _, required := range s.cfg.MFA.RequiredRoles {
+		for _, role := range user.Roles {
+			if role == required {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AuthMFA обменивает токен, полученный на /auth, и код TOTP или код восстановления на пару токенов.
+// Токен второго фактора одноразовый, неверные коды учитываются вместе с неудачными попытками входа
+func (s *Service) AuthMFA(ctx context.Context, mfaToken, code, ip string) (*models.TokenPair, error) {
+	claims, err := s.tokens.ParseMFAToken(mfaToken)
+	if err != nil {
+		return nil, models.ErrInvalidToken
+	}
+	user, err := s.userByClaims(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	err = s.checkLoginLocked(ctx, user.Email, ip)
+	if err != nil {
+		return nil, err
+	}
+	totp, err := s.db.GetTOTP(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if totp == nil || !totp.Enabled {
+		return nil, models.ErrMFANotEnabled
+	}
+
+	err = s.checkMFACode(ctx, totp, code)
+	if errors.Is(err, models.ErrInvalidMFACode) {
+		s.registerLoginFailure(ctx, user.Email, ip)
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+	s.resetLoginFailures(ctx, user.Email)
+
+	err = s.revokeToken(ctx, claims, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokens(ctx, user, nil)
+}
+
+// checkMFACode принимает код TOTP или неиспользованный код восстановления
+func (s *Service) checkMFACode(ctx context.Context, totp *models.TOTP, code string) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return models.ErrInvalidMFACode
+	}
+	step, ok := utils.ValidateTOTP(totp.Secret, code, time.Now(), totp.LastStep)
+	if ok {
+		return s.db.UseTOTPStep(ctx, totp.UserID, step)
+	}
+	err := s.db.UseRecoveryCode(ctx, totp.UserID, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	s.log.DebugMsg("recovery code used")
+	return nil
+}
+
+// SetupTOTP создает новый секрет TOTP, который начинает действовать после подтверждения кодом в VerifyTOTP.
+// Вызывается с access токеном или, если роль требует TOTP, с токеном второго фактора
+func (s *Service) SetupTOTP(ctx context.Context, token string) (*models.TOTPSetup, error) {
+	_, user, err := s.enrollmentUser(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := utils.NewTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.SaveTOTPSecret(ctx, user.ID, secret)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TOTPSetup{
+		Secret: secret,
+		URI:    utils.TOTPURI(s.cfg.MFA.Issuer, user.Email, secret),
+	}, nil
+}
+
+// VerifyTOTP подтверждает секрет первым кодом из приложения и выдает коды восстановления.
+// Если подключение выполнено с токеном второго фактора, вход завершается выдачей токенов
+func (s *Service) VerifyTOTP(ctx context.Context, token, code, ip string) (*models.MFAEnrollment, error) {
+	mfaClaims, user, err := s.enrollmentUser(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	err = s.checkLoginLocked(ctx, user.Email, ip)
+	if err != nil {
+		return nil, err
+	}
+	totp, err := s.db.GetTOTP(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if totp == nil {
+		return nil, models.ErrMFASetupNotStarted
+	}
+	if totp.Enabled {
+		return nil, models.ErrMFAAlreadyEnabled
+	}
+	step, ok := utils.ValidateTOTP(totp.Secret, strings.TrimSpace(code), time.Now(), 0)
+	if !ok {
+		s.registerLoginFailure(ctx, user.Email, ip)
+		return nil, models.ErrInvalidMFACode
+	}
+
+	codes := make([]string, 0, models.RecoveryCodesCount)
+	hashes := make([]string, 0, models.RecoveryCodesCount)
+	for i := 0; i < models.RecoveryCodesCount; i++ {
+		recoveryCode, err := utils.NewRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, recoveryCode)
+		hashes = append(hashes, utils.HashToken(utils.NormalizeRecoveryCode(recoveryCode)))
+	}
+	err = s.db.EnableTOTP(ctx, user.ID, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	result := &models.MFAEnrollment{RecoveryCodes: codes}
+	if mfaClaims == nil {
+		return result, nil
+	}
+
+	s.resetLoginFailures(ctx, user.Email)
+	err = s.revokeToken(ctx, mfaClaims, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	result.TokenPair, err = s.issueTokens(ctx, user, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// enrollmentUser возвращает пользователя по access токену или по токену второго фактора,
+// во втором случае возвращаются и claims токена второго фактора
+func (s *Service) enrollmentUser(ctx context.Context, token string) (*utils.Claims, *models.User, error) {
+	token = accessToken(token)
+	claims, err := s.tokens.ParseToken(token)
+	if err == nil {
+		user, err := s.userByClaims(ctx, claims)
+		return nil, user, err
+	}
+	claims, err = s.tokens.ParseMFAToken(token)
+	if err != nil {
+		return nil, nil, models.ErrInvalidToken
+	}
+	user, err := s.userByClaims(ctx, claims)
+	if err != nil {
+		return nil, nil, err
+	}
+	return claims, user, nil
+}
+
+// userByClaims проверяет, что токен не отозван, и возвращает его владельца
+func (s *Service) userByClaims(ctx context.Context, claims *utils.Claims) (*models.User, error) {
+	err := s.checkRevoked(ctx, claims)
+	if errors.Is(err, models.ErrTokenRevoked) {
+		return nil, models.ErrInvalidToken
+	} else if err != nil {
+		return nil, err
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, models.ErrInvalidToken
+	}
+	user, err := s.db.GetUserByUUID(ctx, userID)
+	if errors.Is(err, models.ErrUserNotFound) {
+		return nil, models.ErrInvalidToken
+	} else if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, models.ErrUserDisabled
+	}
+	return user, nil
+}
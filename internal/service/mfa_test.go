@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/ast3am/VKintern-movies/internal/service/mocks"
+	"github.com/ast3am/VKintern-movies/internal/utils"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func mfaClaims(userID uuid.UUID, jti string) *utils.Claims {
+	return &utils.Claims{
+		Email: "admin@vk.ru",
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			Subject:   userID.String(),
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(5 * time.Minute).Unix(),
+		},
+	}
+}
+
+func TestService_AuthMFAChallenge(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	cfg := testCfg
+	cfg.MFA.RequiredRoles = []string{models.RoleAdmin}
+	s := NewService(mockDB, log, tokens, nil, nil, &cfg)
+	ip := "127.0.0.1"
+	admin := &models.User{ID: uuid.New(), Email: "admin@vk.ru", Roles: []string{models.RoleAdmin}}
+	user := &models.User{ID: uuid.New(), Email: "user@vk.ru", Roles: []string{models.RoleUser}}
+	require.NoError(t, admin.SetPassword("adminPassword1"))
+	require.NoError(t, user.SetPassword("userPassword1"))
+
+	mockDB.On("GetLoginLockedUntil", ctx, mock.AnythingOfType("string"), ip, mock.AnythingOfType("time.Time")).Return(nil, nil)
+	mockDB.On("GetUserByEmail", ctx, admin.Email).Return(admin, nil)
+	mockDB.On("GetUserByEmail", ctx, user.Email).Return(user, nil)
+
+	//Роль требует TOTP, который еще не подключен
+	mockDB.On("GetTOTP", ctx, admin.ID).Return(nil, nil).Once()
+	tokens.On("GetMFAToken", admin.ID, admin.Email).Return("mfa token", nil).Once()
+	tokenPair, challenge, err := s.Auth(ctx, admin.Email, "adminPassword1", ip)
+	assert.NoError(t, err)
+	assert.Nil(t, tokenPair)
+	assert.Equal(t, &models.MFAChallenge{MFAToken: "mfa token", SetupRequired: true}, challenge)
+
+	//TOTP подключен
+	mockDB.On("GetTOTP", ctx, user.ID).Return(&models.TOTP{UserID: user.ID, Enabled: true}, nil).Once()
+	tokens.On("GetMFAToken", user.ID, user.Email).Return("mfa token", nil).Once()
+	tokenPair, challenge, err = s.Auth(ctx, user.Email, "userPassword1", ip)
+	assert.NoError(t, err)
+	assert.Nil(t, tokenPair)
+	assert.Equal(t, &models.MFAChallenge{MFAToken: "mfa token"}, challenge)
+
+	//Неподтвержденный секрет не требует второго фактора
+	mockDB.On("GetTOTP", ctx, user.ID).Return(&models.TOTP{UserID: user.ID}, nil).Once()
+	mockDB.On("ResetLoginFailures", ctx, models.LoginKeyEmail, user.Email).Return(nil).Once()
+	mockDB.On("GetUserPermissions", ctx, user.ID).Return([]models.Permission{}, nil).Once()
+	tokens.On("GetToken", user.ID, user.Email, user.Roles, []models.Permission{}).Return("some token", nil).Once()
+	tokens.On("GetRefreshToken").Return("some refresh token", time.Now().Add(time.Hour), nil).Once()
+	mockDB.On("CreateRefreshToken", ctx, mock.AnythingOfType("models.RefreshToken")).Return(nil).Once()
+	tokenPair, challenge, err = s.Auth(ctx, user.Email, "userPassword1", ip)
+	assert.NoError(t, err)
+	assert.Nil(t, challenge)
+	assert.Equal(t, &models.TokenPair{AccessToken: "some token", RefreshToken: "some refresh token"}, tokenPair)
+}
+
+func TestService_AuthMFA(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	ip := "127.0.0.1"
+	user := &models.User{ID: uuid.New(), Email: "admin@vk.ru", Roles: []string{models.RoleAdmin}}
+	secret, err := utils.NewTOTPSecret()
+	require.NoError(t, err)
+	totp := &models.TOTP{UserID: user.ID, Secret: secret, Enabled: true}
+	now := time.Now()
+	code, err := utils.TOTPCode(secret, now)
+	require.NoError(t, err)
+
+	//Недействительный токен
+	tokens.On("ParseMFAToken", "bad token").Return(nil, errors.New("not a valid token")).Once()
+	tokenPair, err := s.AuthMFA(ctx, "bad token", code, ip)
+	assert.ErrorIs(t, err, models.ErrInvalidToken)
+	assert.Nil(t, tokenPair)
+
+	tokens.On("ParseMFAToken", "mfa token").Return(mfaClaims(user.ID, "mfa-jti"), nil)
+	mockDB.On("GetUserTokensRevokedAt", ctx, user.ID).Return(nil, nil).Once()
+	mockDB.On("IsTokenRevoked", ctx, "mfa-jti").Return(false, nil).Once()
+	mockDB.On("GetUserByUUID", ctx, user.ID).Return(user, nil)
+	mockDB.On("GetLoginLockedUntil", ctx, user.Email, ip, mock.AnythingOfType("time.Time")).Return(nil, nil)
+	mockDB.On("GetTOTP", ctx, user.ID).Return(totp, nil)
+
+	//Неверный код учитывается как неудачная попытка входа
+	mockDB.On("UseRecoveryCode", ctx, user.ID, utils.HashToken("WRONG")).Return(models.ErrInvalidMFACode).Once()
+	mockDB.On("RegisterLoginFailure", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("time.Time"), testCfg.Login.FailureWindow).Return(1, nil).Twice()
+	tokenPair, err = s.AuthMFA(ctx, "mfa token", "wrong", ip)
+	assert.ErrorIs(t, err, models.ErrInvalidMFACode)
+	assert.Nil(t, tokenPair)
+
+	//Верный код, токен второго фактора отзывается
+	mockDB.On("UseTOTPStep", ctx, user.ID, now.Unix()/30).Return(nil).Once()
+	mockDB.On("ResetLoginFailures", ctx, models.LoginKeyEmail, user.Email).Return(nil).Once()
+	mockDB.On("RevokeToken", ctx, "mfa-jti", user.ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+	mockDB.On("GetUserPermissions", ctx, user.ID).Return([]models.Permission{models.PermUserManage}, nil)
+	tokens.On("GetToken", user.ID, user.Email, user.Roles, []models.Permission{models.PermUserManage}).Return("some token", nil)
+	tokens.On("GetRefreshToken").Return("some refresh token", time.Now().Add(time.Hour), nil)
+	mockDB.On("CreateRefreshToken", ctx, mock.AnythingOfType("models.RefreshToken")).Return(nil)
+	tokenPair, err = s.AuthMFA(ctx, "mfa token", code, ip)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.TokenPair{AccessToken: "some token", RefreshToken: "some refresh token"}, tokenPair)
+
+	//Токен второго фактора одноразовый
+	tokenPair, err = s.AuthMFA(ctx, "mfa token", code, ip)
+	assert.ErrorIs(t, err, models.ErrInvalidToken)
+	assert.Nil(t, tokenPair)
+
+	//Код восстановления
+	tokens.On("ParseMFAToken", "another mfa token").Return(mfaClaims(user.ID, "another-jti"), nil)
+	mockDB.On("IsTokenRevoked", ctx, "another-jti").Return(false, nil).Once()
+	mockDB.On("UseRecoveryCode", ctx, user.ID, utils.HashToken("AAAAABBBBB")).Return(nil).Once()
+	log.On("DebugMsg", "recovery code used").Return().Once()
+	mockDB.On("ResetLoginFailures", ctx, models.LoginKeyEmail, user.Email).Return(nil).Once()
+	mockDB.On("RevokeToken", ctx, "another-jti", user.ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+	tokenPair, err = s.AuthMFA(ctx, "another mfa token", "aaaaa-bbbbb", ip)
+	assert.NoError(t, err)
+	assert.NotNil(t, tokenPair)
+}
+
+func TestService_SetupAndVerifyTOTP(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	ip := "127.0.0.1"
+	user := &models.User{ID: uuid.New(), Email: "admin@vk.ru", Roles: []string{models.RoleAdmin}}
+
+	//Setup с access токеном
+	tokens.On("ParseToken", "access token").Return(mfaClaims(user.ID, "access-jti"), nil)
+	mockDB.On("GetUserTokensRevokedAt", ctx, user.ID).Return(nil, nil).Once()
+	mockDB.On("IsTokenRevoked", ctx, "access-jti").Return(false, nil).Once()
+	mockDB.On("GetUserByUUID", ctx, user.ID).Return(user, nil)
+	var secret string
+	mockDB.On("SaveTOTPSecret", ctx, user.ID, mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		secret = args.String(2)
+	}).Return(nil).Once()
+	setup, err := s.SetupTOTP(ctx, "access token")
+	require.NoError(t, err)
+	assert.Equal(t, secret, setup.Secret)
+	assert.Equal(t, utils.TOTPURI(testCfg.MFA.Issuer, user.Email, secret), setup.URI)
+
+	//Без начатой настройки и после подключения
+	mockDB.On("GetLoginLockedUntil", ctx, user.Email, ip, mock.AnythingOfType("time.Time")).Return(nil, nil)
+	mockDB.On("GetTOTP", ctx, user.ID).Return(nil, nil).Once()
+	_, err = s.VerifyTOTP(ctx, "access token", "123456", ip)
+	assert.ErrorIs(t, err, models.ErrMFASetupNotStarted)
+	mockDB.On("GetTOTP", ctx, user.ID).Return(&models.TOTP{UserID: user.ID, Secret: secret, Enabled: true}, nil).Once()
+	_, err = s.VerifyTOTP(ctx, "access token", "123456", ip)
+	assert.ErrorIs(t, err, models.ErrMFAAlreadyEnabled)
+
+	//Неверный код
+	pending := &models.TOTP{UserID: user.ID, Secret: secret}
+	mockDB.On("GetTOTP", ctx, user.ID).Return(pending, nil)
+	mockDB.On("RegisterLoginFailure", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("time.Time"), testCfg.Login.FailureWindow).Return(1, nil).Twice()
+	_, err = s.VerifyTOTP(ctx, "access token", "wrong", ip)
+	assert.ErrorIs(t, err, models.ErrInvalidMFACode)
+
+	//Подключение с access токеном возвращает только коды восстановления
+	now := time.Now()
+	code, err := utils.TOTPCode(secret, now)
+	require.NoError(t, err)
+	var hashes []string
+	mockDB.On("EnableTOTP", ctx, user.ID, now.Unix()/30, mock.AnythingOfType("[]string")).Run(func(args mock.Arguments) {
+		hashes = args.Get(3).([]string)
+	}).Return(nil).Once()
+	enrollment, err := s.VerifyTOTP(ctx, "access token", code, ip)
+	require.NoError(t, err)
+	assert.Nil(t, enrollment.TokenPair)
+	require.Len(t, enrollment.RecoveryCodes, models.RecoveryCodesCount)
+	require.Len(t, hashes, models.RecoveryCodesCount)
+	for i, recoveryCode := range enrollment.RecoveryCodes {
+		assert.Equal(t, utils.HashToken(utils.NormalizeRecoveryCode(recoveryCode)), hashes[i])
+	}
+
+	//Обязательное подключение с токеном второго фактора завершает вход
+	tokens.On("ParseToken", "mfa token").Return(nil, errors.New("not a valid token")).Once()
+	tokens.On("ParseMFAToken", "mfa token").Return(mfaClaims(user.ID, "mfa-jti"), nil).Once()
+	mockDB.On("IsTokenRevoked", ctx, "mfa-jti").Return(false, nil).Once()
+	mockDB.On("EnableTOTP", ctx, user.ID, now.Unix()/30, mock.AnythingOfType("[]string")).Return(nil).Once()
+	mockDB.On("ResetLoginFailures", ctx, models.LoginKeyEmail, user.Email).Return(nil).Once()
+	mockDB.On("RevokeToken", ctx, "mfa-jti", user.ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+	mockDB.On("GetUserPermissions", ctx, user.ID).Return([]models.Permission{}, nil).Once()
+	tokens.On("GetToken", user.ID, user.Email, user.Roles, []models.Permission{}).Return("some token", nil).Once()
+	tokens.On("GetRefreshToken").Return("some refresh token", time.Now().Add(time.Hour), nil).Once()
+	mockDB.On("CreateRefreshToken", ctx, mock.AnythingOfType("models.RefreshToken")).Return(nil).Once()
+	enrollment, err = s.VerifyTOTP(ctx, "mfa token", code, ip)
+	require.NoError(t, err)
+	assert.Len(t, enrollment.RecoveryCodes, models.RecoveryCodesCount)
+	assert.Equal(t, &models.TokenPair{AccessToken: "some token", RefreshToken: "some refresh token"}, enrollment.TokenPair)
+
+	//Недействительный токен
+	tokens.On("ParseToken", "bad token").Return(nil, errors.New("not a valid token")).Once()
+	tokens.On("ParseMFAToken", "bad token").Return(nil, errors.New("not a valid token")).Once()
+	_, err = s.SetupTOTP(ctx, "bad token")
+	assert.ErrorIs(t, err, models.ErrInvalidToken)
+}
@@ -8,6 +8,8 @@ import (
 	models "github.com/ast3am/VKintern-movies/internal/models"
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	uuid "github.com/google/uuid"
 )
 
@@ -16,13 +18,39 @@ type db struct {
 	mock.Mock
 }
 
-// CreateActor provides a mock function with given fields: ctx, id, actor
-func (_m *db) CreateActor(ctx context.Context, id uuid.UUID, actor models.Actor) error {
-	ret := _m.Called(ctx, id, actor)
+// ConsumeOIDCState provides a mock function with given fields: ctx, hash, now
+func (_m *db) ConsumeOIDCState(ctx context.Context, hash string, now time.Time) (*models.OIDCState, error) {
+	ret := _m.Called(ctx, hash, now)
+
+	var r0 *models.OIDCState
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) (*models.OIDCState, error)); ok {
+		return rf(ctx, hash, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) *models.OIDCState); ok {
+		r0 = rf(ctx, hash, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.OIDCState)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = rf(ctx, hash, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAPIKey provides a mock function with given fields: ctx, key
+func (_m *db) CreateAPIKey(ctx context.Context, key models.APIKey) error {
+	ret := _m.Called(ctx, key)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Actor) error); ok {
-		r0 = rf(ctx, id, actor)
+	if rf, ok := ret.Get(0).(func(context.Context, models.APIKey) error); ok {
+		r0 = rf(ctx, key)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -30,13 +58,121 @@ func (_m *db) CreateActor(ctx context.Context, id uuid.UUID, actor models.Actor)
 	return r0
 }
 
+// CreateActor provides a mock function with given fields: ctx, id, actor
+func (_m *db) CreateActor(ctx context.Context, id uuid.UUID, actor models.Actor) (*models.ActorDetails, error) {
+	ret := _m.Called(ctx, id, actor)
+
+	var r0 *models.ActorDetails
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Actor) (*models.ActorDetails, error)); ok {
+		return rf(ctx, id, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Actor) *models.ActorDetails); ok {
+		r0 = rf(ctx, id, actor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ActorDetails)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.Actor) error); ok {
+		r1 = rf(ctx, id, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateMovie provides a mock function with given fields: ctx, id, movie
-func (_m *db) CreateMovie(ctx context.Context, id uuid.UUID, movie models.Movie) error {
+func (_m *db) CreateMovie(ctx context.Context, id uuid.UUID, movie models.Movie) (*models.MovieDetails, error) {
 	ret := _m.Called(ctx, id, movie)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Movie) error); ok {
+	var r0 *models.MovieDetails
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Movie) (*models.MovieDetails, error)); ok {
+		return rf(ctx, id, movie)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Movie) *models.MovieDetails); ok {
 		r0 = rf(ctx, id, movie)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.MovieDetails)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.Movie) error); ok {
+		r1 = rf(ctx, id, movie)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateOIDCState provides a mock function with given fields: ctx, state
+func (_m *db) CreateOIDCState(ctx context.Context, state models.OIDCState) error {
+	ret := _m.Called(ctx, state)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.OIDCState) error); ok {
+		r0 = rf(ctx, state)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateOIDCUser provides a mock function with given fields: ctx, id, user, issuer, subject
+func (_m *db) CreateOIDCUser(ctx context.Context, id uuid.UUID, user models.User, issuer string, subject string) error {
+	ret := _m.Called(ctx, id, user, issuer, subject)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.User, string, string) error); ok {
+		r0 = rf(ctx, id, user, issuer, subject)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreatePasswordResetToken provides a mock function with given fields: ctx, token, resendAfter
+func (_m *db) CreatePasswordResetToken(ctx context.Context, token models.PasswordResetToken, resendAfter time.Time) error {
+	ret := _m.Called(ctx, token, resendAfter)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.PasswordResetToken, time.Time) error); ok {
+		r0 = rf(ctx, token, resendAfter)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateRefreshToken provides a mock function with given fields: ctx, token
+func (_m *db) CreateRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	ret := _m.Called(ctx, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.RefreshToken) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateUser provides a mock function with given fields: ctx, id, user
+func (_m *db) CreateUser(ctx context.Context, id uuid.UUID, user models.User) error {
+	ret := _m.Called(ctx, id, user)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.User) error); ok {
+		r0 = rf(ctx, id, user)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -72,25 +208,53 @@ func (_m *db) DeleteMovie(ctx context.Context, uid uuid.UUID) error {
 	return r0
 }
 
-// GetActorByUUID provides a mock function with given fields: ctx, id
-func (_m *db) GetActorByUUID(ctx context.Context, id uuid.UUID) (*models.Actor, error) {
+// DeleteUser provides a mock function with given fields: ctx, id
+func (_m *db) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	ret := _m.Called(ctx, id)
 
-	var r0 *models.Actor
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnableTOTP provides a mock function with given fields: ctx, userID, step, recoveryCodeHashes
+func (_m *db) EnableTOTP(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	ret := _m.Called(ctx, userID, step, recoveryCodeHashes)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, int64, []string) error); ok {
+		r0 = rf(ctx, userID, step, recoveryCodeHashes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAPIKeyByHash provides a mock function with given fields: ctx, hash
+func (_m *db) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	ret := _m.Called(ctx, hash)
+
+	var r0 *models.APIKey
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*models.Actor, error)); ok {
-		return rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.APIKey, error)); ok {
+		return rf(ctx, hash)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *models.Actor); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.APIKey); ok {
+		r0 = rf(ctx, hash)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.Actor)
+			r0 = ret.Get(0).(*models.APIKey)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
-		r1 = rf(ctx, id)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, hash)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -98,20 +262,20 @@ func (_m *db) GetActorByUUID(ctx context.Context, id uuid.UUID) (*models.Actor,
 	return r0, r1
 }
 
-// GetActorList provides a mock function with given fields: ctx
-func (_m *db) GetActorList(ctx context.Context) (map[string][]string, error) {
+// GetAPIKeyList provides a mock function with given fields: ctx
+func (_m *db) GetAPIKeyList(ctx context.Context) ([]*models.APIKey, error) {
 	ret := _m.Called(ctx)
 
-	var r0 map[string][]string
+	var r0 []*models.APIKey
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) (map[string][]string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*models.APIKey, error)); ok {
 		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) map[string][]string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) []*models.APIKey); ok {
 		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(map[string][]string)
+			r0 = ret.Get(0).([]*models.APIKey)
 		}
 	}
 
@@ -124,6 +288,91 @@ func (_m *db) GetActorList(ctx context.Context) (map[string][]string, error) {
 	return r0, r1
 }
 
+// GetActorByUUID provides a mock function with given fields: ctx, id
+func (_m *db) GetActorByUUID(ctx context.Context, id uuid.UUID) (*models.ActorDetails, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *models.ActorDetails
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*models.ActorDetails, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *models.ActorDetails); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ActorDetails)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetActorList provides a mock function with given fields: ctx, after, limit, offset
+func (_m *db) GetActorList(ctx context.Context, after *models.Cursor, limit int, offset int) ([]*models.ActorDetails, int, error) {
+	ret := _m.Called(ctx, after, limit, offset)
+
+	var r0 []*models.ActorDetails
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Cursor, int, int) ([]*models.ActorDetails, int, error)); ok {
+		return rf(ctx, after, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Cursor, int, int) []*models.ActorDetails); ok {
+		r0 = rf(ctx, after, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.ActorDetails)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.Cursor, int, int) int); ok {
+		r1 = rf(ctx, after, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, *models.Cursor, int, int) error); ok {
+		r2 = rf(ctx, after, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetLoginLockedUntil provides a mock function with given fields: ctx, email, ip, now
+func (_m *db) GetLoginLockedUntil(ctx context.Context, email string, ip string, now time.Time) (*time.Time, error) {
+	ret := _m.Called(ctx, email, ip, now)
+
+	var r0 *time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) (*time.Time, error)); ok {
+		return rf(ctx, email, ip, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) *time.Time); ok {
+		r0 = rf(ctx, email, ip, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*time.Time)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time) error); ok {
+		r1 = rf(ctx, email, ip, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetMovie provides a mock function with given fields: ctx, actor, movie
 func (_m *db) GetMovie(ctx context.Context, actor string, movie string) ([]*models.Movie, error) {
 	ret := _m.Called(ctx, actor, movie)
@@ -151,19 +400,19 @@ func (_m *db) GetMovie(ctx context.Context, actor string, movie string) ([]*mode
 }
 
 // GetMovieByUUID provides a mock function with given fields: ctx, id
-func (_m *db) GetMovieByUUID(ctx context.Context, id uuid.UUID) (*models.Movie, error) {
+func (_m *db) GetMovieByUUID(ctx context.Context, id uuid.UUID) (*models.MovieDetails, error) {
 	ret := _m.Called(ctx, id)
 
-	var r0 *models.Movie
+	var r0 *models.MovieDetails
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*models.Movie, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*models.MovieDetails, error)); ok {
 		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *models.Movie); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *models.MovieDetails); ok {
 		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.Movie)
+			r0 = ret.Get(0).(*models.MovieDetails)
 		}
 	}
 
@@ -176,25 +425,84 @@ func (_m *db) GetMovieByUUID(ctx context.Context, id uuid.UUID) (*models.Movie,
 	return r0, r1
 }
 
-// GetMovieList provides a mock function with given fields: ctx, sortby, list
-func (_m *db) GetMovieList(ctx context.Context, sortby string, list string) ([]*models.Movie, error) {
-	ret := _m.Called(ctx, sortby, list)
+// GetMovieList provides a mock function with given fields: ctx, filter, sort, after, limit, offset
+func (_m *db) GetMovieList(ctx context.Context, filter models.MovieFilter, sort models.SortSpec, after *models.Cursor, limit int, offset int) ([]*models.Movie, int, error) {
+	ret := _m.Called(ctx, filter, sort, after, limit, offset)
 
 	var r0 []*models.Movie
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]*models.Movie, error)); ok {
-		return rf(ctx, sortby, list)
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.MovieFilter, models.SortSpec, *models.Cursor, int, int) ([]*models.Movie, int, error)); ok {
+		return rf(ctx, filter, sort, after, limit, offset)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) []*models.Movie); ok {
-		r0 = rf(ctx, sortby, list)
+	if rf, ok := ret.Get(0).(func(context.Context, models.MovieFilter, models.SortSpec, *models.Cursor, int, int) []*models.Movie); ok {
+		r0 = rf(ctx, filter, sort, after, limit, offset)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.Movie)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, sortby, list)
+	if rf, ok := ret.Get(1).(func(context.Context, models.MovieFilter, models.SortSpec, *models.Cursor, int, int) int); ok {
+		r1 = rf(ctx, filter, sort, after, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, models.MovieFilter, models.SortSpec, *models.Cursor, int, int) error); ok {
+		r2 = rf(ctx, filter, sort, after, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetRefreshToken provides a mock function with given fields: ctx, hash
+func (_m *db) GetRefreshToken(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	ret := _m.Called(ctx, hash)
+
+	var r0 *models.RefreshToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.RefreshToken, error)); ok {
+		return rf(ctx, hash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.RefreshToken); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.RefreshToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTOTP provides a mock function with given fields: ctx, userID
+func (_m *db) GetTOTP(ctx context.Context, userID uuid.UUID) (*models.TOTP, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 *models.TOTP
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*models.TOTP, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *models.TOTP); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.TOTP)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -228,6 +536,451 @@ func (_m *db) GetUserByEmail(ctx context.Context, email string) (*models.User, e
 	return r0, r1
 }
 
+// GetUserByIdentity provides a mock function with given fields: ctx, issuer, subject
+func (_m *db) GetUserByIdentity(ctx context.Context, issuer string, subject string) (*models.User, bool, error) {
+	ret := _m.Called(ctx, issuer, subject)
+
+	var r0 *models.User
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*models.User, bool, error)); ok {
+		return rf(ctx, issuer, subject)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.User); ok {
+		r0 = rf(ctx, issuer, subject)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) bool); ok {
+		r1 = rf(ctx, issuer, subject)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
+		r2 = rf(ctx, issuer, subject)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetUserByUUID provides a mock function with given fields: ctx, id
+func (_m *db) GetUserByUUID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*models.User, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *models.User); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserList provides a mock function with given fields: ctx, limit, offset
+func (_m *db) GetUserList(ctx context.Context, limit int, offset int) ([]*models.User, int, error) {
+	ret := _m.Called(ctx, limit, offset)
+
+	var r0 []*models.User
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]*models.User, int, error)); ok {
+		return rf(ctx, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) []*models.User); ok {
+		r0 = rf(ctx, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) int); ok {
+		r1 = rf(ctx, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int, int) error); ok {
+		r2 = rf(ctx, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetUserPermissions provides a mock function with given fields: ctx, id
+func (_m *db) GetUserPermissions(ctx context.Context, id uuid.UUID) ([]models.Permission, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 []models.Permission
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]models.Permission, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []models.Permission); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Permission)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserTokensRevokedAt provides a mock function with given fields: ctx, userID
+func (_m *db) GetUserTokensRevokedAt(ctx context.Context, userID uuid.UUID) (*time.Time, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 *time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*time.Time, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *time.Time); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*time.Time)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsTokenRevoked provides a mock function with given fields: ctx, jti
+func (_m *db) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	ret := _m.Called(ctx, jti)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, jti)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, jti)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, jti)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LinkUserIdentity provides a mock function with given fields: ctx, userID, issuer, subject
+func (_m *db) LinkUserIdentity(ctx context.Context, userID uuid.UUID, issuer string, subject string) error {
+	ret := _m.Called(ctx, userID, issuer, subject)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, string) error); ok {
+		r0 = rf(ctx, userID, issuer, subject)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LockLogin provides a mock function with given fields: ctx, kind, value, until
+func (_m *db) LockLogin(ctx context.Context, kind string, value string, until time.Time) error {
+	ret := _m.Called(ctx, kind, value, until)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) error); ok {
+		r0 = rf(ctx, kind, value, until)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RegisterLoginFailure provides a mock function with given fields: ctx, kind, value, now, window
+func (_m *db) RegisterLoginFailure(ctx context.Context, kind string, value string, now time.Time, window time.Duration) (int, error) {
+	ret := _m.Called(ctx, kind, value, now, window)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, time.Duration) (int, error)); ok {
+		return rf(ctx, kind, value, now, window)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, time.Duration) int); ok {
+		r0 = rf(ctx, kind, value, now, window)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time, time.Duration) error); ok {
+		r1 = rf(ctx, kind, value, now, window)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResetLoginFailures provides a mock function with given fields: ctx, kind, value
+func (_m *db) ResetLoginFailures(ctx context.Context, kind string, value string) error {
+	ret := _m.Called(ctx, kind, value)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, kind, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetPasswordByToken provides a mock function with given fields: ctx, hash, now, password, algo
+func (_m *db) ResetPasswordByToken(ctx context.Context, hash string, now time.Time, password string, algo string) (uuid.UUID, string, error) {
+	ret := _m.Called(ctx, hash, now, password, algo)
+
+	var r0 uuid.UUID
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, string, string) (uuid.UUID, string, error)); ok {
+		return rf(ctx, hash, now, password, algo)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, string, string) uuid.UUID); ok {
+		r0 = rf(ctx, hash, now, password, algo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time, string, string) string); ok {
+		r1 = rf(ctx, hash, now, password, algo)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, time.Time, string, string) error); ok {
+		r2 = rf(ctx, hash, now, password, algo)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// RevokeAPIKey provides a mock function with given fields: ctx, id
+func (_m *db) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeRefreshTokenFamily provides a mock function with given fields: ctx, familyID
+func (_m *db) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	ret := _m.Called(ctx, familyID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, familyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeToken provides a mock function with given fields: ctx, jti, userID, expiresAt
+func (_m *db) RevokeToken(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error {
+	ret := _m.Called(ctx, jti, userID, expiresAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, uuid.UUID, time.Time) error); ok {
+		r0 = rf(ctx, jti, userID, expiresAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeUserTokens provides a mock function with given fields: ctx, userID
+func (_m *db) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	ret := _m.Called(ctx, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RotateRefreshToken provides a mock function with given fields: ctx, oldHash, token
+func (_m *db) RotateRefreshToken(ctx context.Context, oldHash string, token models.RefreshToken) error {
+	ret := _m.Called(ctx, oldHash, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.RefreshToken) error); ok {
+		r0 = rf(ctx, oldHash, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveTOTPSecret provides a mock function with given fields: ctx, userID, secret
+func (_m *db) SaveTOTPSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	ret := _m.Called(ctx, userID, secret)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, userID, secret)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Search provides a mock function with given fields: ctx, query, kinds, limit
+func (_m *db) Search(ctx context.Context, query string, kinds []string, limit int) ([]*models.SearchResult, error) {
+	ret := _m.Called(ctx, query, kinds, limit)
+
+	var r0 []*models.SearchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, int) ([]*models.SearchResult, error)); ok {
+		return rf(ctx, query, kinds, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, int) []*models.SearchResult); ok {
+		r0 = rf(ctx, query, kinds, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.SearchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string, int) error); ok {
+		r1 = rf(ctx, query, kinds, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetUserDisabled provides a mock function with given fields: ctx, id, disabled
+func (_m *db) SetUserDisabled(ctx context.Context, id uuid.UUID, disabled bool) error {
+	ret := _m.Called(ctx, id, disabled)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, bool) error); ok {
+		r0 = rf(ctx, id, disabled)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetUserRoles provides a mock function with given fields: ctx, id, roles
+func (_m *db) SetUserRoles(ctx context.Context, id uuid.UUID, roles []string) error {
+	ret := _m.Called(ctx, id, roles)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, []string) error); ok {
+		r0 = rf(ctx, id, roles)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Suggest provides a mock function with given fields: ctx, kind, prefix, limit
+func (_m *db) Suggest(ctx context.Context, kind string, prefix string, limit int) ([]*models.Suggestion, error) {
+	ret := _m.Called(ctx, kind, prefix, limit)
+
+	var r0 []*models.Suggestion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) ([]*models.Suggestion, error)); ok {
+		return rf(ctx, kind, prefix, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) []*models.Suggestion); ok {
+		r0 = rf(ctx, kind, prefix, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Suggestion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int) error); ok {
+		r1 = rf(ctx, kind, prefix, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TouchAPIKey provides a mock function with given fields: ctx, id, usedAt, notAfter
+func (_m *db) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time, notAfter time.Time) error {
+	ret := _m.Called(ctx, id, usedAt, notAfter)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, time.Time, time.Time) error); ok {
+		r0 = rf(ctx, id, usedAt, notAfter)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateActor provides a mock function with given fields: ctx, id, actor
 func (_m *db) UpdateActor(ctx context.Context, id uuid.UUID, actor models.Actor) error {
 	ret := _m.Called(ctx, id, actor)
@@ -256,6 +1009,48 @@ func (_m *db) UpdateMovie(ctx context.Context, id uuid.UUID, movie models.Movie)
 	return r0
 }
 
+// UpdateUserPassword provides a mock function with given fields: ctx, email, password, algo
+func (_m *db) UpdateUserPassword(ctx context.Context, email string, password string, algo string) error {
+	ret := _m.Called(ctx, email, password, algo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, email, password, algo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseRecoveryCode provides a mock function with given fields: ctx, userID, hash
+func (_m *db) UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) error {
+	ret := _m.Called(ctx, userID, hash)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, userID, hash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseTOTPStep provides a mock function with given fields: ctx, userID, step
+func (_m *db) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	ret := _m.Called(ctx, userID, step)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, int64) error); ok {
+		r0 = rf(ctx, userID, step)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTnewDb interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,44 @@
+// Code generated by mockery v2.23.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	models "github.com/ast3am/VKintern-movies/internal/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// mailer is an autogenerated mock type for the mailer type
+type mailer struct {
+	mock.Mock
+}
+
+// Send provides a mock function with given fields: ctx, msg
+func (_m *mailer) Send(ctx context.Context, msg models.MailMessage) error {
+	ret := _m.Called(ctx, msg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.MailMessage) error); ok {
+		r0 = rf(ctx, msg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTnewMailer interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// newMailer creates a new instance of mailer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMailer(t mockConstructorTestingTnewMailer) *mailer {
+	mock := &mailer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
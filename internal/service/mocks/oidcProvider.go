@@ -0,0 +1,80 @@
+// Code generated by mockery v2.23.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	models "github.com/ast3am/VKintern-movies/internal/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// oidcProvider is an autogenerated mock type for the oidcProvider type
+type oidcProvider struct {
+	mock.Mock
+}
+
+// AuthCodeURL provides a mock function with given fields: ctx, state, nonce, codeVerifier
+func (_m *oidcProvider) AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error) {
+	ret := _m.Called(ctx, state, nonce, codeVerifier)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (string, error)); ok {
+		return rf(ctx, state, nonce, codeVerifier)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) string); ok {
+		r0 = rf(ctx, state, nonce, codeVerifier)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, state, nonce, codeVerifier)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Exchange provides a mock function with given fields: ctx, code, codeVerifier, nonce
+func (_m *oidcProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*models.OIDCClaims, error) {
+	ret := _m.Called(ctx, code, codeVerifier, nonce)
+
+	var r0 *models.OIDCClaims
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*models.OIDCClaims, error)); ok {
+		return rf(ctx, code, codeVerifier, nonce)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *models.OIDCClaims); ok {
+		r0 = rf(ctx, code, codeVerifier, nonce)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.OIDCClaims)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, code, codeVerifier, nonce)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTnewOidcProvider interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// newOidcProvider creates a new instance of oidcProvider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewOidcProvider(t mockConstructorTestingTnewOidcProvider) *oidcProvider {
+	mock := &oidcProvider{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,179 @@
+// Code generated by mockery v2.23.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/ast3am/VKintern-movies/internal/models"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+
+	utils "github.com/ast3am/VKintern-movies/internal/utils"
+
+	uuid "github.com/google/uuid"
+)
+
+// tokenManager is an autogenerated mock type for the tokenManager type
+type tokenManager struct {
+	mock.Mock
+}
+
+// GetMFAToken provides a mock function with given fields: userID, email
+func (_m *tokenManager) GetMFAToken(userID uuid.UUID, email string) (string, error) {
+	ret := _m.Called(userID, email)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string) (string, error)); ok {
+		return rf(userID, email)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string) string); ok {
+		r0 = rf(userID, email)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, string) error); ok {
+		r1 = rf(userID, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRefreshToken provides a mock function with given fields:
+func (_m *tokenManager) GetRefreshToken() (string, time.Time, error) {
+	ret := _m.Called()
+
+	var r0 string
+	var r1 time.Time
+	var r2 error
+	if rf, ok := ret.Get(0).(func() (string, time.Time, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func() time.Time); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Get(1).(time.Time)
+	}
+
+	if rf, ok := ret.Get(2).(func() error); ok {
+		r2 = rf()
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetToken provides a mock function with given fields: userID, email, roles, permissions
+func (_m *tokenManager) GetToken(userID uuid.UUID, email string, roles []string, permissions []models.Permission) (string, error) {
+	ret := _m.Called(userID, email, roles, permissions)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string, []string, []models.Permission) (string, error)); ok {
+		return rf(userID, email, roles, permissions)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string, []string, []models.Permission) string); ok {
+		r0 = rf(userID, email, roles, permissions)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, string, []string, []models.Permission) error); ok {
+		r1 = rf(userID, email, roles, permissions)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// JWKS provides a mock function with given fields:
+func (_m *tokenManager) JWKS() models.JWKSet {
+	ret := _m.Called()
+
+	var r0 models.JWKSet
+	if rf, ok := ret.Get(0).(func() models.JWKSet); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(models.JWKSet)
+	}
+
+	return r0
+}
+
+// ParseMFAToken provides a mock function with given fields: token
+func (_m *tokenManager) ParseMFAToken(token string) (*utils.Claims, error) {
+	ret := _m.Called(token)
+
+	var r0 *utils.Claims
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*utils.Claims, error)); ok {
+		return rf(token)
+	}
+	if rf, ok := ret.Get(0).(func(string) *utils.Claims); ok {
+		r0 = rf(token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*utils.Claims)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ParseToken provides a mock function with given fields: token
+func (_m *tokenManager) ParseToken(token string) (*utils.Claims, error) {
+	ret := _m.Called(token)
+
+	var r0 *utils.Claims
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*utils.Claims, error)); ok {
+		return rf(token)
+	}
+	if rf, ok := ret.Get(0).(func(string) *utils.Claims); ok {
+		r0 = rf(token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*utils.Claims)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTnewTokenManager interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// newTokenManager creates a new instance of tokenManager. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewTokenManager(t mockConstructorTestingTnewTokenManager) *tokenManager {
+	mock := &tokenManager{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -6,18 +6,22 @@ import (
 	"github.com/google/uuid"
 )
 
-func (s *Service) CreateMovie(ctx context.Context, movie models.Movie) error {
+func (s *Service) CreateMovie(ctx context.Context, movie models.Movie) (*models.MovieDetails, error) {
 	err := movie.Validate()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	id, _ := uuid.NewUUID()
-	err = s.db.CreateMovie(ctx, id, movie)
-	return err
+	result, err := s.db.CreateMovie(ctx, id, movie)
+	if err != nil {
+		return nil, err
+	}
+	s.suggestions.invalidate(models.SearchKindMovie)
+	return result, nil
 }
 
 func (s *Service) UpdateMovie(ctx context.Context, id string, movie models.Movie) error {
-	uid, err := uuid.Parse(id)
+	uid, err := parseID(id)
 	if err != nil {
 		return err
 	}
@@ -38,7 +42,7 @@ func (s *Service) UpdateMovie(ctx context.Context, id string, movie models.Movie
 		movie.Rating = nowMovie.Rating
 	}
 	if len(movie.ActorList) == 0 {
-		movie.ActorList = nowMovie.ActorList
+		movie.ActorList = nowMovie.ActorNames()
 	}
 	err = movie.Validate()
 	if err != nil {
@@ -48,11 +52,38 @@ func (s *Service) UpdateMovie(ctx context.Context, id string, movie models.Movie
 	if err != nil {
 		return err
 	}
+	s.suggestions.invalidate(models.SearchKindMovie)
+	return nil
+}
+
+func (s *Service) GetMovieByID(ctx context.Context, id string) (*models.MovieDetails, error) {
+	uid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.db.GetMovieByUUID(ctx, uid)
+}
+
+// ReplaceMovie полностью заменяет информацию о фильме, в отличие от UpdateMovie пустые поля не берутся из БД
+func (s *Service) ReplaceMovie(ctx context.Context, id string, movie models.Movie) error {
+	uid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	err = movie.Validate()
+	if err != nil {
+		return err
+	}
+	err = s.db.UpdateMovie(ctx, uid, movie)
+	if err != nil {
+		return err
+	}
+	s.suggestions.invalidate(models.SearchKindMovie)
 	return nil
 }
 
 func (s *Service) DeleteMovie(ctx context.Context, id string) error {
-	uid, err := uuid.Parse(id)
+	uid, err := parseID(id)
 	if err != nil {
 		return err
 	}
@@ -60,23 +91,65 @@ func (s *Service) DeleteMovie(ctx context.Context, id string) error {
 	if err != nil {
 		return err
 	}
+	s.suggestions.invalidate(models.SearchKindMovie)
 	return nil
 }
 
-func (s *Service) GetMovieList(ctx context.Context, sortby, line string) ([]*models.Movie, error) {
-	if sortby == "" {
-		sortby = "rating"
+// movieListQuery разбирает условия отбора и проверяет сортировку, без сортировки используется
+// models.DefaultMovieSort, а с полнотекстовым запросом - models.RankMovieSort
+func movieListQuery(filterParams models.MovieFilterParams, sort models.SortSpec) (models.MovieFilter, models.SortSpec, error) {
+	filter, err := filterParams.Parse()
+	if err != nil {
+		return filter, nil, err
 	}
-	if line == "" {
-		line = "desc"
+	if len(sort) == 0 {
+		sort = models.DefaultMovieSort
+		if filter.Query != "" {
+			sort = models.RankMovieSort
+		}
+	} else if filter.Query == "" && sort.HasField("rank") {
+		return filter, nil, models.ErrRankWithoutQuery
 	}
-	result, err := s.db.GetMovieList(ctx, sortby, line)
+	return filter, sort, nil
+}
+
+// GetMovieList возвращает страницу фильмов, подходящих под условия отбора
+func (s *Service) GetMovieList(ctx context.Context, filterParams models.MovieFilterParams, sort models.SortSpec, params models.PageParams) (*models.MovieList, error) {
+	filter, sort, err := movieListQuery(filterParams, sort)
 	if err != nil {
 		return nil, err
 	}
+	page, err := s.parsePage(params, sort)
+	if err != nil {
+		return nil, err
+	}
+	movies, total, err := s.db.GetMovieList(ctx, filter, sort, page.after, page.fetch(), page.offset())
+	if err != nil {
+		return nil, err
+	}
+	result := &models.MovieList{Movies: movies, PageInfo: page.info(total)}
+	if page.hasMore(len(movies)) {
+		result.Movies = movies[:page.limit]
+		last := result.Movies[page.limit-1]
+		result.NextCursor = page.cursor(last.SortValues(sort), last.ID)
+	}
 	return result, nil
 }
 
+// GetAllMovies возвращает все фильмы, подходящие под условия отбора, одним списком. Нужен устаревшему
+// /movie/get-list, который выдавал весь список без постраничного вывода
+func (s *Service) GetAllMovies(ctx context.Context, filterParams models.MovieFilterParams, sort models.SortSpec) ([]*models.Movie, error) {
+	filter, sort, err := movieListQuery(filterParams, sort)
+	if err != nil {
+		return nil, err
+	}
+	movies, _, err := s.db.GetMovieList(ctx, filter, sort, nil, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return movies, nil
+}
+
 func (s *Service) GetMovie(ctx context.Context, actor, movie string) ([]*models.Movie, error) {
 	actor = "%" + actor + "%"
 	movie = "%" + movie + "%"
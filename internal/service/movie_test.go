@@ -8,14 +8,17 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
 	"testing"
 )
 
 func TestService_CreateMovie(t *testing.T) {
 	mockDB := mocks.NewDb(t)
 	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
 	ctx := context.TODO()
-	s := NewService(mockDB, log)
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
 	movieTrue := models.Movie{
 		Name:        "Forrest Gump",
 		Description: "Description 1",
@@ -37,31 +40,34 @@ func TestService_CreateMovie(t *testing.T) {
 		},
 	}
 
-	mockDB.On("CreateMovie", ctx, mock.AnythingOfType("uuid.UUID"), movieTrue).Return(nil)
+	created := &models.MovieDetails{Name: "Forrest Gump", Rating: 8.8, Cast: []models.CastMember{{Name: "Brad Pitt"}, {Name: "Tom Hanks"}}}
+	mockDB.On("CreateMovie", ctx, mock.AnythingOfType("uuid.UUID"), movieTrue).Return(created, nil)
 	//true
-	err := s.CreateMovie(ctx, movieTrue)
+	result, err := s.CreateMovie(ctx, movieTrue)
 	assert.Nil(t, err)
+	assert.Equal(t, created, result)
 	//false
-	err = s.CreateMovie(ctx, movieFalse)
+	_, err = s.CreateMovie(ctx, movieFalse)
 	assert.NotNil(t, err)
 }
 
 func TestService_UpdateMovie(t *testing.T) {
 	mockDB := mocks.NewDb(t)
 	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
 	ctx := context.TODO()
-	s := NewService(mockDB, log)
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
 	validID := "b0482c7a-1a4c-4a3c-9463-35f0036a0d60"
 	validIDnoMovie := "7cfd7e7f-3a27-46c5-9c3e-3b3d3b1c1416"
 	noValidID := "b0482c7a-1a4c-4a3"
-	movie := &models.Movie{
+	movie := &models.MovieDetails{
 		Name:        "Forrest Gump",
 		Description: "Description 1",
 		ReleaseDate: GetDate("1994-07-06"),
 		Rating:      8.8,
-		ActorList: []string{
-			"Tom Hanks",
-			"Brad Pitt",
+		Cast: []models.CastMember{
+			{Name: "Tom Hanks"},
+			{Name: "Brad Pitt"},
 		},
 	}
 	mockDB.On("GetMovieByUUID", ctx, mock.AnythingOfType("uuid.UUID")).Return(movie, func(ctx context.Context, id uuid.UUID) error {
@@ -121,11 +127,59 @@ func TestService_UpdateMovie(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestService_GetMovieByID(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	validID := "b0482c7a-1a4c-4a3c-9463-35f0036a0d60"
+	movie := &models.MovieDetails{ID: uuid.MustParse(validID), Name: "Forrest Gump", Cast: []models.CastMember{}}
+	mockDB.On("GetMovieByUUID", ctx, uuid.MustParse(validID)).Return(movie, nil).Once()
+	result, err := s.GetMovieByID(ctx, validID)
+	assert.Nil(t, err)
+	assert.Equal(t, movie, result)
+	//not found
+	mockDB.On("GetMovieByUUID", ctx, uuid.MustParse(validID)).Return(nil, models.ErrMovieNotFound).Once()
+	_, err = s.GetMovieByID(ctx, validID)
+	assert.ErrorIs(t, err, models.ErrMovieNotFound)
+	//novalid UUID
+	_, err = s.GetMovieByID(ctx, "b0482c7a-1a4c-4a3")
+	assert.ErrorIs(t, err, models.ErrInvalidID)
+}
+
+func TestService_ReplaceMovie(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	validID := "b0482c7a-1a4c-4a3c-9463-35f0036a0d60"
+	//незаполненные поля и пустой список актеров не берутся из БД
+	movie := models.Movie{Name: "Training Day", Rating: 7.7}
+	mockDB.On("UpdateMovie", ctx, uuid.MustParse(validID), movie).Return(nil).Once()
+	err := s.ReplaceMovie(ctx, validID, movie)
+	assert.Nil(t, err)
+	//no valid data
+	err = s.ReplaceMovie(ctx, validID, models.Movie{Description: "Description 2", Rating: 11})
+	var verr *models.ValidationError
+	assert.ErrorAs(t, err, &verr)
+	assert.Equal(t, []models.FieldError{
+		{Field: "name", Message: "must be 1-150 characters long"},
+		{Field: "rating", Message: "must be between 0 and 10"},
+	}, verr.Fields)
+	assert.ErrorIs(t, err, models.ErrValidation)
+	//novalid UUID
+	err = s.ReplaceMovie(ctx, "b0482c7a-1a4c-4a3", movie)
+	assert.ErrorIs(t, err, models.ErrInvalidID)
+}
+
 func TestService_DeleteMovie(t *testing.T) {
 	mockDB := mocks.NewDb(t)
 	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
 	ctx := context.TODO()
-	s := NewService(mockDB, log)
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
 	validID := "b0482c7a-1a4c-4a3c-9463-35f0036a0d60"
 	validIDnoUser := "7cfd7e7f-3a27-46c5-9c3e-3b3d3b1c1416"
 	noValidID := "b0482c7a-1a4c-4a3"
@@ -149,10 +203,12 @@ func TestService_DeleteMovie(t *testing.T) {
 func TestService_GetMovieList(t *testing.T) {
 	mockDB := mocks.NewDb(t)
 	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
 	ctx := context.TODO()
-	s := NewService(mockDB, log)
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
 	result := []*models.Movie{
 		{
+			ID:          uuid.MustParse("20648636-b14e-4f88-a02a-8c4e3c2f534d"),
 			Name:        "Forrest Gump",
 			Description: "Description 1",
 			ReleaseDate: GetDate("1994-07-06"),
@@ -162,6 +218,7 @@ func TestService_GetMovieList(t *testing.T) {
 				"Brad Pitt",
 			},
 		}, {
+			ID:          uuid.MustParse("3f1c6a52-9b7e-4d2a-8c5f-6e0d1b2a3c4d"),
 			Name:        "Training Day",
 			Description: "Description 2",
 			ReleaseDate: GetDate("2001-10-05"),
@@ -172,24 +229,190 @@ func TestService_GetMovieList(t *testing.T) {
 			},
 		},
 	}
-	mockDB.On("GetMovieList", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(result, nil).Times(2)
-	result, err := s.GetMovieList(ctx, "", "")
-	assert.NotNil(t, result)
+	mockDB.On("GetMovieList", ctx, models.MovieFilter{ActorMatch: models.ActorMatchAny}, models.DefaultMovieSort, (*models.Cursor)(nil), 3, 0).Return(result, 2, nil).Once()
+	list, err := s.GetMovieList(ctx, models.MovieFilterParams{}, nil, models.PageParams{})
 	assert.Nil(t, err)
-	result, err = s.GetMovieList(ctx, "rating", "desc")
-	assert.NotNil(t, result)
+	assert.Equal(t, result, list.Movies)
+	assert.Equal(t, models.PageInfo{Total: 2, Limit: 2}, list.PageInfo)
+
+	//limit меньше числа фильмов, курсор строится по полям сортировки последнего фильма страницы
+	sort := models.SortSpec{{Field: "release_date"}, {Field: "rating", Desc: true}, {Field: "name"}}
+	mockDB.On("GetMovieList", ctx, models.MovieFilter{ActorMatch: models.ActorMatchAny}, sort, (*models.Cursor)(nil), 2, 0).Return(result, 2, nil).Once()
+	list, err = s.GetMovieList(ctx, models.MovieFilterParams{}, sort, models.PageParams{Limit: "1"})
 	assert.Nil(t, err)
-	mockDB.On("GetMovieList", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil, errors.New("some error")).Once()
-	result, err = s.GetMovieList(ctx, "rating", "desc")
-	assert.Nil(t, result)
+	assert.Equal(t, result[:1], list.Movies)
+	next, err := models.DecodeCursor(list.NextCursor, sort)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1994-07-06T00:00:00Z", "8.5", "Forrest Gump"}, next.Values)
+	assert.Equal(t, result[0].ID, next.ID)
+
+	//курсор другой сортировки не принимается
+	_, err = s.GetMovieList(ctx, models.MovieFilterParams{}, models.SortSpec{{Field: "name"}}, models.PageParams{Cursor: list.NextCursor})
+	assert.ErrorIs(t, err, models.ErrInvalidCursor)
+
+	mockDB.On("GetMovieList", ctx, models.MovieFilter{ActorMatch: models.ActorMatchAny}, models.SortSpec{{Field: "name", Desc: true}}, (*models.Cursor)(nil), 5, 5).Return(result, 7, nil).Once()
+	list, err = s.GetMovieList(ctx, models.MovieFilterParams{}, models.SortSpec{{Field: "name", Desc: true}}, models.PageParams{Page: "2", PerPage: "5"})
+	assert.Nil(t, err)
+	assert.Equal(t, models.PageInfo{Total: 7, Page: 2, PerPage: 5}, list.PageInfo)
+
+	//у фильма без даты выхода дата в курсоре пустая
+	noDate := []*models.Movie{{ID: result[0].ID, Name: "Forrest Gump", Rating: 8.5}, result[1]}
+	mockDB.On("GetMovieList", ctx, models.MovieFilter{ActorMatch: models.ActorMatchAny}, sort, (*models.Cursor)(nil), 2, 0).Return(noDate, 2, nil).Once()
+	list, err = s.GetMovieList(ctx, models.MovieFilterParams{}, sort, models.PageParams{Limit: "1"})
+	assert.Nil(t, err)
+	next, err = models.DecodeCursor(list.NextCursor, sort)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"", "8.5", "Forrest Gump"}, next.Values)
+
+	mockDB.On("GetMovieList", ctx, models.MovieFilter{ActorMatch: models.ActorMatchAny}, models.DefaultMovieSort, (*models.Cursor)(nil), 3, 0).Return(nil, 0, errors.New("some error")).Once()
+	list, err = s.GetMovieList(ctx, models.MovieFilterParams{}, models.DefaultMovieSort, models.PageParams{})
+	assert.Nil(t, list)
 	assert.NotNil(t, err)
 }
 
+func TestService_GetAllMovies(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	result := []*models.Movie{{ID: uuid.MustParse("20648636-b14e-4f88-a02a-8c4e3c2f534d"), Name: "Forrest Gump", Rating: 8.8}}
+
+	//весь список без постраничного вывода
+	mockDB.On("GetMovieList", ctx, models.MovieFilter{ActorMatch: models.ActorMatchAny}, models.DefaultMovieSort, (*models.Cursor)(nil), 0, 0).Return(result, 1, nil).Once()
+	movies, err := s.GetAllMovies(ctx, models.MovieFilterParams{}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, result, movies)
+
+	_, err = s.GetAllMovies(ctx, models.MovieFilterParams{}, models.SortSpec{{Field: "rank"}})
+	assert.ErrorIs(t, err, models.ErrRankWithoutQuery)
+
+	mockDB.On("GetMovieList", ctx, models.MovieFilter{ActorMatch: models.ActorMatchAny}, models.SortSpec{{Field: "name"}}, (*models.Cursor)(nil), 0, 0).Return(nil, 0, errors.New("some error")).Once()
+	movies, err = s.GetAllMovies(ctx, models.MovieFilterParams{}, models.SortSpec{{Field: "name"}})
+	assert.Nil(t, movies)
+	assert.NotNil(t, err)
+}
+
+func TestService_GetMovieListFilter(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	from := GetDate("1990-01-01")
+	to := GetDate("1999-12-31")
+	ratingMin, ratingMax := 7.5, 9.0
+	tomHanks := uuid.MustParse("b0482c7a-1a4c-4a3c-9463-35f0036a0d60")
+	juliaRoberts := uuid.MustParse("7cfd7e7f-3a27-46c5-9c3e-3b3d3b1c1416")
+
+	testTable := []struct {
+		name     string
+		params   models.MovieFilterParams
+		expected models.MovieFilter
+		fields   []models.FieldError
+	}{
+		{
+			name:     "empty",
+			expected: models.MovieFilter{ActorMatch: models.ActorMatchAny},
+		},
+		{
+			name: "all filters",
+			params: models.MovieFilterParams{ReleasedFrom: "1990-01-01", ReleasedTo: "1999-12-31", RatingMin: "7.5", RatingMax: "9",
+				ActorIDs: []string{tomHanks.String() + "," + juliaRoberts.String(), tomHanks.String()}, ActorMatch: "all", ActorNameContains: "hanks", NameContains: "gump"},
+			expected: models.MovieFilter{ReleasedFrom: &from, ReleasedTo: &to, RatingMin: &ratingMin, RatingMax: &ratingMax,
+				ActorIDs: []uuid.UUID{tomHanks, juliaRoberts}, ActorMatch: models.ActorMatchAll, ActorNameContains: "hanks", NameContains: "gump"},
+		},
+		{
+			name:   "wrong format",
+			params: models.MovieFilterParams{ReleasedFrom: "01.01.1990", RatingMin: "high", RatingMax: "11", ActorIDs: []string{"1,2"}, ActorMatch: "some"},
+			fields: []models.FieldError{
+				{Field: "released_from", Message: "must be a date in YYYY-MM-DD format"},
+				{Field: "rating_min", Message: "must be a number between 0 and 10"},
+				{Field: "rating_max", Message: "must be a number between 0 and 10"},
+				{Field: "actor_id", Message: "must be a list of UUIDs"},
+				{Field: "actor_match", Message: "must be any or all"},
+			},
+		},
+		{
+			name:   "too long fragments",
+			params: models.MovieFilterParams{ActorNameContains: strings.Repeat("a", 151), NameContains: strings.Repeat("a", 151)},
+			fields: []models.FieldError{
+				{Field: "actor_name_contains", Message: "must be at most 150 characters long"},
+				{Field: "name_contains", Message: "must be at most 150 characters long"},
+			},
+		},
+		{
+			name:   "wrong ranges",
+			params: models.MovieFilterParams{ReleasedFrom: "2000-01-01", ReleasedTo: "1999-12-31", RatingMin: "8", RatingMax: "7"},
+			fields: []models.FieldError{
+				{Field: "released_to", Message: "must not be before released_from"},
+				{Field: "rating_max", Message: "must not be less than rating_min"},
+			},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.fields == nil {
+				mockDB.On("GetMovieList", ctx, tt.expected, models.DefaultMovieSort, (*models.Cursor)(nil), 3, 0).Return([]*models.Movie{}, 0, nil).Once()
+			}
+			_, err := s.GetMovieList(ctx, tt.params, nil, models.PageParams{})
+			if tt.fields == nil {
+				assert.Nil(t, err)
+				return
+			}
+			var verr *models.ValidationError
+			assert.ErrorAs(t, err, &verr)
+			assert.Equal(t, tt.fields, verr.Fields)
+		})
+	}
+}
+
+func TestService_GetMovieListQuery(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	filter := models.MovieFilter{ActorMatch: models.ActorMatchAny, Query: "волшебник"}
+
+	//Сортировка по релевантности без запроса
+	_, err := s.GetMovieList(ctx, models.MovieFilterParams{}, models.RankMovieSort, models.PageParams{})
+	assert.ErrorIs(t, err, models.ErrRankWithoutQuery)
+
+	_, err = s.GetMovieList(ctx, models.MovieFilterParams{Query: strings.Repeat("a", 151)}, nil, models.PageParams{})
+	var verr *models.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []models.FieldError{{Field: "q", Message: "must be at most 150 characters long"}}, verr.Fields)
+
+	//С запросом по умолчанию фильмы упорядочены по релевантности, курсор хранит rank последнего фильма
+	rank1, rank2, rank3 := 0.6, 0.3, 0.1
+	movies := []*models.Movie{
+		{ID: uuid.New(), Name: "Movie 1", Rank: &rank1},
+		{ID: uuid.New(), Name: "Movie 2", Rank: &rank2},
+		{ID: uuid.New(), Name: "Movie 3", Rank: &rank3},
+	}
+	mockDB.On("GetMovieList", ctx, filter, models.RankMovieSort, (*models.Cursor)(nil), 3, 0).Return(movies, 5, nil).Once()
+	result, err := s.GetMovieList(ctx, models.MovieFilterParams{Query: " волшебник "}, nil, models.PageParams{})
+	require.NoError(t, err)
+	assert.Len(t, result.Movies, 2)
+	cursor, err := models.DecodeCursor(result.NextCursor, models.RankMovieSort)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0.3"}, cursor.Values)
+	assert.Equal(t, movies[1].ID, cursor.ID)
+
+	//Заданная сортировка сохраняется
+	sort := models.SortSpec{{Field: "release_date"}}
+	mockDB.On("GetMovieList", ctx, filter, sort, (*models.Cursor)(nil), 3, 0).Return([]*models.Movie{}, 0, nil).Once()
+	_, err = s.GetMovieList(ctx, models.MovieFilterParams{Query: "волшебник"}, sort, models.PageParams{})
+	assert.Nil(t, err)
+}
+
 func TestService_GetMovie(t *testing.T) {
 	mockDB := mocks.NewDb(t)
 	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
 	ctx := context.TODO()
-	s := NewService(mockDB, log)
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
 	result := []*models.Movie{
 		{
 			Name:        "Forrest Gump",
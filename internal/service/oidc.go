@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/ast3am/VKintern-movies/internal/utils"
+	"github.com/google/uuid"
+	"sort"
+	"time"
+)
+
+// oidcEnabled сообщает, настроен ли вход через провайдер. Без настроенного issuer провайдер не передается в сервис
+func (s *Service) oidcEnabled() bool {
+	return s.oidc != nil
+}
+
+// OIDCLogin начинает вход через OpenID Connect провайдер. Возвращает адрес страницы входа провайдера и state,
+// который должен вернуться на callback. nonce и code verifier хранятся в БД и клиенту не передаются
+func (s *Service) OIDCLogin(ctx context.Context) (string, string, error) {
+	if !s.oidcEnabled() {
+		return "", "", models.ErrOIDCDisabled
+	}
+	state, err := utils.NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := utils.NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier, err := utils.NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	authURL, err := s.oidc.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return "", "", err
+	}
+	err = s.db.CreateOIDCState(ctx, models.OIDCState{
+		Hash:         utils.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(s.cfg.OIDC.StateTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+// OIDCCallback завершает вход через провайдер: обменивает код на ID токен, находит или создает пользователя
+// и выпускает пару токенов сервиса. Роли пользователя, созданного при входе через провайдер, заменяются ролями
+// групп из ID токена. Роли локального пользователя, связанного по email, заменяются, только если включен
+// sync_linked_roles. Второй фактор в этом случае проверяет провайдер, TOTP сервиса не запрашивается
+func (s *Service) OIDCCallback(ctx context.Context, state, code string) (*models.TokenPair, error) {
+	if !s.oidcEnabled() {
+		return nil, models.ErrOIDCDisabled
+	}
+	stored, err := s.db.ConsumeOIDCState(ctx, utils.HashToken(state), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.oidc.Exchange(ctx, code, stored.CodeVerifier, stored.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	roles := s.oidcRoles(claims.Groups)
+
+	user, provisioned, err := s.oidcUser(ctx, claims, roles)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, models.ErrUserDisabled
+	}
+	if provisioned || s.cfg.OIDC.SyncLinkedRoles {
+		err = s.syncOIDCRoles(ctx, user, roles)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.issueTokens(ctx, user, nil)
+}
+
+// syncOIDCRoles заменяет роли пользователя ролями его групп у провайдера
+func (s *Service) syncOIDCRoles(ctx context.Context, user *models.User, roles []string) error {
+	if len(roles) == 0 {
+		return models.ErrOIDCNoRoles
+	}
+	if equalRoles(user.Roles, roles) {
+		return nil
+	}
+	err := s.db.SetUserRoles(ctx, user.ID, roles)
+	if err != nil {
+		return err
+	}
+	user.Roles = roles
+	return nil
+}
+
+// oidcUser возвращает пользователя, связанного с учетной записью провайдера, и признак того, что он был создан
+// при входе через провайдер. При первом входе учетная запись связывается с пользователем с тем же email, только
+// если провайдер подтвердил email, иначе создается новый пользователь без пароля с ролями roles
+func (s *Service) oidcUser(ctx context.Context, claims *models.OIDCClaims, roles []string) (*models.User, bool, error) {
+	user, provisioned, err := s.db.GetUserByIdentity(ctx, claims.Issuer, claims.Subject)
+	if !errors.Is(err, models.ErrUserNotFound) {
+		return user, provisioned, err
+	}
+
+	email := models.NormalizeEmail(claims.Email)
+	err = models.ValidateEmail(email)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", models.ErrInvalidIDToken, err)
+	}
+	user, err = s.db.GetUserByEmail(ctx, email)
+	if err == nil {
+		if !claims.EmailVerified {
+			return nil, false, models.ErrOIDCEmailConflict
+		}
+		err = s.db.LinkUserIdentity(ctx, user.ID, claims.Issuer, claims.Subject)
+		if err != nil {
+			return nil, false, err
+		}
+		s.log.DebugMsg("oidc identity linked to existing user")
+		return user, false, nil
+	} else if !errors.Is(err, models.ErrUserNotFound) {
+		return nil, false, err
+	}
+
+	if len(roles) == 0 {
+		return nil, false, models.ErrOIDCNoRoles
+	}
+	user = &models.User{
+		ID:           uuid.New(),
+		Email:        email,
+		PasswordAlgo: models.PasswordAlgoNone,
+		Roles:        roles,
+	}
+	err = s.db.CreateOIDCUser(ctx, user.ID, *user, claims.Issuer, claims.Subject)
+	if err != nil {
+		return nil, false, err
+	}
+	s.log.DebugMsg("oidc user provisioned")
+	return user, true, nil
+}
+
+// oidcRoles возвращает отсортированное объединение ролей групп пользователя, если ни одна группа
+// не сопоставлена с ролями - роли по умолчанию
+func (s *Service) oidcRoles(groups []string) []string {
+	set := make(map[string]struct{})
+	for _, group := range groups {
+		for _, role := range s.cfg.OIDC.GroupRoles[group] {
+			set[role] = struct{}{}
+		}
+	}
+	if len(set) == 0 {
+		for _, role := range s.cfg.OIDC.DefaultRoles {
+			set[role] = struct{}{}
+		}
+	}
+	roles := make([]string, 0, len(set))
+	for role := range set {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// equalRoles сравнивает отсортированные списки ролей
+func equalRoles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
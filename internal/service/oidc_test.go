@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/ast3am/VKintern-movies/internal/service/mocks"
+	"github.com/ast3am/VKintern-movies/internal/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const testIssuer = "https://idp.example.com"
+
+func oidcTestCfg() models.Config {
+	cfg := testCfg
+	cfg.OIDC = models.OIDCConfig{
+		Issuer: testIssuer,
+		GroupRoles: map[string][]string{
+			"movies-admins": {models.RoleAdmin, models.RoleUser},
+			"staff":         {models.RoleUser},
+		},
+		StateTTL: 10 * time.Minute,
+	}
+	return cfg
+}
+
+func TestService_OIDCLogin(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	provider := mocks.NewOidcProvider(t)
+	ctx := context.TODO()
+
+	//Вход не настроен
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	_, _, err := s.OIDCLogin(ctx)
+	assert.ErrorIs(t, err, models.ErrOIDCDisabled)
+
+	cfg := oidcTestCfg()
+	s = NewService(mockDB, log, tokens, provider, nil, &cfg)
+	var nonce, verifier string
+	provider.On("AuthCodeURL", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) {
+			nonce, verifier = args.String(2), args.String(3)
+		}).Return(testIssuer+"/authorize", nil).Once()
+	var stored models.OIDCState
+	mockDB.On("CreateOIDCState", ctx, mock.AnythingOfType("models.OIDCState")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(models.OIDCState)
+	}).Return(nil).Once()
+	authURL, state, err := s.OIDCLogin(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, testIssuer+"/authorize", authURL)
+	assert.Equal(t, utils.HashToken(state), stored.Hash)
+	assert.Equal(t, nonce, stored.Nonce)
+	assert.Equal(t, verifier, stored.CodeVerifier)
+	assert.NotEqual(t, state, nonce)
+	assert.NotEqual(t, nonce, verifier)
+	assert.WithinDuration(t, time.Now().Add(cfg.OIDC.StateTTL), stored.ExpiresAt, time.Second)
+
+	//Ошибка провайдера, state не сохраняется
+	provider.On("AuthCodeURL", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+		Return("", models.ErrOIDCProvider).Once()
+	_, _, err = s.OIDCLogin(ctx)
+	assert.ErrorIs(t, err, models.ErrOIDCProvider)
+}
+
+func TestService_OIDCCallback(t *testing.T) {
+	ctx := context.TODO()
+	stateHash := utils.HashToken("state")
+	userID := uuid.New()
+
+	testTable := []struct {
+		name            string
+		claims          models.OIDCClaims
+		syncLinkedRoles bool
+		mockCall        func(mockDB, log *mock.Mock)
+		wantErr         error
+	}{
+		{
+			name:   "provisioned user, roles updated",
+			claims: models.OIDCClaims{Subject: "sub", Email: "user@mail.com", Groups: []string{"staff", "movies-admins"}},
+			mockCall: func(mockDB, log *mock.Mock) {
+				mockDB.On("GetUserByIdentity", ctx, testIssuer, "sub").
+					Return(&models.User{ID: userID, Email: "user@mail.com", Roles: []string{models.RoleUser}}, true, nil)
+				mockDB.On("SetUserRoles", ctx, userID, []string{models.RoleAdmin, models.RoleUser}).Return(nil)
+			},
+		},
+		{
+			name:   "provisioned user, no roles",
+			claims: models.OIDCClaims{Subject: "sub", Email: "user@mail.com", Groups: []string{"unknown"}},
+			mockCall: func(mockDB, log *mock.Mock) {
+				mockDB.On("GetUserByIdentity", ctx, testIssuer, "sub").
+					Return(&models.User{ID: userID, Email: "user@mail.com", Roles: []string{models.RoleUser}}, true, nil)
+			},
+			wantErr: models.ErrOIDCNoRoles,
+		},
+		{
+			name:   "linked local user, roles kept",
+			claims: models.OIDCClaims{Subject: "sub", Email: "admin@mail.com", Groups: []string{"unknown"}},
+			mockCall: func(mockDB, log *mock.Mock) {
+				mockDB.On("GetUserByIdentity", ctx, testIssuer, "sub").
+					Return(&models.User{ID: userID, Email: "admin@mail.com", Roles: []string{models.RoleAdmin}}, false, nil)
+			},
+		},
+		{
+			name:            "linked local user, roles synced on opt-in",
+			claims:          models.OIDCClaims{Subject: "sub", Email: "admin@mail.com", Groups: []string{"staff"}},
+			syncLinkedRoles: true,
+			mockCall: func(mockDB, log *mock.Mock) {
+				mockDB.On("GetUserByIdentity", ctx, testIssuer, "sub").
+					Return(&models.User{ID: userID, Email: "admin@mail.com", Roles: []string{models.RoleAdmin}}, false, nil)
+				mockDB.On("SetUserRoles", ctx, userID, []string{models.RoleUser}).Return(nil)
+			},
+		},
+		{
+			name:   "new user provisioned",
+			claims: models.OIDCClaims{Subject: "sub", Email: " New@Mail.com ", Groups: []string{"staff", "unknown"}},
+			mockCall: func(mockDB, log *mock.Mock) {
+				mockDB.On("GetUserByIdentity", ctx, testIssuer, "sub").Return(nil, false, models.ErrUserNotFound)
+				mockDB.On("GetUserByEmail", ctx, "new@mail.com").Return(nil, models.ErrUserNotFound)
+				mockDB.On("CreateOIDCUser", ctx, mock.AnythingOfType("uuid.UUID"), mock.MatchedBy(func(user models.User) bool {
+					return user.Email == "new@mail.com" && user.PasswordAlgo == models.PasswordAlgoNone &&
+						assert.ObjectsAreEqual([]string{models.RoleUser}, user.Roles)
+				}), testIssuer, "sub").Return(nil)
+				log.On("DebugMsg", "oidc user provisioned").Return()
+			},
+		},
+		{
+			name:   "existing user linked by verified email",
+			claims: models.OIDCClaims{Subject: "sub", Email: "user@mail.com", EmailVerified: true, Groups: []string{"staff"}},
+			mockCall: func(mockDB, log *mock.Mock) {
+				mockDB.On("GetUserByIdentity", ctx, testIssuer, "sub").Return(nil, false, models.ErrUserNotFound)
+				mockDB.On("GetUserByEmail", ctx, "user@mail.com").
+					Return(&models.User{ID: userID, Email: "user@mail.com", Roles: []string{models.RoleUser}}, nil)
+				mockDB.On("LinkUserIdentity", ctx, userID, testIssuer, "sub").Return(nil)
+				log.On("DebugMsg", "oidc identity linked to existing user").Return()
+			},
+		},
+		{
+			name:   "existing user, email not verified",
+			claims: models.OIDCClaims{Subject: "sub", Email: "user@mail.com", Groups: []string{"staff"}},
+			mockCall: func(mockDB, log *mock.Mock) {
+				mockDB.On("GetUserByIdentity", ctx, testIssuer, "sub").Return(nil, false, models.ErrUserNotFound)
+				mockDB.On("GetUserByEmail", ctx, "user@mail.com").
+					Return(&models.User{ID: userID, Email: "user@mail.com", Roles: []string{models.RoleUser}}, nil)
+			},
+			wantErr: models.ErrOIDCEmailConflict,
+		},
+		{
+			name:   "no email",
+			claims: models.OIDCClaims{Subject: "sub", Groups: []string{"staff"}},
+			mockCall: func(mockDB, log *mock.Mock) {
+				mockDB.On("GetUserByIdentity", ctx, testIssuer, "sub").Return(nil, false, models.ErrUserNotFound)
+			},
+			wantErr: models.ErrInvalidIDToken,
+		},
+		{
+			name:   "new user, no roles",
+			claims: models.OIDCClaims{Subject: "sub", Email: "user@mail.com", Groups: []string{"unknown"}},
+			mockCall: func(mockDB, log *mock.Mock) {
+				mockDB.On("GetUserByIdentity", ctx, testIssuer, "sub").Return(nil, false, models.ErrUserNotFound)
+				mockDB.On("GetUserByEmail", ctx, "user@mail.com").Return(nil, models.ErrUserNotFound)
+			},
+			wantErr: models.ErrOIDCNoRoles,
+		},
+		{
+			name:   "disabled user",
+			claims: models.OIDCClaims{Subject: "sub", Email: "user@mail.com", Groups: []string{"staff"}},
+			mockCall: func(mockDB, log *mock.Mock) {
+				mockDB.On("GetUserByIdentity", ctx, testIssuer, "sub").
+					Return(&models.User{ID: userID, Email: "user@mail.com", Roles: []string{models.RoleUser}, Disabled: true}, true, nil)
+			},
+			wantErr: models.ErrUserDisabled,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			mockDB := mocks.NewDb(t)
+			log := mocks.NewLogger(t)
+			tokens := mocks.NewTokenManager(t)
+			provider := mocks.NewOidcProvider(t)
+			cfg := oidcTestCfg()
+			cfg.OIDC.SyncLinkedRoles = test.syncLinkedRoles
+			s := NewService(mockDB, log, tokens, provider, nil, &cfg)
+			claims := test.claims
+			claims.Issuer = testIssuer
+
+			mockDB.On("ConsumeOIDCState", ctx, stateHash, mock.AnythingOfType("time.Time")).
+				Return(&models.OIDCState{Hash: stateHash, Nonce: "nonce", CodeVerifier: "verifier"}, nil)
+			provider.On("Exchange", ctx, "code", "verifier", "nonce").Return(&claims, nil)
+			test.mockCall(&mockDB.Mock, &log.Mock)
+			if test.wantErr == nil {
+				mockDB.On("GetUserPermissions", ctx, mock.AnythingOfType("uuid.UUID")).Return([]models.Permission{models.PermMovieRead}, nil)
+				tokens.On("GetToken", mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("string"), mock.Anything, []models.Permission{models.PermMovieRead}).
+					Return("access token", nil)
+				tokens.On("GetRefreshToken").Return("refresh token", time.Now().Add(time.Hour), nil)
+				mockDB.On("CreateRefreshToken", ctx, mock.AnythingOfType("models.RefreshToken")).Return(nil)
+			}
+
+			pair, err := s.OIDCCallback(ctx, "state", "code")
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+				assert.Nil(t, pair)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &models.TokenPair{AccessToken: "access token", RefreshToken: "refresh token"}, pair)
+		})
+	}
+}
+
+func TestService_OIDCCallbackErrors(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	provider := mocks.NewOidcProvider(t)
+	ctx := context.TODO()
+	cfg := oidcTestCfg()
+	cfg.OIDC.GroupRoles = nil
+	cfg.OIDC.DefaultRoles = []string{models.RoleUser}
+	s := NewService(mockDB, log, tokens, provider, nil, &cfg)
+
+	mockDB.On("ConsumeOIDCState", ctx, utils.HashToken("used state"), mock.AnythingOfType("time.Time")).
+		Return(nil, models.ErrInvalidOIDCState).Once()
+	_, err := s.OIDCCallback(ctx, "used state", "code")
+	assert.ErrorIs(t, err, models.ErrInvalidOIDCState)
+
+	mockDB.On("ConsumeOIDCState", ctx, utils.HashToken("state"), mock.AnythingOfType("time.Time")).
+		Return(&models.OIDCState{Nonce: "nonce", CodeVerifier: "verifier"}, nil)
+	provider.On("Exchange", ctx, "bad code", "verifier", "nonce").Return(nil, models.ErrOIDCProvider).Once()
+	_, err = s.OIDCCallback(ctx, "state", "bad code")
+	assert.ErrorIs(t, err, models.ErrOIDCProvider)
+
+	//Группы не сопоставлены, используются роли по умолчанию
+	provider.On("Exchange", ctx, "code", "verifier", "nonce").
+		Return(&models.OIDCClaims{Issuer: testIssuer, Subject: "sub", Groups: []string{"unknown"}}, nil).Once()
+	dbErr := errors.New("db error")
+	mockDB.On("GetUserByIdentity", ctx, testIssuer, "sub").
+		Return(&models.User{ID: uuid.New(), Roles: []string{models.RoleAdmin}}, true, nil).Once()
+	mockDB.On("SetUserRoles", ctx, mock.AnythingOfType("uuid.UUID"), []string{models.RoleUser}).Return(dbErr).Once()
+	_, err = s.OIDCCallback(ctx, "state", "code")
+	assert.ErrorIs(t, err, dbErr)
+}
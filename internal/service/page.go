@@ -0,0 +1,91 @@
+package service
+
+import (
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/google/uuid"
+	"math"
+	"strconv"
+)
+
+// pageRequest - разобранные параметры страницы. В режиме курсора из БД запрашивается на одну запись больше,
+// чтобы узнать, есть ли следующая страница
+type pageRequest struct {
+	keyset bool
+	sort   models.SortSpec
+	after  *models.Cursor
+	limit  int
+	page   int
+}
+
+// parsePage разбирает параметры страницы для сортировки sort, без параметров выдается первая страница по курсору
+func (s *Service) parsePage(params models.PageParams, sort models.SortSpec) (*pageRequest, error) {
+	result := &pageRequest{sort: sort, limit: s.cfg.Pagination.DefaultPageSize}
+	keyset := params.Limit != "" || params.Cursor != ""
+	offset := params.Page != "" || params.PerPage != ""
+	if keyset && offset {
+		return nil, models.ErrInvalidPagination
+	}
+	size := params.Limit
+	if offset {
+		size = params.PerPage
+	}
+	var err error
+	if size != "" {
+		result.limit, err = strconv.Atoi(size)
+		if err != nil || result.limit < 1 || result.limit > s.cfg.Pagination.MaxPageSize {
+			return nil, models.ErrInvalidPagination
+		}
+	}
+	if !offset {
+		result.keyset = true
+		if params.Cursor != "" {
+			result.after, err = models.DecodeCursor(params.Cursor, sort)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+	result.page = 1
+	if params.Page != "" {
+		result.page, err = strconv.Atoi(params.Page)
+		// смещение (page-1)*limit должно помещаться в int32, иначе умножение переполняется
+		if err != nil || result.page < 1 || result.page-1 > math.MaxInt32/result.limit {
+			return nil, models.ErrInvalidPagination
+		}
+	}
+	return result, nil
+}
+
+// fetch - количество записей, запрашиваемых из БД
+func (p *pageRequest) fetch() int {
+	if p.keyset {
+		return p.limit + 1
+	}
+	return p.limit
+}
+
+func (p *pageRequest) offset() int {
+	if p.keyset {
+		return 0
+	}
+	return (p.page - 1) * p.limit
+}
+
+// info - сведения о странице без курсора следующей страницы
+func (p *pageRequest) info(total int) models.PageInfo {
+	if p.keyset {
+		return models.PageInfo{Total: total, Limit: p.limit}
+	}
+	return models.PageInfo{Total: total, Page: p.page, PerPage: p.limit}
+}
+
+// hasMore сообщает, что в режиме курсора из БД получено больше записей, чем входит в страницу
+func (p *pageRequest) hasMore(n int) bool {
+	return p.keyset && n > p.limit
+}
+
+// cursor - курсор следующей страницы по значениям полей сортировки и UUID последней записи страницы
+func (p *pageRequest) cursor(values []string, id uuid.UUID) string {
+	return models.Cursor{Sort: p.sort.String(), Values: values, ID: id}.Encode()
+}
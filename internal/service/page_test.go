@@ -0,0 +1,115 @@
+package service
+
+import (
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestService_parsePage(t *testing.T) {
+	s := &Service{cfg: &testCfg}
+	id := uuid.MustParse("b0482c7a-1a4c-4a3c-9463-35f0036a0d60")
+	sort := models.SortSpec{{Field: "rating", Desc: true}, {Field: "name"}}
+	cursor := models.Cursor{Sort: "-rating,name", Values: []string{"8.8", "Inception"}, ID: id}.Encode()
+
+	testTable := []struct {
+		name   string
+		params models.PageParams
+		want   *pageRequest
+		err    error
+	}{
+		{
+			name: "default",
+			want: &pageRequest{keyset: true, sort: sort, limit: 2},
+		},
+		{
+			name:   "keyset",
+			params: models.PageParams{Limit: "5", Cursor: cursor},
+			want:   &pageRequest{keyset: true, sort: sort, after: &models.Cursor{Sort: "-rating,name", Values: []string{"8.8", "Inception"}, ID: id}, limit: 5},
+		},
+		{
+			name:   "offset",
+			params: models.PageParams{Page: "3", PerPage: "10"},
+			want:   &pageRequest{sort: sort, limit: 10, page: 3},
+		},
+		{
+			name:   "offset default page",
+			params: models.PageParams{PerPage: "4"},
+			want:   &pageRequest{sort: sort, limit: 4, page: 1},
+		},
+		{
+			name:   "mixed modes",
+			params: models.PageParams{Limit: "5", Page: "2"},
+			err:    models.ErrInvalidPagination,
+		},
+		{
+			name:   "limit above max",
+			params: models.PageParams{Limit: "11"},
+			err:    models.ErrInvalidPagination,
+		},
+		{
+			name:   "zero per_page",
+			params: models.PageParams{PerPage: "0"},
+			err:    models.ErrInvalidPagination,
+		},
+		{
+			name:   "page offset overflow",
+			params: models.PageParams{Page: "9223372036854775807", PerPage: "10"},
+			err:    models.ErrInvalidPagination,
+		},
+		{
+			name:   "page offset above int32",
+			params: models.PageParams{Page: "214748366", PerPage: "10"},
+			err:    models.ErrInvalidPagination,
+		},
+		{
+			name:   "last page within int32",
+			params: models.PageParams{Page: "214748365", PerPage: "10"},
+			want:   &pageRequest{sort: sort, limit: 10, page: 214748365},
+		},
+		{
+			name:   "not a number",
+			params: models.PageParams{Page: "first"},
+			err:    models.ErrInvalidPagination,
+		},
+		{
+			name:   "broken cursor",
+			params: models.PageParams{Cursor: "not-a-cursor"},
+			err:    models.ErrInvalidCursor,
+		},
+		{
+			name:   "cursor of another sort",
+			params: models.PageParams{Cursor: models.Cursor{Sort: "rating,name", Values: []string{"8.8", "Inception"}, ID: id}.Encode()},
+			err:    models.ErrInvalidCursor,
+		},
+		{
+			name:   "cursor without values",
+			params: models.PageParams{Cursor: models.Cursor{Sort: "-rating,name", Values: []string{"8.8"}, ID: id}.Encode()},
+			err:    models.ErrInvalidCursor,
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := s.parsePage(tt.params, sort)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, page)
+		})
+	}
+}
+
+func TestPageRequest(t *testing.T) {
+	keyset := &pageRequest{keyset: true, limit: 2}
+	assert.Equal(t, 3, keyset.fetch())
+	assert.Equal(t, 0, keyset.offset())
+	assert.False(t, keyset.hasMore(2))
+	assert.True(t, keyset.hasMore(3))
+	assert.Equal(t, models.PageInfo{Total: 7, Limit: 2}, keyset.info(7))
+
+	offset := &pageRequest{limit: 5, page: 3}
+	assert.Equal(t, 5, offset.fetch())
+	assert.Equal(t, 10, offset.offset())
+	assert.False(t, offset.hasMore(6))
+	assert.Equal(t, models.PageInfo{Total: 12, Page: 3, PerPage: 5}, offset.info(12))
+}
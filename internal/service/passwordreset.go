@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/ast3am/VKintern-movies/internal/utils"
+	"net/url"
+	"time"
+)
+
+const (
+	passwordResetSubject = "Восстановление пароля"
+	mailSendTimeout      = time.Minute
+)
+
+// ForgotPassword отправляет пользователю письмо со ссылкой для смены пароля. Ответ не зависит от того,
+// существует ли пользователь, письмо отправляется в фоне, чтобы время ответа тоже не выдавало этого.
+// Пользователям, входящим только через провайдер, и заблокированным пользователям письмо не отправляется
+func (s *Service) ForgotPassword(ctx context.Context, email string) error {
+	email = models.NormalizeEmail(email)
+	user, err := s.db.GetUserByEmail(ctx, email)
+	if errors.Is(err, models.ErrUserNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if user.Disabled || user.PasswordAlgo == models.PasswordAlgoNone {
+		s.log.DebugMsg("password reset skipped for disabled or external user")
+		return nil
+	}
+
+	token, err := utils.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	err = s.db.CreatePasswordResetToken(ctx, models.PasswordResetToken{
+		Hash:      utils.HashToken(token),
+		UserID:    user.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.cfg.PasswordReset.TokenTTL),
+	}, now.Add(-s.cfg.PasswordReset.ResendInterval))
+	if errors.Is(err, models.ErrPasswordResetThrottled) {
+		s.log.DebugMsg("password reset throttled")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	msg, err := s.passwordResetMail(user.Email, token)
+	if err != nil {
+		return err
+	}
+	s.sending.Add(1)
+	go func() {
+		defer s.sending.Done()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailSendTimeout)
+		defer cancel()
+		err := s.mail.Send(ctx, msg)
+		if err != nil {
+			s.log.ErrorMsg("can't send password reset mail", err)
+			return
+		}
+		s.log.DebugMsg("password reset mail sent")
+	}()
+	return nil
+}
+
+func (s *Service) passwordResetMail(email, token string) (models.MailMessage, error) {
+	link, err := url.Parse(s.cfg.PasswordReset.URL)
+	if err != nil {
+		return models.MailMessage{}, err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return models.MailMessage{
+		To:      email,
+		Subject: passwordResetSubject,
+		Body: "Для смены пароля перейдите по ссылке:\n" + link.String() + "\n\n" +
+			"Ссылка действует " + s.cfg.PasswordReset.TokenTTL.String() + " и может быть использована один раз.\n" +
+			"Если вы не запрашивали смену пароля, просто проигнорируйте это письмо.\n",
+	}, nil
+}
+
+// ResetPassword устанавливает новый пароль по токену из письма и отзывает все токены пользователя
+func (s *Service) ResetPassword(ctx context.Context, token, password string) error {
+	err := models.ValidatePassword(password)
+	if err != nil {
+		return err
+	}
+	user := models.User{}
+	err = user.SetPassword(password)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	id, email, err := s.db.ResetPasswordByToken(ctx, utils.HashToken(token), now, user.Password, user.PasswordAlgo)
+	if err != nil {
+		return err
+	}
+	s.revoked.setUser(id, &now)
+	s.resetLoginFailures(ctx, email)
+	return nil
+}
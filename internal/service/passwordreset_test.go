@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/ast3am/VKintern-movies/internal/service/mocks"
+	"github.com/ast3am/VKintern-movies/internal/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var regexpLink = regexp.MustCompile(`http://\S+`)
+
+func TestService_ForgotPassword(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	mail := mocks.NewMailer(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, mail, &testCfg)
+	userID := uuid.New()
+
+	//Неизвестный email, ответ не отличается
+	mockDB.On("GetUserByEmail", ctx, "unknown@mail.com").Return(nil, models.ErrUserNotFound).Once()
+	err := s.ForgotPassword(ctx, " Unknown@Mail.com ")
+	assert.NoError(t, err)
+
+	//Пользователь без пароля
+	mockDB.On("GetUserByEmail", ctx, "external@mail.com").Return(&models.User{ID: userID, Email: "external@mail.com", PasswordAlgo: models.PasswordAlgoNone}, nil).Once()
+	log.On("DebugMsg", "password reset skipped for disabled or external user").Return().Once()
+	err = s.ForgotPassword(ctx, "external@mail.com")
+	assert.NoError(t, err)
+
+	//Письмо уже отправлено недавно
+	user := &models.User{ID: userID, Email: "user@mail.com", PasswordAlgo: models.PasswordAlgoBcrypt}
+	mockDB.On("GetUserByEmail", ctx, "user@mail.com").Return(user, nil)
+	mockDB.On("CreatePasswordResetToken", ctx, mock.AnythingOfType("models.PasswordResetToken"), mock.AnythingOfType("time.Time")).
+		Return(models.ErrPasswordResetThrottled).Once()
+	log.On("DebugMsg", "password reset throttled").Return().Once()
+	err = s.ForgotPassword(ctx, "user@mail.com")
+	assert.NoError(t, err)
+
+	//positive, в письме ссылка с токеном, в БД только его хеш
+	var stored models.PasswordResetToken
+	var resendAfter time.Time
+	mockDB.On("CreatePasswordResetToken", ctx, mock.AnythingOfType("models.PasswordResetToken"), mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) {
+			stored, resendAfter = args.Get(1).(models.PasswordResetToken), args.Get(2).(time.Time)
+		}).Return(nil).Once()
+	var sent models.MailMessage
+	mail.On("Send", mock.Anything, mock.AnythingOfType("models.MailMessage")).Run(func(args mock.Arguments) {
+		sent = args.Get(1).(models.MailMessage)
+	}).Return(nil).Once()
+	log.On("DebugMsg", "password reset mail sent").Return().Once()
+	err = s.ForgotPassword(ctx, "user@mail.com")
+	require.NoError(t, err)
+	require.NoError(t, s.Close(ctx))
+	assert.Equal(t, userID, stored.UserID)
+	assert.Equal(t, testCfg.PasswordReset.TokenTTL, stored.ExpiresAt.Sub(stored.CreatedAt))
+	assert.Equal(t, testCfg.PasswordReset.ResendInterval, stored.CreatedAt.Sub(resendAfter))
+	assert.Equal(t, "user@mail.com", sent.To)
+	assert.Equal(t, passwordResetSubject, sent.Subject)
+	link := regexpLink.FindString(sent.Body)
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	assert.Equal(t, "ru", parsed.Query().Get("lang"))
+	assert.Equal(t, stored.Hash, utils.HashToken(parsed.Query().Get("token")))
+
+	//Ошибка отправки только логируется
+	sendErr := errors.New("smtp error")
+	mockDB.On("CreatePasswordResetToken", ctx, mock.AnythingOfType("models.PasswordResetToken"), mock.AnythingOfType("time.Time")).Return(nil).Once()
+	mail.On("Send", mock.Anything, mock.AnythingOfType("models.MailMessage")).Return(sendErr).Once()
+	log.On("ErrorMsg", "can't send password reset mail", sendErr).Return().Once()
+	err = s.ForgotPassword(ctx, "user@mail.com")
+	assert.NoError(t, err)
+	require.NoError(t, s.Close(ctx))
+
+	//Close не ждет дольше своего контекста
+	release := make(chan struct{})
+	mockDB.On("CreatePasswordResetToken", ctx, mock.AnythingOfType("models.PasswordResetToken"), mock.AnythingOfType("time.Time")).Return(nil).Once()
+	mail.On("Send", mock.Anything, mock.AnythingOfType("models.MailMessage")).Run(func(args mock.Arguments) {
+		<-release
+	}).Return(nil).Once()
+	log.On("DebugMsg", "password reset mail sent").Return().Once()
+	err = s.ForgotPassword(ctx, "user@mail.com")
+	assert.NoError(t, err)
+	closeCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Close(closeCtx), context.DeadlineExceeded)
+	close(release)
+	require.NoError(t, s.Close(ctx))
+}
+
+func TestService_ResetPassword(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	userID := uuid.New()
+	hash := utils.HashToken("reset token")
+
+	err := s.ResetPassword(ctx, "reset token", "weak")
+	assert.ErrorIs(t, err, models.ErrWeakPassword)
+
+	mockDB.On("ResetPasswordByToken", ctx, hash, mock.AnythingOfType("time.Time"), mock.AnythingOfType("string"), models.PasswordAlgoBcrypt).
+		Return(uuid.Nil, "", models.ErrInvalidResetToken).Once()
+	err = s.ResetPassword(ctx, "reset token", "newPassword1")
+	assert.ErrorIs(t, err, models.ErrInvalidResetToken)
+
+	//positive, новый пароль сохраняется в виде хеша, токены пользователя отозваны
+	var passwordHash string
+	mockDB.On("ResetPasswordByToken", ctx, hash, mock.AnythingOfType("time.Time"), mock.AnythingOfType("string"), models.PasswordAlgoBcrypt).
+		Run(func(args mock.Arguments) {
+			passwordHash = args.String(3)
+		}).Return(userID, "user@mail.com", nil).Once()
+	mockDB.On("ResetLoginFailures", ctx, models.LoginKeyEmail, "user@mail.com").Return(nil).Once()
+	err = s.ResetPassword(ctx, "reset token", "newPassword1")
+	require.NoError(t, err)
+	user := models.User{Email: "user@mail.com", Password: passwordHash, PasswordAlgo: models.PasswordAlgoBcrypt}
+	assert.True(t, user.CheckCreds("user@mail.com", "newPassword1"))
+	revokedAt, ok := s.revoked.getUser(userID)
+	assert.True(t, ok)
+	assert.NotNil(t, revokedAt)
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"sync"
+	"time"
+)
+
+// revocationCacheTTL - время, в течение которого результат проверки отзыва берется из кеша.
+// Отзыв, сделанный другим экземпляром сервиса, начинает действовать не позже чем через это время
+const revocationCacheTTL = time.Minute
+
+// revocationGracePeriod - запас к exp токена при хранении записи об отзыве, покрывает допуск по времени при проверке токена
+const revocationGracePeriod = 5 * time.Minute
+
+type tokenCacheEntry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+type userCacheEntry struct {
+	revokedAt *time.Time
+	expiresAt time.Time
+}
+
+// revocationCache хранит в памяти результаты проверок отзыва токенов, чтобы не обращаться к БД на каждый запрос.
+// Отозванный токен хранится до окончания его действия, остальные записи - revocationCacheTTL
+type revocationCache struct {
+	mu          sync.Mutex
+	ttl         time.Duration
+	tokens      map[string]tokenCacheEntry
+	users       map[uuid.UUID]userCacheEntry
+	nextCleanup time.Time
+	now         func() time.Time
+}
+
+func newRevocationCache(ttl time.Duration) *revocationCache {
+	return &revocationCache{
+		ttl:    ttl,
+		tokens: make(map[string]tokenCacheEntry),
+		users:  make(map[uuid.UUID]userCacheEntry),
+		now:    time.Now,
+	}
+}
+
+func (c *revocationCache) getToken(jti string) (revoked, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.tokens[jti]
+	if !ok || c.now().After(entry.expiresAt) {
+		return false, false
+	}
+	return entry.revoked, true
+}
+
+// setToken сохраняет результат проверки токена, tokenExpiresAt - время окончания действия самого токена
+func (c *revocationCache) setToken(jti string, revoked bool, tokenExpiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cleanup()
+	expiresAt := c.now().Add(c.ttl)
+	if revoked || tokenExpiresAt.Before(expiresAt) {
+		expiresAt = tokenExpiresAt
+	}
+	c.tokens[jti] = tokenCacheEntry{revoked: revoked, expiresAt: expiresAt}
+}
+
+func (c *revocationCache) getUser(id uuid.UUID) (revokedAt *time.Time, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.users[id]
+	if !ok || c.now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.revokedAt, true
+}
+
+func (c *revocationCache) setUser(id uuid.UUID, revokedAt *time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cleanup()
+	c.users[id] = userCacheEntry{revokedAt: revokedAt, expiresAt: c.now().Add(c.ttl)}
+}
+
+// cleanup удаляет просроченные записи не чаще одного раза за ttl, вызывается под блокировкой
+func (c *revocationCache) cleanup() {
+	now := c.now()
+	if now.Before(c.nextCleanup) {
+		return
+	}
+	for jti, entry := range c.tokens {
+		if now.After(entry.expiresAt) {
+			delete(c.tokens, jti)
+		}
+	}
+	for id, entry := range c.users {
+		if now.After(entry.expiresAt) {
+			delete(c.users, id)
+		}
+	}
+	c.nextCleanup = now.Add(c.ttl)
+}
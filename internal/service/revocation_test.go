@@ -0,0 +1,48 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRevocationCache(t *testing.T) {
+	now := time.Now()
+	c := newRevocationCache(time.Minute)
+	c.now = func() time.Time { return now }
+
+	_, ok := c.getToken("jti")
+	assert.False(t, ok)
+
+	//Не отозванный токен хранится ttl
+	c.setToken("active", false, now.Add(time.Hour))
+	revoked, ok := c.getToken("active")
+	assert.True(t, ok)
+	assert.False(t, revoked)
+
+	//Отозванный токен хранится до окончания действия токена
+	c.setToken("revoked", true, now.Add(time.Hour))
+
+	id := uuid.New()
+	revokedAt := now.Add(-time.Minute)
+	c.setUser(id, &revokedAt)
+	cached, ok := c.getUser(id)
+	assert.True(t, ok)
+	assert.Equal(t, &revokedAt, cached)
+
+	now = now.Add(2 * time.Minute)
+	_, ok = c.getToken("active")
+	assert.False(t, ok)
+	_, ok = c.getUser(id)
+	assert.False(t, ok)
+	revoked, ok = c.getToken("revoked")
+	assert.True(t, ok)
+	assert.True(t, revoked)
+
+	//Просроченные записи удаляются при следующей записи
+	c.setToken("another", false, now.Add(time.Hour))
+	assert.NotContains(t, c.tokens, "active")
+	assert.NotContains(t, c.users, id)
+	assert.Contains(t, c.tokens, "revoked")
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"html"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Пороги похожести по умолчанию для операторов pg_trgm % (similarity) и <% (word_similarity)
+const (
+	similarityThreshold     = 0.3
+	wordSimilarityThreshold = 0.6
+)
+
+// Search ищет фильмы и актеров по похожести названия на запрос с учетом опечаток. Ищутся только те виды
+// объектов, на чтение которых у Principal есть право
+func (s *Service) Search(ctx context.Context, params models.SearchParams) (*models.SearchResults, error) {
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, models.ErrInvalidToken
+	}
+	query := strings.TrimSpace(params.Query)
+	var verr models.ValidationError
+	if query == "" {
+		verr.Add("q", "must not be empty")
+	} else if len(query) > 150 {
+		verr.Add("q", "must be at most 150 characters long")
+	}
+	if params.Kind != "" && !slices.Contains(models.SearchKinds, params.Kind) {
+		verr.Add("kind", "must be movie or actor")
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	kinds := make([]string, 0, len(models.SearchKinds))
+	for _, kind := range models.SearchKinds {
+		if (params.Kind == "" || params.Kind == kind) && principal.HasPermission(searchPermissions[kind]) {
+			kinds = append(kinds, kind)
+		}
+	}
+	if len(kinds) == 0 {
+		return nil, models.ErrPermissionDenied
+	}
+	limit := s.cfg.Pagination.DefaultPageSize
+	if params.Limit != "" {
+		var err error
+		limit, err = strconv.Atoi(params.Limit)
+		if err != nil || limit < 1 || limit > s.cfg.Pagination.MaxPageSize {
+			return nil, models.ErrInvalidPagination
+		}
+	}
+
+	results, err := s.db.Search(ctx, query, kinds, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, val := range results {
+		val.Highlight = highlight(val.Name, query)
+	}
+	return &models.SearchResults{Query: query, Results: results}, nil
+}
+
+var searchPermissions = map[string]models.Permission{
+	models.SearchKindMovie: models.PermMovieRead,
+	models.SearchKindActor: models.PermActorRead,
+}
+
+// highlight выделяет в name слова, похожие на слова запроса, тегом <mark>, остальной текст экранируется
+func highlight(name, query string) string {
+	queryWords := make([]map[string]bool, 0)
+	for _, val := range splitWords(query) {
+		if isWord(val) {
+			queryWords = append(queryWords, trigrams(val))
+		}
+	}
+	var sb strings.Builder
+	for _, val := range splitWords(name) {
+		if !isWord(val) {
+			sb.WriteString(html.EscapeString(val))
+			continue
+		}
+		word := trigrams(val)
+		marked := false
+		for _, queryWord := range queryWords {
+			if wordMatches(word, queryWord) {
+				marked = true
+				break
+			}
+		}
+		if marked {
+			sb.WriteString("<mark>" + html.EscapeString(val) + "</mark>")
+		} else {
+			sb.WriteString(html.EscapeString(val))
+		}
+	}
+	return sb.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isWord(s string) bool {
+	for _, r := range s {
+		return isWordRune(r)
+	}
+	return false
+}
+
+// splitWords делит строку на слова из букв и цифр и промежутки между ними, как pg_trgm
+func splitWords(s string) []string {
+	result := make([]string, 0)
+	start := 0
+	for i, r := range s {
+		if i > start && isWordRune(r) != isWord(s[start:]) {
+			result = append(result, s[start:i])
+			start = i
+		}
+	}
+	if start < len(s) {
+		result = append(result, s[start:])
+	}
+	return result
+}
+
+// trigrams возвращает триграммы слова так же, как pg_trgm: слово в нижнем регистре дополняется
+// двумя пробелами в начале и одним в конце
+func trigrams(word string) map[string]bool {
+	runes := []rune("  " + strings.ToLower(word) + " ")
+	result := make(map[string]bool, len(runes)-2)
+	for i := 0; i+3 <= len(runes); i++ {
+		result[string(runes[i:i+3])] = true
+	}
+	return result
+}
+
+// wordMatches сообщает, что слово названия похоже на слово запроса так же, как их сравнивает поиск:
+// по доле общих триграмм среди всех триграмм обоих слов (similarity) или среди триграмм запроса (word_similarity),
+// поэтому выделяются и слова, начало которых набрано в запросе
+func wordMatches(word, queryWord map[string]bool) bool {
+	common := 0
+	for val := range queryWord {
+		if word[val] {
+			common++
+		}
+	}
+	return float64(common)/float64(len(word)+len(queryWord)-common) >= similarityThreshold ||
+		float64(common)/float64(len(queryWord)) >= wordSimilarityThreshold
+}
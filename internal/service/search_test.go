@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/ast3am/VKintern-movies/internal/service/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestService_Search(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	ctx := models.ContextWithPrincipal(context.TODO(), &models.Principal{
+		UserID:      uuid.New(),
+		Permissions: []models.Permission{models.PermMovieRead, models.PermActorRead},
+	})
+	movieReader := models.ContextWithPrincipal(context.TODO(), &models.Principal{
+		UserID:      uuid.New(),
+		Permissions: []models.Permission{models.PermMovieRead},
+	})
+
+	//Без Principal в контексте поиск не выполняется
+	_, err := s.Search(context.TODO(), models.SearchParams{Query: "hanks"})
+	assert.ErrorIs(t, err, models.ErrInvalidToken)
+
+	testTable := []struct {
+		name    string
+		ctx     context.Context
+		params  models.SearchParams
+		wantErr error
+		fields  []models.FieldError
+	}{
+		{"empty query", ctx, models.SearchParams{Query: "  "}, models.ErrValidation,
+			[]models.FieldError{{Field: "q", Message: "must not be empty"}}},
+		{"long query and unknown kind", ctx, models.SearchParams{Query: strings.Repeat("a", 151), Kind: "user"}, models.ErrValidation,
+			[]models.FieldError{{Field: "q", Message: "must be at most 150 characters long"}, {Field: "kind", Message: "must be movie or actor"}}},
+		{"no permission for kind", movieReader, models.SearchParams{Query: "hanks", Kind: models.SearchKindActor}, models.ErrPermissionDenied, nil},
+		{"invalid limit", ctx, models.SearchParams{Query: "hanks", Limit: "ten"}, models.ErrInvalidPagination, nil},
+		{"limit too large", ctx, models.SearchParams{Query: "hanks", Limit: "11"}, models.ErrInvalidPagination, nil},
+	}
+	for _, test := range testTable {
+		result, err := s.Search(test.ctx, test.params)
+		assert.ErrorIs(t, err, test.wantErr, test.name)
+		assert.Nil(t, result, test.name)
+		if test.fields != nil {
+			var verr *models.ValidationError
+			require.ErrorAs(t, err, &verr, test.name)
+			assert.Equal(t, test.fields, verr.Fields, test.name)
+		}
+	}
+
+	//positive, запрос обрезается, лимит по умолчанию берется из настроек
+	found := []*models.SearchResult{
+		{Kind: models.SearchKindActor, ID: uuid.New(), Name: "Tom Hanks", Score: 1},
+		{Kind: models.SearchKindMovie, ID: uuid.New(), Name: "Inception", Score: 0.4},
+	}
+	mockDB.On("Search", ctx, "tom hanks", []string{models.SearchKindMovie, models.SearchKindActor}, 2).Return(found, nil).Once()
+	result, err := s.Search(ctx, models.SearchParams{Query: " tom hanks "})
+	require.NoError(t, err)
+	assert.Equal(t, "tom hanks", result.Query)
+	assert.Equal(t, "<mark>Tom</mark> <mark>Hanks</mark>", result.Results[0].Highlight)
+	assert.Equal(t, "Inception", result.Results[1].Highlight)
+
+	//Ищутся только фильмы, на чтение актеров нет права
+	mockDB.On("Search", movieReader, "inceptoin", []string{models.SearchKindMovie}, 5).Return([]*models.SearchResult{}, nil).Once()
+	result, err = s.Search(movieReader, models.SearchParams{Query: "inceptoin", Limit: "5"})
+	require.NoError(t, err)
+	assert.Empty(t, result.Results)
+}
+
+func TestHighlight(t *testing.T) {
+	testTable := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{"Tom Hanks", "hanks", "Tom <mark>Hanks</mark>"},
+		{"Inception", "inceptoin", "<mark>Inception</mark>"},
+		{"Inception", "incep", "<mark>Inception</mark>"},
+		{"The Lord of the Rings", "lord rings", "The <mark>Lord</mark> of the <mark>Rings</mark>"},
+		{"Tom & Jerry", "jerry", "Tom &amp; <mark>Jerry</mark>"},
+		{"Брат 2", "брат", "<mark>Брат</mark> 2"},
+		{"Forrest Gump", "matrix", "Forrest Gump"},
+	}
+	for _, test := range testTable {
+		assert.Equal(t, test.expected, highlight(test.name, test.query), test.name+" / "+test.query)
+	}
+}
@@ -3,23 +3,65 @@ package service
 import (
 	"context"
 	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/ast3am/VKintern-movies/internal/utils"
 	"github.com/google/uuid"
+	"sync"
+	"time"
 )
 
 //go:generate mockery --name db
 type db interface {
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
-	GetActorByUUID(ctx context.Context, id uuid.UUID) (*models.Actor, error)
-	CreateActor(ctx context.Context, id uuid.UUID, actor models.Actor) error
+	GetUserByUUID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	GetUserPermissions(ctx context.Context, id uuid.UUID) ([]models.Permission, error)
+	GetUserList(ctx context.Context, limit, offset int) ([]*models.User, int, error)
+	SetUserRoles(ctx context.Context, id uuid.UUID, roles []string) error
+	SetUserDisabled(ctx context.Context, id uuid.UUID, disabled bool) error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	UpdateUserPassword(ctx context.Context, email, password, algo string) error
+	CreateUser(ctx context.Context, id uuid.UUID, user models.User) error
+	CreateRefreshToken(ctx context.Context, token models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, hash string) (*models.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldHash string, token models.RefreshToken) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeToken(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	RevokeUserTokens(ctx context.Context, userID uuid.UUID) error
+	GetUserTokensRevokedAt(ctx context.Context, userID uuid.UUID) (*time.Time, error)
+	RegisterLoginFailure(ctx context.Context, kind, value string, now time.Time, window time.Duration) (int, error)
+	LockLogin(ctx context.Context, kind, value string, until time.Time) error
+	GetLoginLockedUntil(ctx context.Context, email, ip string, now time.Time) (*time.Time, error)
+	ResetLoginFailures(ctx context.Context, kind, value string) error
+	GetTOTP(ctx context.Context, userID uuid.UUID) (*models.TOTP, error)
+	SaveTOTPSecret(ctx context.Context, userID uuid.UUID, secret string) error
+	EnableTOTP(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error
+	UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) error
+	CreateAPIKey(ctx context.Context, key models.APIKey) error
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	GetAPIKeyList(ctx context.Context) ([]*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+	TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt, notAfter time.Time) error
+	CreatePasswordResetToken(ctx context.Context, token models.PasswordResetToken, resendAfter time.Time) error
+	ResetPasswordByToken(ctx context.Context, hash string, now time.Time, password, algo string) (uuid.UUID, string, error)
+	CreateOIDCState(ctx context.Context, state models.OIDCState) error
+	ConsumeOIDCState(ctx context.Context, hash string, now time.Time) (*models.OIDCState, error)
+	GetUserByIdentity(ctx context.Context, issuer, subject string) (*models.User, bool, error)
+	LinkUserIdentity(ctx context.Context, userID uuid.UUID, issuer, subject string) error
+	CreateOIDCUser(ctx context.Context, id uuid.UUID, user models.User, issuer, subject string) error
+	GetActorByUUID(ctx context.Context, id uuid.UUID) (*models.ActorDetails, error)
+	CreateActor(ctx context.Context, id uuid.UUID, actor models.Actor) (*models.ActorDetails, error)
 	UpdateActor(ctx context.Context, id uuid.UUID, actor models.Actor) error
-	GetActorList(ctx context.Context) (map[string][]string, error)
+	GetActorList(ctx context.Context, after *models.Cursor, limit, offset int) ([]*models.ActorDetails, int, error)
 	DeleteActor(ctx context.Context, uid uuid.UUID) error
-	CreateMovie(ctx context.Context, id uuid.UUID, movie models.Movie) error
-	GetMovieByUUID(ctx context.Context, id uuid.UUID) (*models.Movie, error)
+	CreateMovie(ctx context.Context, id uuid.UUID, movie models.Movie) (*models.MovieDetails, error)
+	GetMovieByUUID(ctx context.Context, id uuid.UUID) (*models.MovieDetails, error)
 	UpdateMovie(ctx context.Context, id uuid.UUID, movie models.Movie) error
 	DeleteMovie(ctx context.Context, uid uuid.UUID) error
-	GetMovieList(ctx context.Context, sortby, list string) ([]*models.Movie, error)
+	GetMovieList(ctx context.Context, filter models.MovieFilter, sort models.SortSpec, after *models.Cursor, limit, offset int) ([]*models.Movie, int, error)
 	GetMovie(ctx context.Context, actor, movie string) ([]*models.Movie, error)
+	Search(ctx context.Context, query string, kinds []string, limit int) ([]*models.SearchResult, error)
+	Suggest(ctx context.Context, kind, prefix string, limit int) ([]*models.Suggestion, error)
 }
 
 //go:generate mockery --name logger
@@ -28,14 +70,75 @@ type logger interface {
 	ErrorMsg(msg string, err error)
 }
 
+//go:generate mockery --name tokenManager
+type tokenManager interface {
+	GetToken(userID uuid.UUID, email string, roles []string, permissions []models.Permission) (string, error)
+	GetRefreshToken() (string, time.Time, error)
+	GetMFAToken(userID uuid.UUID, email string) (string, error)
+	ParseToken(token string) (*utils.Claims, error)
+	ParseMFAToken(token string) (*utils.Claims, error)
+	JWKS() models.JWKSet
+}
+
+//go:generate mockery --name oidcProvider
+type oidcProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*models.OIDCClaims, error)
+}
+
+//go:generate mockery --name mailer
+type mailer interface {
+	Send(ctx context.Context, msg models.MailMessage) error
+}
+
 type Service struct {
-	db  db
-	log logger
+	db      db
+	log     logger
+	tokens  tokenManager
+	oidc    oidcProvider
+	mail    mailer
+	cfg     *models.Config
+	revoked *revocationCache
+	// suggestions - подсказки GET /suggest, сбрасываются при изменении фильмов и актеров
+	suggestions *suggestCache
+	// sending - письма, отправляемые в фоне
+	sending sync.WaitGroup
 }
 
-func NewService(db db, log logger) *Service {
+func NewService(db db, log logger, tokens tokenManager, oidc oidcProvider, mail mailer, cfg *models.Config) *Service {
 	return &Service{
-		db,
-		log,
+		db:          db,
+		log:         log,
+		tokens:      tokens,
+		oidc:        oidc,
+		mail:        mail,
+		cfg:         cfg,
+		revoked:     newRevocationCache(revocationCacheTTL),
+		suggestions: newSuggestCache(cfg.Suggest.CacheSize, cfg.Suggest.CacheTTL),
+	}
+}
+
+// Close дожидается отправки писем, отправляемых в фоне. Если ctx завершится раньше, возвращает ошибку ctx,
+// неотправленные письма при этом теряются
+func (s *Service) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.sending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// parseID разбирает UUID из пути запроса
+func parseID(id string) (uuid.UUID, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, models.ErrInvalidID
 	}
+	return uid, nil
 }
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Suggest возвращает фильмы или актеров, название которых начинается с префикса, для подсказок при вводе.
+// Результаты кешируются в памяти по виду объектов и префиксу в нижнем регистре
+func (s *Service) Suggest(ctx context.Context, params models.SuggestParams) (*models.Suggestions, error) {
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, models.ErrInvalidToken
+	}
+	// пробел в конце префикса значим: "tom " не подсказывает "Tomas"
+	prefix := strings.ToLower(strings.TrimLeftFunc(params.Prefix, unicode.IsSpace))
+	var verr models.ValidationError
+	if strings.TrimSpace(prefix) == "" {
+		verr.Add("prefix", "must not be empty")
+	} else if len(prefix) > 150 {
+		verr.Add("prefix", "must be at most 150 characters long")
+	}
+	if !slices.Contains(models.SearchKinds, params.Kind) {
+		verr.Add("kind", "must be movie or actor")
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	if !principal.HasPermission(searchPermissions[params.Kind]) {
+		return nil, models.ErrPermissionDenied
+	}
+
+	result, generation, ok := s.suggestions.get(params.Kind, prefix)
+	if !ok {
+		var err error
+		result, err = s.db.Suggest(ctx, params.Kind, prefix, s.cfg.Suggest.Limit)
+		if err != nil {
+			return nil, err
+		}
+		s.suggestions.set(params.Kind, prefix, generation, result)
+	}
+	return &models.Suggestions{Kind: params.Kind, Prefix: prefix, Suggestions: result}, nil
+}
+
+type suggestKey struct {
+	kind   string
+	prefix string
+}
+
+type suggestCacheEntry struct {
+	result    []*models.Suggestion
+	expiresAt time.Time
+}
+
+// suggestCache хранит в памяти подсказки по префиксам. Изменение фильма или актера сбрасывает все подсказки
+// своего вида, записи других экземпляров сервиса становятся видны после ttl. Сохраненные подсказки
+// отдаются всем запросам с тем же префиксом и не изменяются
+type suggestCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[suggestKey]suggestCacheEntry
+	// generations меняется при сбросе вида, чтобы не сохранить подсказки, прочитанные из БД до изменения
+	generations map[string]uint64
+	now         func() time.Time
+}
+
+func newSuggestCache(size int, ttl time.Duration) *suggestCache {
+	return &suggestCache{
+		ttl:         ttl,
+		size:        size,
+		entries:     make(map[suggestKey]suggestCacheEntry),
+		generations: make(map[string]uint64),
+		now:         time.Now,
+	}
+}
+
+// get возвращает подсказки из кеша и номер поколения вида, который передается в set после чтения из БД
+func (c *suggestCache) get(kind, prefix string) (result []*models.Suggestion, generation uint64, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[suggestKey{kind, prefix}]
+	if !ok || c.now().After(entry.expiresAt) {
+		return nil, c.generations[kind], false
+	}
+	return entry.result, c.generations[kind], true
+}
+
+// set сохраняет подсказки, если вид не сбрасывался после get. Если кеш заполнен, удаляются просроченные записи,
+// а если таких нет - все записи
+func (c *suggestCache) set(kind, prefix string, generation uint64, result []*models.Suggestion) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size <= 0 || c.generations[kind] != generation {
+		return
+	}
+	now := c.now()
+	if len(c.entries) >= c.size {
+		for key, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+	}
+	if len(c.entries) >= c.size {
+		clear(c.entries)
+	}
+	c.entries[suggestKey{kind, prefix}] = suggestCacheEntry{result: result, expiresAt: now.Add(c.ttl)}
+}
+
+// invalidate сбрасывает подсказки вида kind после изменения фильма или актера
+func (c *suggestCache) invalidate(kind string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generations[kind]++
+	for key := range c.entries {
+		if key.kind == kind {
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/ast3am/VKintern-movies/internal/service/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestService_Suggest(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	ctx := models.ContextWithPrincipal(context.TODO(), &models.Principal{
+		UserID:      uuid.New(),
+		Permissions: []models.Permission{models.PermMovieRead, models.PermActorRead, models.PermActorWrite, models.PermMovieDelete},
+	})
+	movieReader := models.ContextWithPrincipal(context.TODO(), &models.Principal{
+		UserID:      uuid.New(),
+		Permissions: []models.Permission{models.PermMovieRead},
+	})
+
+	//Без Principal в контексте подсказки не выдаются
+	_, err := s.Suggest(context.TODO(), models.SuggestParams{Prefix: "tom", Kind: models.SearchKindActor})
+	assert.ErrorIs(t, err, models.ErrInvalidToken)
+
+	testTable := []struct {
+		name    string
+		ctx     context.Context
+		params  models.SuggestParams
+		wantErr error
+		fields  []models.FieldError
+	}{
+		{"empty prefix and no kind", ctx, models.SuggestParams{Prefix: "  "}, models.ErrValidation,
+			[]models.FieldError{{Field: "prefix", Message: "must not be empty"}, {Field: "kind", Message: "must be movie or actor"}}},
+		{"unknown kind", ctx, models.SuggestParams{Prefix: "tom", Kind: "user"}, models.ErrValidation,
+			[]models.FieldError{{Field: "kind", Message: "must be movie or actor"}}},
+		{"no permission for kind", movieReader, models.SuggestParams{Prefix: "tom", Kind: models.SearchKindActor}, models.ErrPermissionDenied, nil},
+	}
+	for _, test := range testTable {
+		result, err := s.Suggest(test.ctx, test.params)
+		assert.ErrorIs(t, err, test.wantErr, test.name)
+		assert.Nil(t, result, test.name)
+		if test.fields != nil {
+			var verr *models.ValidationError
+			require.ErrorAs(t, err, &verr, test.name)
+			assert.Equal(t, test.fields, verr.Fields, test.name)
+		}
+	}
+
+	//positive, префикс приводится к нижнему регистру, повторный запрос берется из кеша
+	actors := []*models.Suggestion{{ID: uuid.New(), Name: "Tom Hanks"}}
+	mockDB.On("Suggest", ctx, models.SearchKindActor, "tom ", 3).Return(actors, nil).Once()
+	result, err := s.Suggest(ctx, models.SuggestParams{Prefix: " Tom ", Kind: models.SearchKindActor})
+	require.NoError(t, err)
+	assert.Equal(t, &models.Suggestions{Kind: models.SearchKindActor, Prefix: "tom ", Suggestions: actors}, result)
+	result, err = s.Suggest(ctx, models.SuggestParams{Prefix: "TOM ", Kind: models.SearchKindActor})
+	require.NoError(t, err)
+	assert.Equal(t, actors, result.Suggestions)
+
+	//Ошибка БД не кешируется
+	mockDB.On("Suggest", ctx, models.SearchKindMovie, "for", 3).Return(nil, errors.New("db error")).Once()
+	_, err = s.Suggest(ctx, models.SuggestParams{Prefix: "for", Kind: models.SearchKindMovie})
+	assert.EqualError(t, err, "db error")
+	movies := []*models.Suggestion{{ID: uuid.New(), Name: "Forrest Gump"}}
+	mockDB.On("Suggest", ctx, models.SearchKindMovie, "for", 3).Return(movies, nil).Once()
+	result, err = s.Suggest(ctx, models.SuggestParams{Prefix: "for", Kind: models.SearchKindMovie})
+	require.NoError(t, err)
+	assert.Equal(t, movies, result.Suggestions)
+
+	//Новый актер сбрасывает подсказки актеров, подсказки фильмов остаются в кеше
+	actor := models.Actor{Name: "Tom Holland", Gender: "Male", BirthDate: GetDate("1996-06-01")}
+	mockDB.On("CreateActor", ctx, mock.AnythingOfType("uuid.UUID"), actor).Return(&models.ActorDetails{Name: "Tom Holland"}, nil).Once()
+	_, err = s.CreateActor(ctx, actor)
+	require.NoError(t, err)
+	actors = append(actors, &models.Suggestion{ID: uuid.New(), Name: "Tom Holland"})
+	mockDB.On("Suggest", ctx, models.SearchKindActor, "tom ", 3).Return(actors, nil).Once()
+	result, err = s.Suggest(ctx, models.SuggestParams{Prefix: "tom ", Kind: models.SearchKindActor})
+	require.NoError(t, err)
+	assert.Equal(t, actors, result.Suggestions)
+	result, err = s.Suggest(ctx, models.SuggestParams{Prefix: "for", Kind: models.SearchKindMovie})
+	require.NoError(t, err)
+	assert.Equal(t, movies, result.Suggestions)
+
+	//Удаление фильма сбрасывает подсказки фильмов
+	movieID := uuid.New()
+	mockDB.On("DeleteMovie", ctx, movieID).Return(nil).Once()
+	require.NoError(t, s.DeleteMovie(ctx, movieID.String()))
+	mockDB.On("Suggest", ctx, models.SearchKindMovie, "for", 3).Return([]*models.Suggestion{}, nil).Once()
+	result, err = s.Suggest(ctx, models.SuggestParams{Prefix: "for", Kind: models.SearchKindMovie})
+	require.NoError(t, err)
+	assert.Empty(t, result.Suggestions)
+}
+
+func TestSuggestCache(t *testing.T) {
+	now := time.Date(2024, time.March, 16, 10, 0, 0, 0, time.UTC)
+	c := newSuggestCache(2, time.Minute)
+	c.now = func() time.Time { return now }
+	tom := []*models.Suggestion{{ID: uuid.New(), Name: "Tom Hanks"}}
+
+	_, generation, ok := c.get(models.SearchKindActor, "tom")
+	assert.False(t, ok)
+	c.set(models.SearchKindActor, "tom", generation, tom)
+	result, _, ok := c.get(models.SearchKindActor, "tom")
+	assert.True(t, ok)
+	assert.Equal(t, tom, result)
+
+	//Подсказки, прочитанные до изменения актера, не сохраняются
+	_, generation, _ = c.get(models.SearchKindActor, "jul")
+	c.invalidate(models.SearchKindActor)
+	c.set(models.SearchKindActor, "jul", generation, []*models.Suggestion{})
+	_, _, ok = c.get(models.SearchKindActor, "jul")
+	assert.False(t, ok)
+	_, _, ok = c.get(models.SearchKindActor, "tom")
+	assert.False(t, ok)
+
+	//Запись действует ttl, заполненный кеш освобождает место
+	_, generation, _ = c.get(models.SearchKindMovie, "for")
+	c.set(models.SearchKindMovie, "for", generation, []*models.Suggestion{})
+	now = now.Add(time.Minute + time.Second)
+	_, _, ok = c.get(models.SearchKindMovie, "for")
+	assert.False(t, ok)
+	c.set(models.SearchKindMovie, "inc", generation, []*models.Suggestion{})
+	c.set(models.SearchKindMovie, "pre", generation, []*models.Suggestion{})
+	c.set(models.SearchKindMovie, "tra", generation, []*models.Suggestion{})
+	assert.LessOrEqual(t, len(c.entries), 2)
+	_, _, ok = c.get(models.SearchKindMovie, "tra")
+	assert.True(t, ok)
+
+	//Размер 0 отключает кеш
+	c = newSuggestCache(0, time.Minute)
+	c.set(models.SearchKindActor, "tom", 0, tom)
+	_, _, ok = c.get(models.SearchKindActor, "tom")
+	assert.False(t, ok)
+}
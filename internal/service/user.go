@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultUserListLimit = 20
+	maxUserListLimit     = 100
+)
+
+func (s *Service) GetUserList(ctx context.Context, limit, offset string) (*models.UserList, error) {
+	result := &models.UserList{Limit: defaultUserListLimit}
+	var err error
+	if limit != "" {
+		result.Limit, err = strconv.Atoi(limit)
+		if err != nil || result.Limit < 1 || result.Limit > maxUserListLimit {
+			return nil, models.ErrInvalidPagination
+		}
+	}
+	if offset != "" {
+		result.Offset, err = strconv.Atoi(offset)
+		if err != nil || result.Offset < 0 {
+			return nil, models.ErrInvalidPagination
+		}
+	}
+	result.Users, result.Total, err = s.db.GetUserList(ctx, result.Limit, result.Offset)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Service) GetUser(ctx context.Context, id string) (*models.User, error) {
+	uid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.db.GetUserByUUID(ctx, uid)
+}
+
+// SetUserRoles заменяет роли пользователя. Права записаны в выданных токенах,
+// поэтому токены пользователя отзываются, чтобы понижение прав действовало сразу
+func (s *Service) SetUserRoles(ctx context.Context, id string, roles []string) error {
+	uid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	if len(roles) == 0 {
+		return models.ErrEmptyRoles
+	}
+	err = s.db.SetUserRoles(ctx, uid, roles)
+	if err != nil {
+		return err
+	}
+	return s.revokeUserTokens(ctx, uid)
+}
+
+// DisableUser блокирует вход пользователя и отзывает все его токены
+func (s *Service) DisableUser(ctx context.Context, id string) error {
+	uid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	err = s.db.SetUserDisabled(ctx, uid, true)
+	if err != nil {
+		return err
+	}
+	return s.revokeUserTokens(ctx, uid)
+}
+
+func (s *Service) EnableUser(ctx context.Context, id string) error {
+	uid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return s.db.SetUserDisabled(ctx, uid, false)
+}
+
+// ResetUserPassword устанавливает пользователю новый пароль и отзывает все его токены
+func (s *Service) ResetUserPassword(ctx context.Context, id, password string) error {
+	uid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	err = models.ValidatePassword(password)
+	if err != nil {
+		return err
+	}
+	user, err := s.db.GetUserByUUID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	err = user.SetPassword(password)
+	if err != nil {
+		return err
+	}
+	err = s.db.UpdateUserPassword(ctx, user.Email, user.Password, user.PasswordAlgo)
+	if err != nil {
+		return err
+	}
+	return s.revokeUserTokens(ctx, uid)
+}
+
+func (s *Service) DeleteUser(ctx context.Context, id string) error {
+	uid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	err = s.db.DeleteUser(ctx, uid)
+	if err != nil {
+		return err
+	}
+	// записи об отзыве удаляются вместе с пользователем, кеш не должен пропускать его токены до истечения ttl
+	now := time.Now()
+	s.revoked.setUser(uid, &now)
+	return nil
+}
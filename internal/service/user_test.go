@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/ast3am/VKintern-movies/internal/service/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestService_GetUserList(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	users := []*models.User{{ID: uuid.New(), Email: "admin@vk.ru", Roles: []string{"admin"}}}
+
+	mockDB.On("GetUserList", ctx, defaultUserListLimit, 0).Return(users, 1, nil).Once()
+	result, err := s.GetUserList(ctx, "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.UserList{Users: users, Total: 1, Limit: defaultUserListLimit}, result)
+
+	mockDB.On("GetUserList", ctx, 5, 10).Return([]*models.User{}, 1, nil).Once()
+	result, err = s.GetUserList(ctx, "5", "10")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.Limit)
+	assert.Equal(t, 10, result.Offset)
+
+	for _, test := range [][2]string{{"0", ""}, {"101", ""}, {"abc", ""}, {"", "-1"}, {"", "abc"}} {
+		_, err = s.GetUserList(ctx, test[0], test[1])
+		assert.ErrorIs(t, err, models.ErrInvalidPagination, test)
+	}
+}
+
+func TestService_SetUserRoles(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	id := uuid.New()
+
+	//Смена ролей отзывает токены пользователя
+	mockDB.On("SetUserRoles", ctx, id, []string{models.RoleEditor}).Return(nil).Once()
+	mockDB.On("RevokeUserTokens", ctx, id).Return(nil).Once()
+	err := s.SetUserRoles(ctx, id.String(), []string{models.RoleEditor})
+	assert.NoError(t, err)
+	revokedAt, ok := s.revoked.getUser(id)
+	assert.True(t, ok)
+	assert.NotNil(t, revokedAt)
+
+	err = s.SetUserRoles(ctx, id.String(), nil)
+	assert.ErrorIs(t, err, models.ErrEmptyRoles)
+	err = s.SetUserRoles(ctx, "not uuid", []string{models.RoleEditor})
+	assert.NotNil(t, err)
+
+	mockDB.On("SetUserRoles", ctx, id, []string{"unknown"}).Return(models.ErrUnknownRole).Once()
+	err = s.SetUserRoles(ctx, id.String(), []string{"unknown"})
+	assert.ErrorIs(t, err, models.ErrUnknownRole)
+}
+
+func TestService_DisableUser(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	id := uuid.New()
+
+	mockDB.On("SetUserDisabled", ctx, id, true).Return(nil).Once()
+	mockDB.On("RevokeUserTokens", ctx, id).Return(nil).Once()
+	err := s.DisableUser(ctx, id.String())
+	assert.NoError(t, err)
+
+	mockDB.On("SetUserDisabled", ctx, id, false).Return(nil).Once()
+	err = s.EnableUser(ctx, id.String())
+	assert.NoError(t, err)
+
+	mockDB.On("SetUserDisabled", ctx, id, true).Return(models.ErrUserNotFound).Once()
+	err = s.DisableUser(ctx, id.String())
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+}
+
+func TestService_ResetUserPassword(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	user := &models.User{ID: uuid.New(), Email: "testuser@mail.com", Roles: []string{"user"}}
+
+	mockDB.On("GetUserByUUID", ctx, user.ID).Return(user, nil).Once()
+	mockDB.On("UpdateUserPassword", ctx, user.Email, mock.MatchedBy(func(hash string) bool {
+		return (&models.User{Email: user.Email, Password: hash, PasswordAlgo: models.PasswordAlgoBcrypt}).CheckCreds(user.Email, "newPassword1")
+	}), models.PasswordAlgoBcrypt).Return(nil).Once()
+	mockDB.On("RevokeUserTokens", ctx, user.ID).Return(nil).Once()
+	err := s.ResetUserPassword(ctx, user.ID.String(), "newPassword1")
+	assert.NoError(t, err)
+
+	err = s.ResetUserPassword(ctx, user.ID.String(), "weak")
+	assert.ErrorIs(t, err, models.ErrWeakPassword)
+
+	mockDB.On("GetUserByUUID", ctx, user.ID).Return(nil, models.ErrUserNotFound).Once()
+	err = s.ResetUserPassword(ctx, user.ID.String(), "newPassword1")
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+}
+
+func TestService_DeleteUser(t *testing.T) {
+	mockDB := mocks.NewDb(t)
+	log := mocks.NewLogger(t)
+	tokens := mocks.NewTokenManager(t)
+	ctx := context.TODO()
+	s := NewService(mockDB, log, tokens, nil, nil, &testCfg)
+	id := uuid.New()
+
+	mockDB.On("DeleteUser", ctx, id).Return(nil).Once()
+	err := s.DeleteUser(ctx, id.String())
+	assert.NoError(t, err)
+	_, ok := s.revoked.getUser(id)
+	assert.True(t, ok)
+
+	mockDB.On("DeleteUser", ctx, id).Return(models.ErrUserNotFound).Once()
+	err = s.DeleteUser(ctx, id.String())
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+	err = s.DeleteUser(ctx, "not uuid")
+	assert.NotNil(t, err)
+}
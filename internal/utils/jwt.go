@@ -2,53 +2,255 @@ package utils
 
 import (
 	"errors"
+	"fmt"
+	"github.com/ast3am/VKintern-movies/internal/models"
 	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"sort"
 	"time"
 )
 
-var roleMap = map[string]int{
-	"user":  0,
-	"admin": 1,
+// mfaAudienceSuffix добавляется к audience токена, ожидающего второй фактор, поэтому такой токен
+// не принимается там, где нужен access токен, и наоборот
+const mfaAudienceSuffix = "/mfa"
+
+var errNotValidToken = errors.New("not a valid token")
+
+// Claims - содержимое access токена, sub содержит UUID пользователя, jti - уникальный идентификатор токена.
+// Права ролей записываются в токен при выпуске, изменения ролей вступают в силу с новым токеном.
+// iat_us - время выпуска в микросекундах, iat хранится с точностью до секунды и для сравнения с отзывом не подходит
+type Claims struct {
+	Email         string              `json:"email"`
+	Roles         []string            `json:"roles"`
+	Permissions   []models.Permission `json:"permissions"`
+	IssuedAtMicro int64               `json:"iat_us,omitempty"`
+	jwt.StandardClaims
 }
 
-func GetToken(email, role string) (string, error) {
-	secretKey := []byte("my_secret_key")
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"email": email,
-		"role":  role,
-		"exp":   time.Now().Add(time.Hour * 1).Unix(),
-	})
+// IssuedBefore сообщает, выпущен ли токен раньше момента t. У токенов без iat_us известна только секунда выпуска,
+// поэтому выпущенные в ту же секунду, что и t, тоже считаются выпущенными раньше
+func (c *Claims) IssuedBefore(t time.Time) bool {
+	if c.IssuedAtMicro != 0 {
+		return c.IssuedAtMicro < t.UnixMicro()
+	}
+	return c.IssuedAt <= t.Unix()
+}
+
+func (c *Claims) HasPermission(permission models.Permission) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenManager выпускает access и refresh токены и проверяет access токены с параметрами из конфига.
+// Если в конфиге заданы ключи, токены подписываются RS256 или EdDSA ключом signing_key_id и проверяются ключом из kid,
+// иначе используется HS256 с секретом
+type TokenManager struct {
+	signing    *signingKey
+	keys       map[string]*verificationKey
+	issuer     string
+	audience   string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	mfaTTL     time.Duration
+	clockSkew  time.Duration
+	now        func() time.Time
+}
+
+// minSecretLength - наименьшая длина секрета HS256 в байтах, короткий секрет подбирается перебором по одному токену
+const minSecretLength = 32
+
+func NewTokenManager(cfg models.JWTConfig) (*TokenManager, error) {
+	if cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, errors.New("jwt issuer and audience must be set")
+	}
+	if cfg.AccessTTL <= 0 || cfg.RefreshTTL <= 0 || cfg.MFATTL <= 0 {
+		return nil, errors.New("jwt access, refresh and mfa ttl must be positive")
+	}
+	tm := &TokenManager{
+		issuer:     cfg.Issuer,
+		audience:   cfg.Audience,
+		accessTTL:  cfg.AccessTTL,
+		refreshTTL: cfg.RefreshTTL,
+		mfaTTL:     cfg.MFATTL,
+		clockSkew:  cfg.ClockSkew,
+		now:        time.Now,
+	}
+	if len(cfg.Keys) > 0 {
+		err := tm.loadKeys(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return tm, nil
+	}
+	if cfg.Secret == "" {
+		return nil, errors.New("jwt secret is not set, use JWT_SECRET, JWT_SECRET_FILE or jwt keys")
+	}
+	if len(cfg.Secret) < minSecretLength {
+		return nil, fmt.Errorf("jwt secret must be at least %d bytes long", minSecretLength)
+	}
+	secret := []byte(cfg.Secret)
+	tm.signing = &signingKey{
+		verificationKey: verificationKey{method: jwt.SigningMethodHS256, publicKey: secret},
+		privateKey:      secret,
+	}
+	tm.keys = map[string]*verificationKey{"": &tm.signing.verificationKey}
+	return tm, nil
+}
+
+// loadKeys загружает ключи из конфига. Ключи без приватной части остаются только для проверки токенов,
+// это позволяет менять ключ подписи, не отзывая уже выпущенные токены
+func (tm *TokenManager) loadKeys(cfg models.JWTConfig) error {
+	signingKeyID := cfg.SigningKeyID
+	if signingKeyID == "" {
+		signingKeyID = cfg.Keys[0].ID
+	}
+	tm.keys = make(map[string]*verificationKey, len(cfg.Keys))
+	for _, keyCfg := range cfg.Keys {
+		if _, ok := tm.keys[keyCfg.ID]; ok {
+			return fmt.Errorf("jwt key %s is duplicated", keyCfg.ID)
+		}
+		key, privateKey, err := loadKey(keyCfg)
+		if err != nil {
+			return err
+		}
+		tm.keys[key.id] = key
+		if key.id != signingKeyID {
+			continue
+		}
+		if privateKey == nil {
+			return fmt.Errorf("jwt signing key %s has no private key", key.id)
+		}
+		tm.signing = &signingKey{verificationKey: *key, privateKey: privateKey}
+	}
+	if tm.signing == nil {
+		return fmt.Errorf("jwt signing key %s not found", signingKeyID)
+	}
+	return nil
+}
+
+func (tm *TokenManager) GetToken(userID uuid.UUID, email string, roles []string, permissions []models.Permission) (string, error) {
+	return tm.sign(Claims{
+		Email:       email,
+		Roles:       roles,
+		Permissions: permissions,
+	}, userID, tm.audience, tm.accessTTL)
+}
 
-	resToken, err := token.SignedString(secretKey)
+// GetMFAToken выпускает короткоживущий токен после проверки пароля, который обменивается на access токен
+// вместе с кодом второго фактора. Токен не содержит прав и имеет отдельный audience
+func (tm *TokenManager) GetMFAToken(userID uuid.UUID, email string) (string, error) {
+	return tm.sign(Claims{Email: email}, userID, tm.audience+mfaAudienceSuffix, tm.mfaTTL)
+}
+
+func (tm *TokenManager) sign(claims Claims, userID uuid.UUID, audience string, ttl time.Duration) (string, error) {
+	now := tm.now()
+	claims.IssuedAtMicro = now.UnixMicro()
+	claims.StandardClaims = jwt.StandardClaims{
+		Id:        uuid.NewString(),
+		Subject:   userID.String(),
+		Issuer:    tm.issuer,
+		Audience:  audience,
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+	token := jwt.NewWithClaims(tm.signing.method, claims)
+	if tm.signing.id != "" {
+		token.Header["kid"] = tm.signing.id
+	}
+
+	resToken, err := token.SignedString(tm.signing.privateKey)
 	if err != nil {
 		return "", errors.New("can't make token")
 	}
 	return resToken, nil
 }
 
-func CheckPermissionByToken(token, permissionLevel string) error {
-	secretKey := []byte("my_secret_key")
-	tokenParse, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method from token")
+// GetRefreshToken возвращает новый refresh токен и время окончания его действия
+func (tm *TokenManager) GetRefreshToken() (string, time.Time, error) {
+	token, err := NewOpaqueToken()
+	if err != nil {
+		return "", time.Time{}, errors.New("can't make refresh token")
+	}
+	return token, tm.now().Add(tm.refreshTTL), nil
+}
+
+// ParseToken проверяет подпись и срок действия токена без проверки прав
+func (tm *TokenManager) ParseToken(token string) (*Claims, error) {
+	return tm.parse(token, tm.audience)
+}
+
+// ParseMFAToken проверяет токен, выпущенный GetMFAToken
+func (tm *TokenManager) ParseMFAToken(token string) (*Claims, error) {
+	return tm.parse(token, tm.audience+mfaAudienceSuffix)
+}
+
+func (tm *TokenManager) parse(token, audience string) (*Claims, error) {
+	claims := &Claims{}
+	// стандартная проверка jwt-go не учитывает допуск по времени, поэтому claims проверяются в validateClaims
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	_, err := parser.ParseWithClaims(token, claims, tm.keyFunc)
+	if err != nil {
+		return nil, errNotValidToken
+	}
+	err = tm.validateClaims(claims, audience)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// keyFunc выбирает ключ проверки по kid. alg из заголовка должен совпадать с алгоритмом ключа,
+// иначе возможна подмена алгоритма, например HS256 с публичным RSA ключом в качестве секрета или alg none
+func (tm *TokenManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := tm.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown key id")
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method from token")
+	}
+	return key.publicKey, nil
+}
+
+// JWKS возвращает публичные ключи проверки токенов, при подписи HS256 список пуст
+func (tm *TokenManager) JWKS() models.JWKSet {
+	result := models.JWKSet{Keys: []models.JWK{}}
+	for _, key := range tm.keys {
+		if key.method == jwt.SigningMethodHS256 {
+			continue
 		}
-		return secretKey, nil
+		result.Keys = append(result.Keys, key.jwk())
+	}
+	sort.Slice(result.Keys, func(i, j int) bool {
+		return result.Keys[i].Kid < result.Keys[j].Kid
 	})
+	return result
+}
 
-	if err != nil {
-		err = errors.New("not a valid token")
-		return err
-	}
-	role := ""
-	if claims, ok := tokenParse.Claims.(jwt.MapClaims); ok && tokenParse.Valid {
-		role = claims["role"].(string)
-	} else {
-		err = errors.New("not a valid token")
-		return err
-	}
-	if roleMap[role] < roleMap[permissionLevel] {
-		err = errors.New("permission denied")
-		return err
+func (tm *TokenManager) validateClaims(claims *Claims, audience string) error {
+	now := tm.now().Unix()
+	skew := int64(tm.clockSkew.Seconds())
+	switch {
+	case claims.ExpiresAt == 0 || claims.IssuedAt == 0 || claims.NotBefore == 0:
+		return errNotValidToken
+	case claims.Id == "" || claims.Subject == "":
+		return errNotValidToken
+	case now > claims.ExpiresAt+skew:
+		return errNotValidToken
+	case now < claims.NotBefore-skew:
+		return errNotValidToken
+	case now < claims.IssuedAt-skew:
+		return errNotValidToken
+	case !claims.VerifyIssuer(tm.issuer, true):
+		return errNotValidToken
+	case !claims.VerifyAudience(audience, true):
+		return errNotValidToken
 	}
 	return nil
 }
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/ast3am/VKintern-movies/internal/models"
+	"github.com/dgrijalva/jwt-go"
+	"math/big"
+	"os"
+)
+
+const minRSAKeyBits = 2048
+
+// verificationKey - ключ, которым проверяются токены с указанным kid. Алгоритм определяется типом ключа,
+// поэтому токен с тем же kid, но другим alg не принимается
+type verificationKey struct {
+	id        string
+	method    jwt.SigningMethod
+	publicKey interface{}
+}
+
+// signingKey - ключ, которым подписываются новые токены
+type signingKey struct {
+	verificationKey
+	privateKey interface{}
+}
+
+// loadKey читает ключ из PEM файлов. Если задан только приватный ключ, публичный ключ получается из него,
+// если только публичный - ключ используется лишь для проверки ранее выпущенных токенов
+func loadKey(cfg models.JWTKey) (*verificationKey, interface{}, error) {
+	if cfg.ID == "" {
+		return nil, nil, errors.New("jwt key id is not set")
+	}
+	var privateKey, publicKey interface{}
+	if cfg.PrivateKeyFile != "" {
+		key, err := readPrivateKey(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("jwt key %s: %w", cfg.ID, err)
+		}
+		privateKey = key
+		switch key := key.(type) {
+		case *rsa.PrivateKey:
+			publicKey = &key.PublicKey
+		case ed25519.PrivateKey:
+			publicKey = key.Public()
+		}
+	}
+	if cfg.PublicKeyFile != "" {
+		key, err := readPublicKey(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("jwt key %s: %w", cfg.ID, err)
+		}
+		if publicKey != nil && !samePublicKey(publicKey, key) {
+			return nil, nil, fmt.Errorf("jwt key %s: public key does not match private key", cfg.ID)
+		}
+		publicKey = key
+	}
+	if publicKey == nil {
+		return nil, nil, fmt.Errorf("jwt key %s: key file is not set", cfg.ID)
+	}
+
+	key := &verificationKey{id: cfg.ID, publicKey: publicKey}
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		if publicKey.N.BitLen() < minRSAKeyBits {
+			return nil, nil, fmt.Errorf("jwt key %s: rsa key must be at least %d bits", cfg.ID, minRSAKeyBits)
+		}
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = SigningMethodEdDSA
+	default:
+		return nil, nil, fmt.Errorf("jwt key %s: unsupported key type %T", cfg.ID, publicKey)
+	}
+	return key, privateKey, nil
+}
+
+func readPrivateKey(path string) (interface{}, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	return nil, fmt.Errorf("unexpected PEM block %q in %s", block.Type, path)
+}
+
+func readPublicKey(path string) (interface{}, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	return nil, fmt.Errorf("unexpected PEM block %q in %s", block.Type, path)
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+	return block, nil
+}
+
+func samePublicKey(a, b interface{}) bool {
+	key, ok := a.(interface{ Equal(x crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
+
+// jwk возвращает публичную часть ключа в формате JWK
+func (k *verificationKey) jwk() models.JWK {
+	result := models.JWK{
+		Use: "sig",
+		Alg: k.method.Alg(),
+		Kid: k.id,
+	}
+	switch publicKey := k.publicKey.(type) {
+	case *rsa.PublicKey:
+		result.Kty = "RSA"
+		result.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		result.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case ed25519.PublicKey:
+		result.Kty = "OKP"
+		result.Crv = "Ed25519"
+		result.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	}
+	return result
+}
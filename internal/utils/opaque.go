@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+const opaqueTokenSize = 32
+
+// apiKeyPrefix отличает API ключи от других секретов, например при поиске утечек в репозиториях
+const (
+	apiKeyPrefix     = "vkm_"
+	apiKeyPrefixSize = 4
+)
+
+// NewOpaqueToken возвращает случайный токен, не несущий данных, для хранения в БД используется HashToken
+func NewOpaqueToken() (string, error) {
+	buf := make([]byte, opaqueTokenSize)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewAPIKey возвращает новый API ключ и его видимый префикс, по которому ключ можно узнать в списке
+func NewAPIKey() (key, prefix string, err error) {
+	buf := make([]byte, apiKeyPrefixSize)
+	_, err = rand.Read(buf)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	prefix = apiKeyPrefix + hex.EncodeToString(buf)
+	return prefix + "_" + secret, prefix, nil
+}
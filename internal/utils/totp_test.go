@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+// секрет "12345678901234567890" из тестовых векторов RFC 6238
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	//Последние 6 цифр 8-значных кодов SHA1 из RFC 6238
+	testTable := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, test := range testTable {
+		code, err := TOTPCode(rfcTOTPSecret, time.Unix(test.unix, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, test.code, code, test.unix)
+	}
+	_, err := TOTPCode("not base32!", time.Now())
+	assert.Error(t, err)
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / totpPeriod
+	code, err := TOTPCode(rfcTOTPSecret, now)
+	require.NoError(t, err)
+
+	matched, ok := ValidateTOTP(rfcTOTPSecret, code, now, 0)
+	assert.True(t, ok)
+	assert.Equal(t, step, matched)
+
+	//Допуск в один период
+	matched, ok = ValidateTOTP(rfcTOTPSecret, code, now.Add(totpPeriod*time.Second), 0)
+	assert.True(t, ok)
+	assert.Equal(t, step, matched)
+	_, ok = ValidateTOTP(rfcTOTPSecret, code, now.Add(2*totpPeriod*time.Second), 0)
+	assert.False(t, ok)
+
+	//Уже использованный код не принимается
+	_, ok = ValidateTOTP(rfcTOTPSecret, code, now, step)
+	assert.False(t, ok)
+
+	for _, wrong := range []string{"", "12345", "1234567", "000000"} {
+		_, ok = ValidateTOTP(rfcTOTPSecret, wrong, now, 0)
+		assert.False(t, ok, wrong)
+	}
+	_, ok = ValidateTOTP("not base32!", code, now, 0)
+	assert.False(t, ok)
+}
+
+func TestNewTOTPSecret(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+	another, err := NewTOTPSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, another)
+	_, err = TOTPCode(secret, time.Now())
+	assert.NoError(t, err)
+
+	uri := TOTPURI("VKintern-movies", "admin@vk.ru", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/VKintern-movies:admin@vk.ru?"), uri)
+	assert.Contains(t, uri, "secret="+secret)
+}
+
+func TestRecoveryCode(t *testing.T) {
+	code, err := NewRecoveryCode()
+	require.NoError(t, err)
+	assert.Len(t, code, 11)
+	assert.Equal(t, "-", code[5:6])
+	assert.Equal(t, strings.ReplaceAll(code, "-", ""), NormalizeRecoveryCode(code))
+	assert.Equal(t, NormalizeRecoveryCode(code), NormalizeRecoveryCode(" "+strings.ToLower(code)+" "))
+}
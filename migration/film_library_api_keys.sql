@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS api_keys(
+    uuid         uuid primary key,
+    name         varchar     not null,
+    prefix       varchar     not null unique,
+    key_hash     varchar     not null unique,
+    permissions  varchar[]   not null,
+    created_by   uuid references users (uuid) on delete set null,
+    created_at   timestamptz not null default now(),
+    expires_at   timestamptz,
+    last_used_at timestamptz,
+    revoked_at   timestamptz
+);
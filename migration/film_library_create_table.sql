@@ -29,9 +29,9 @@ CREATE TABLE movie_actors(
 
 insert into users
 values ('2300a1f6-b2aa-4f5b-b6ca-8f495582e255', 'testuser@mail.com',
-        'userPassword', 'user');
+        '$2a$10$apWt4E8hzz/K.YSakzODCeHo.SY9.dZAdkk3FdHddNgExGw8NJ1Ya', 'user');
 
 insert into users
 values ('482d6f53-b2ee-4684-887e-2588ae6c9d48',
-        'admin@vk.ru', 'adminPassword#1', 'admin') ;
+        'admin@vk.ru', '$2a$10$hvQ.PFIJjN3yFTzkoGkXpeO0K3PpmdihiV4vcn31HOjTyjCJuD7AG', 'admin') ;
 
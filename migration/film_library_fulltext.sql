@@ -0,0 +1,10 @@
+-- Конфигурация russian разбирает латиницу английским стеммером, english добавляется для слов с диакритикой
+ALTER TABLE movies
+    ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('russian', coalesce(description, '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS movies_search_vector_idx ON movies USING gin (search_vector);
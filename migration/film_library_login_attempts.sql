@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS login_attempts(
+    kind            varchar     not null,
+    value           varchar     not null,
+    failures        int         not null,
+    last_failure_at timestamptz not null,
+    locked_until    timestamptz,
+    primary key (kind, value)
+);
@@ -0,0 +1,4 @@
+-- фильм без даты выхода хранится с NULL, раньше вместо NULL записывалась нулевая дата
+UPDATE movies
+SET release_date = NULL
+WHERE release_date = '0001-01-01';
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS oidc_states(
+    state_hash    varchar primary key,
+    nonce         varchar     not null,
+    code_verifier varchar     not null,
+    expires_at    timestamptz not null
+);
+
+CREATE TABLE IF NOT EXISTS user_identities(
+    issuer     varchar     not null,
+    subject    varchar     not null,
+    user_uuid  uuid        not null references users (uuid) on delete cascade,
+    created_at timestamptz not null default now(),
+    primary key (issuer, subject),
+    unique (issuer, user_uuid)
+);
@@ -0,0 +1,8 @@
+ALTER TABLE user_identities
+    ADD COLUMN IF NOT EXISTS provisioned boolean not null default false;
+
+-- пользователи без пароля могли появиться только при входе через провайдер
+UPDATE user_identities ui
+SET provisioned = true
+FROM users u
+WHERE u.uuid = ui.user_uuid AND u.password_algo = 'none';
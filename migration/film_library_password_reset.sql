@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS password_reset_tokens(
+    token_hash varchar primary key,
+    user_uuid  uuid        not null references users (uuid) on delete cascade,
+    created_at timestamptz not null default now(),
+    expires_at timestamptz not null,
+    used_at    timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS password_reset_tokens_user_idx ON password_reset_tokens (user_uuid);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens(
+    token_hash varchar primary key,
+    family_id  uuid        not null,
+    user_uuid  uuid        not null references users (uuid) on delete cascade,
+    expires_at timestamptz not null,
+    rotated_at timestamptz,
+    revoked_at timestamptz,
+    created_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens (family_id);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS revoked_tokens(
+    jti        varchar primary key,
+    user_uuid  uuid        not null references users (uuid) on delete cascade,
+    expires_at timestamptz not null,
+    revoked_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS tokens_revoked_at timestamptz;
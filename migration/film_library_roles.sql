@@ -0,0 +1,57 @@
+CREATE TABLE IF NOT EXISTS roles(
+    name        varchar primary key,
+    description text not null default ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions(
+    role       varchar not null references roles (name) on delete cascade on update cascade,
+    permission varchar not null,
+    primary key (role, permission)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles(
+    user_uuid uuid    not null references users (uuid) on delete cascade,
+    role      varchar not null references roles (name) on delete cascade on update cascade,
+    primary key (user_uuid, role)
+);
+
+INSERT INTO roles (name, description)
+VALUES ('user', 'просмотр фильмов и актеров'),
+       ('editor', 'просмотр и редактирование фильмов и актеров без удаления'),
+       ('admin', 'полный доступ')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission)
+VALUES ('user', 'movie:read'),
+       ('user', 'actor:read'),
+       ('editor', 'movie:read'),
+       ('editor', 'movie:write'),
+       ('editor', 'actor:read'),
+       ('editor', 'actor:write'),
+       ('admin', 'movie:read'),
+       ('admin', 'movie:write'),
+       ('admin', 'movie:delete'),
+       ('admin', 'actor:read'),
+       ('admin', 'actor:write'),
+       ('admin', 'actor:delete'),
+       ('admin', 'user:manage')
+ON CONFLICT DO NOTHING;
+
+-- роли из столбца users.role переносятся в user_roles, после чего столбец удаляется
+DO
+$$
+    BEGIN
+        IF EXISTS(SELECT 1
+                  FROM information_schema.columns
+                  WHERE table_name = 'users'
+                    AND column_name = 'role') THEN
+            INSERT INTO user_roles (user_uuid, role)
+            SELECT uuid, role
+            FROM users
+            WHERE role IN (SELECT name FROM roles)
+            ON CONFLICT DO NOTHING;
+            ALTER TABLE users
+                DROP COLUMN role;
+        END IF;
+    END
+$$;
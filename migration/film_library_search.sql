@@ -0,0 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS movies_name_trgm_idx ON movies USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS actors_name_trgm_idx ON actors USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS movie_actors_actor_name_trgm_idx ON movie_actors USING gin (actor_name gin_trgm_ops);
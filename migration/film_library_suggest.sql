@@ -0,0 +1,3 @@
+-- Индексы для поиска по началу названия без учета регистра оператором ^@
+CREATE INDEX IF NOT EXISTS movies_name_prefix_idx ON movies USING spgist (lower(name));
+CREATE INDEX IF NOT EXISTS actors_name_prefix_idx ON actors USING spgist (lower(name));
@@ -0,0 +1,7 @@
+ALTER TABLE movies
+    ADD COLUMN IF NOT EXISTS created_at timestamptz not null default now(),
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz not null default now();
+
+ALTER TABLE actors
+    ADD COLUMN IF NOT EXISTS created_at timestamptz not null default now(),
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz not null default now();
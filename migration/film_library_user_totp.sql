@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS user_totp(
+    user_uuid  uuid primary key references users (uuid) on delete cascade,
+    secret     varchar     not null,
+    enabled    boolean     not null default false,
+    last_step  bigint      not null default 0,
+    created_at timestamptz not null default now()
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes(
+    user_uuid uuid    not null references users (uuid) on delete cascade,
+    code_hash varchar not null,
+    used_at   timestamptz,
+    primary key (user_uuid, code_hash)
+);
@@ -0,0 +1,22 @@
+-- email хранится в нижнем регистре, адреса, различающиеся только регистром, нужно объединить вручную до миграции
+DO
+$$
+    DECLARE
+        duplicates text;
+    BEGIN
+        SELECT string_agg(email, ', ')
+        INTO duplicates
+        FROM (SELECT lower(email) AS email
+              FROM users
+              GROUP BY lower(email)
+              HAVING count(*) > 1) d;
+        IF duplicates IS NOT NULL THEN
+            RAISE EXCEPTION 'users differ only by email case: %', duplicates;
+        END IF;
+        UPDATE users
+        SET email = lower(email)
+        WHERE email <> lower(email);
+    END
+$$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_idx ON users (lower(email));
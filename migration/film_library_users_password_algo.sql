@@ -0,0 +1,8 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS password_algo varchar not null default 'plain';
+
+UPDATE users
+SET password_algo = CASE
+                        WHEN password ~ '^\$2[abxy]\$[0-9]{2}\$' THEN 'bcrypt'
+                        ELSE 'plain'
+    END;
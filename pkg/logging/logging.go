@@ -2,6 +2,7 @@ package logging
 
 import (
 	"fmt"
+	"github.com/ast3am/VKintern-movies/internal/models"
 	"github.com/rs/zerolog"
 	"io"
 	"net/http"
@@ -73,6 +74,7 @@ func (l *Logger) HandlerLog(r *http.Request, status int, msg string) {
 		Str("URL", r.RequestURI).
 		Str("from", r.RemoteAddr).
 		Str("status", code).
+		Func(principalFields(r)).
 		Msg(msg)
 }
 
@@ -83,6 +85,21 @@ func (l *Logger) HandlerErrorLog(r *http.Request, status int, msg string, err er
 		Str("URL", r.RequestURI).
 		Str("from", r.RemoteAddr).
 		Str("status", code).
+		Func(principalFields(r)).
 		Err(err).
 		Msg(msg)
 }
+
+// principalFields добавляет в запись лога, от чьего имени выполнен запрос, если он прошел аутентификацию
+func principalFields(r *http.Request) func(e *zerolog.Event) {
+	return func(e *zerolog.Event) {
+		principal, ok := models.PrincipalFromContext(r.Context())
+		if !ok {
+			return
+		}
+		e.Str("user", principal.UserID.String())
+		if principal.APIKeyID != nil {
+			e.Str("api_key", principal.APIKeyID.String())
+		}
+	}
+}